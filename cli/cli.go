@@ -0,0 +1,786 @@
+package cli
+
+import (
+	"GoDiag/modules"
+	"GoDiag/update"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK      = 0 // Everything ran successfully
+	ExitFailure = 1 // The command ran, but one or more collectors (or the action itself) failed or timed out
+	ExitUsage   = 2 // The command line could not be understood
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+const usageOverview = `Usage: godiag <command> [options]
+
+Commands:
+  list        List the available collectors
+  collect     Run collectors and write their reports to the output directory
+  bundle      Pack the output directory into a zip for support, optionally redacted
+  history     List, delete or prune past runs kept in the output directory
+  diff        Compare two runs (output directories, bundles or JSON reports)
+  flush-dns   Flush the DNS resolver cache
+  update      Check for a newer version of GoDiag and install it
+  version     Print the GoDiag version
+  help        Show this help
+
+Run 'godiag <command> -h' for the options of a command.
+`
+
+// Run executes the command line given in args (without the program name) and returns the
+// process exit code. It drives the modules package directly and never touches the GUI.
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usageOverview)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "list":
+		return runList(args[1:], stdout, stderr)
+	case "collect":
+		return runCollect(args[1:], stdout, stderr)
+	case "bundle":
+		return runBundle(args[1:], stdout, stderr)
+	case "history":
+		return runHistory(args[1:], stdout, stderr)
+	case "diff":
+		return runDiff(args[1:], stdout, stderr)
+	case "flush-dns":
+		return runFlushDNS(args[1:], stdout, stderr)
+	case "update":
+		return runUpdate(args[1:], stdout, stderr)
+	case "version":
+		fmt.Fprintln(stdout, modules.AppVersion())
+		return ExitOK
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usageOverview)
+		return ExitOK
+	default:
+		fmt.Fprintf(stderr, "godiag: unknown command %q\n\n%s", args[0], usageOverview)
+		return ExitUsage
+	}
+}
+
+// newFlagSet creates a flag set for a subcommand that reports errors instead of exiting.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("godiag "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parseFlags parses args into fs and returns the exit code to use if parsing stopped the command.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK, false
+		}
+		return ExitUsage, false
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected argument %q\n", fs.Arg(0))
+		return ExitUsage, false
+	}
+	return ExitOK, true
+}
+
+func validFormat(format string, stderr io.Writer) bool {
+	if format == formatText || format == formatJSON {
+		return true
+	}
+	fmt.Fprintf(stderr, "godiag: unknown format %q (expected %q or %q)\n", format, formatText, formatJSON)
+	return false
+}
+
+func runList(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("list", stderr)
+	format := fs.String("format", formatText, "output format: text or json")
+	profiles := fs.Bool("registry-profiles", false, "list the registry export profiles instead of the collectors")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if !validFormat(*format, stderr) {
+		return ExitUsage
+	}
+	if *profiles {
+		return listRegistryProfiles(*format, stdout, stderr)
+	}
+
+	collectors := modules.Collectors()
+	if *format == formatJSON {
+		type collectorInfo struct {
+			ID            string   `json:"id"`
+			Name          string   `json:"name"`
+			OutputFiles   []string `json:"output_files"`
+			RequiresAdmin bool     `json:"requires_admin"`
+			Disabled      bool     `json:"disabled,omitempty"` // Turned off by policy
+		}
+		infos := make([]collectorInfo, 0, len(collectors))
+		for _, c := range collectors {
+			infos = append(infos, collectorInfo{ID: c.ID(), Name: c.Name(), OutputFiles: c.OutputFiles(), RequiresAdmin: c.RequiresAdmin(),
+				Disabled: modules.CollectorDisabled(c.ID())})
+		}
+		return writeJSON(stdout, stderr, infos)
+	}
+
+	for _, c := range collectors {
+		admin := ""
+		if c.RequiresAdmin() {
+			admin = " [admin]"
+		}
+		if modules.CollectorDisabled(c.ID()) {
+			admin += " [disabled by policy]"
+		}
+		fmt.Fprintf(stdout, "%-12s %s%s\n", c.ID(), c.Name(), admin)
+		fmt.Fprintf(stdout, "%-12s -> %s\n", "", strings.Join(c.OutputFiles(), ", "))
+	}
+	return ExitOK
+}
+
+func listRegistryProfiles(format string, stdout, stderr io.Writer) int {
+	profiles := modules.RegistryProfiles()
+	if format == formatJSON {
+		return writeJSON(stdout, stderr, profiles)
+	}
+	for _, profile := range profiles {
+		fmt.Fprintf(stdout, "%-12s %s (%d keys)\n", profile.Name, profile.Description, len(profile.Keys))
+	}
+	return ExitOK
+}
+
+func runCollect(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("collect", stderr)
+	only := fs.String("only", "", "comma-separated collector IDs to run (default: all, see 'godiag list')")
+	out := fs.String("out", "", "output directory (default: the configured output directory)")
+	noHistory := fs.Bool("no-history", false, "write straight into the output directory instead of a new timestamped run folder")
+	format := fs.String("format", formatText, "summary format: text or json")
+	bundle := fs.Bool("zip", false, "pack the output directory into a GoDiag_<host>_<date>.zip with SHA-256 checksums")
+	redaction := redactionFlags(fs)
+	exportJSON := fs.Bool("json", false, "also write a .json file next to each report")
+	timeouts := fs.String("timeout", "", "timeout for every collector (e.g. 2m), or per collector (e.g. msinfo32=10m,network=30s)")
+	events := eventFlags(fs)
+	knownIssues := fs.String("known-issues", "", "additional known-issue knowledge base file to annotate events with")
+	rulesFile := fs.String("rules", "", "additional findings rules file")
+	registry := registryFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if !validFormat(*format, stderr) {
+		return ExitUsage
+	}
+	queries, err := events()
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: %v\n", err)
+		return ExitUsage
+	}
+	var issues []modules.KnownIssue
+	if *knownIssues != "" {
+		data, err := os.ReadFile(*knownIssues)
+		if err == nil {
+			issues, err = modules.ParseKnownIssues(data)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "godiag: %v\n", err)
+			return ExitUsage
+		}
+	}
+	var rules []modules.FindingRule
+	if *rulesFile != "" {
+		data, err := os.ReadFile(*rulesFile)
+		if err == nil {
+			rules, err = modules.ParseFindingRules(data)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "godiag: %v\n", err)
+			return ExitUsage
+		}
+	}
+	if err := registry(); err != nil {
+		fmt.Fprintf(stderr, "godiag: %v\n", err)
+		return ExitUsage
+	}
+	redact, err := redaction()
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: %v\n", err)
+		return ExitUsage
+	}
+	if redact != nil && !*bundle {
+		fmt.Fprintln(stderr, "godiag: --redact only applies together with --zip")
+		return ExitUsage
+	}
+
+	collectors, err := selectCollectors(*only)
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: %v\n", err)
+		return ExitUsage
+	}
+	if err := applyTimeouts(*timeouts, collectors); err != nil {
+		fmt.Fprintf(stderr, "godiag: %v\n", err)
+		return ExitUsage
+	}
+
+	if !setOutputDir(*out, stderr) {
+		return ExitUsage
+	}
+	if *exportJSON {
+		modules.SetJSONExport(true)
+	}
+	if queries != nil {
+		modules.SetEventQueries(queries)
+	}
+	if issues != nil {
+		modules.SetUserKnownIssues(issues)
+	}
+	if rules != nil {
+		modules.SetUserFindingRules(rules)
+	}
+	root, err := modules.EnsureOutputDir()
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: failed to create output directory: %v\n", err)
+		return ExitFailure
+	}
+	outputDir := root
+	if !*noHistory {
+		if outputDir, err = modules.NewRunDir(root); err != nil {
+			fmt.Fprintf(stderr, "godiag: %v\n", err)
+			return ExitFailure
+		}
+	}
+
+	// Ctrl+C stops the running collector and skips the rest, but still writes the manifest
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	manifest, err := modules.RunCollectors(ctx, outputDir, collectors,
+		func(index, total int, c modules.Collector, result *modules.CollectorResult) {
+			if result == nil {
+				fmt.Fprintf(stderr, "[%d/%d] %s...\n", index+1, total, c.ID())
+			} else if result.Error != "" {
+				fmt.Fprintf(stderr, "[%d/%d] %s %s: %s\n", index+1, total, c.ID(), result.Status, result.Error)
+			}
+		})
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: %v\n", err)
+		return ExitFailure
+	}
+	if !*noHistory {
+		removed, err := modules.RecordRun(root, manifest)
+		if err != nil {
+			fmt.Fprintf(stderr, "godiag: failed to record run history: %v\n", err)
+		}
+		for _, run := range removed {
+			fmt.Fprintf(stderr, "Deleted run %s (retention: %s)\n", run.ID, modules.GetRetentionPolicy())
+		}
+	}
+
+	if *format == formatJSON {
+		if code := writeJSON(stdout, stderr, manifest); code != ExitOK {
+			return code
+		}
+	} else {
+		for _, result := range manifest.Results {
+			fmt.Fprintf(stdout, "%-12s %-9s %6dms  %s\n", result.ID, result.Status, result.DurationMS, strings.Join(result.Files, ", "))
+		}
+		fmt.Fprintf(stdout, "\n%d succeeded, %d failed, %d timed out, %d skipped. Output written to %s\n",
+			manifest.Count(modules.StatusSuccess), manifest.Count(modules.StatusFailed), manifest.Count(modules.StatusTimedOut),
+			manifest.Count(modules.StatusSkipped), outputDir)
+		if len(manifest.Findings) > 0 {
+			fmt.Fprintf(stdout, "\nFindings (details in %s):\n", modules.FindingsFileName)
+			for _, finding := range manifest.Findings {
+				fmt.Fprintf(stdout, "  %-8s  %s\n", finding.Severity.Label(), finding.Title)
+			}
+		}
+	}
+
+	if *bundle {
+		bundlePath, err := modules.CreateBundle(ctx, outputDir, redact)
+		if err != nil {
+			fmt.Fprintf(stderr, "godiag: failed to create bundle: %v\n", err)
+			return ExitFailure
+		}
+		// Keep stdout parseable when it carries the JSON summary
+		if *format == formatJSON {
+			fmt.Fprintf(stderr, "Bundle written to %s\n", bundlePath)
+		} else {
+			fmt.Fprintf(stdout, "Bundle written to %s\n", bundlePath)
+		}
+	}
+
+	if manifest.Count(modules.StatusFailed) > 0 || manifest.Count(modules.StatusTimedOut) > 0 {
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+func runBundle(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("bundle", stderr)
+	out := fs.String("out", "", "output directory (default: the configured output directory)")
+	run := fs.String("run", "", "ID of the run to pack, see 'godiag history' (default: the most recent run)")
+	redaction := redactionFlags(fs)
+	preview := fs.Bool("preview", false, "list what --redact would hide instead of writing the bundle")
+	format := fs.String("format", formatText, "preview format: text or json")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if !validFormat(*format, stderr) {
+		return ExitUsage
+	}
+	redact, err := redaction()
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: %v\n", err)
+		return ExitUsage
+	}
+	if *preview && redact == nil {
+		fmt.Fprintln(stderr, "godiag: --preview needs --redact")
+		return ExitUsage
+	}
+
+	if !setOutputDir(*out, stderr) {
+		return ExitUsage
+	}
+	root, err := modules.EnsureOutputDir()
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: failed to create output directory: %v\n", err)
+		return ExitFailure
+	}
+	var outputDir string
+	if *run != "" {
+		outputDir, err = modules.RunDir(root, *run)
+	} else {
+		outputDir, err = modules.LatestRunDir(root)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: %v\n", err)
+		return ExitFailure
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *preview {
+		result, err := modules.PreviewRedaction(ctx, outputDir, *redact)
+		if err != nil {
+			fmt.Fprintf(stderr, "godiag: %v\n", err)
+			return ExitFailure
+		}
+		if *format == formatJSON {
+			return writeJSON(stdout, stderr, result)
+		}
+		var output bytes.Buffer
+		result.WriteText(&output)
+		stdout.Write(output.Bytes())
+		return ExitOK
+	}
+
+	bundlePath, err := modules.CreateBundle(ctx, outputDir, redact)
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: failed to create bundle: %v\n", err)
+		return ExitFailure
+	}
+	fmt.Fprintf(stdout, "Bundle written to %s\n", bundlePath)
+	return ExitOK
+}
+
+func runHistory(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("history", stderr)
+	out := fs.String("out", "", "output directory (default: the configured output directory)")
+	format := fs.String("format", formatText, "output format: text or json")
+	deleteRun := fs.String("delete", "", "ID of a run to delete")
+	prune := fs.Bool("prune", false, "delete the runs the retention policy no longer keeps")
+	keepRuns := fs.Int("keep", -1, "with --prune: keep only this many recent runs (0 for no limit; default: the configured policy)")
+	keepDays := fs.Int("keep-days", -1, "with --prune: delete runs older than this many days (0 for no limit; default: the configured policy)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if !validFormat(*format, stderr) {
+		return ExitUsage
+	}
+	if (*keepRuns >= 0 || *keepDays >= 0) && !*prune {
+		fmt.Fprintln(stderr, "godiag: --keep and --keep-days only apply together with --prune")
+		return ExitUsage
+	}
+
+	if !setOutputDir(*out, stderr) {
+		return ExitUsage
+	}
+	root, err := modules.EnsureOutputDir()
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: failed to create output directory: %v\n", err)
+		return ExitFailure
+	}
+
+	if *deleteRun != "" {
+		if err := modules.DeleteRun(root, *deleteRun); err != nil {
+			fmt.Fprintf(stderr, "godiag: %v\n", err)
+			return ExitFailure
+		}
+		fmt.Fprintf(stderr, "Deleted run %s\n", *deleteRun)
+	}
+	if *prune {
+		policy := modules.GetRetentionPolicy()
+		if *keepRuns >= 0 {
+			policy.KeepRuns = *keepRuns
+		}
+		if *keepDays >= 0 {
+			policy.KeepDays = *keepDays
+		}
+		modules.SetRetentionPolicy(policy)
+		removed, err := modules.PruneHistory(root)
+		if err != nil {
+			fmt.Fprintf(stderr, "godiag: %v\n", err)
+			return ExitFailure
+		}
+		fmt.Fprintf(stderr, "Deleted %d runs (retention: %s)\n", len(removed), policy)
+	}
+
+	runs, err := modules.LoadHistory(root)
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: %v\n", err)
+		return ExitFailure
+	}
+	if *format == formatJSON {
+		if runs == nil {
+			runs = []modules.RunRecord{}
+		}
+		return writeJSON(stdout, stderr, runs)
+	}
+	if len(runs) == 0 {
+		fmt.Fprintf(stdout, "No runs recorded in %s\n", root)
+		return ExitOK
+	}
+	for _, run := range runs {
+		fmt.Fprintf(stdout, "%-20s %-9s %s  %s  %d findings  %s\n", run.ID, run.Outcome,
+			run.Started.Local().Format("2006-01-02 15:04"), run.HostName, run.Findings, strings.Join(run.Collectors, ","))
+	}
+	return ExitOK
+}
+
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("diff", stderr)
+	format := fs.String("format", formatText, "output format: text or json")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: godiag diff [options] OLD NEW")
+		fmt.Fprintln(fs.Output(), "OLD and NEW are output directories, bundles (.zip) or JSON reports collected with --json.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return ExitUsage
+	}
+	if !validFormat(*format, stderr) {
+		return ExitUsage
+	}
+
+	var snapshots []*modules.Snapshot
+	for _, source := range fs.Args() {
+		snapshot, err := modules.LoadSnapshot(source)
+		if err != nil {
+			fmt.Fprintf(stderr, "godiag: %v\n", err)
+			return ExitFailure
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	diff := modules.CompareSnapshots(snapshots[0], snapshots[1])
+	if *format == formatJSON {
+		return writeJSON(stdout, stderr, diff)
+	}
+	var output bytes.Buffer
+	diff.WriteText(&output)
+	stdout.Write(output.Bytes())
+	return ExitOK
+}
+
+func runFlushDNS(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("flush-dns", stderr)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := modules.FlushDNSCache(ctx); err != nil {
+		fmt.Fprintf(stderr, "godiag: %v\n", err)
+		return ExitFailure
+	}
+	fmt.Fprintln(stdout, "DNS cache flushed successfully.")
+	return ExitOK
+}
+
+func runUpdate(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("update", stderr)
+	channelName := fs.String("channel", string(update.DefaultChannel()), "update channel: stable or beta; the one picked in the settings by default")
+	checkOnly := fs.Bool("check", false, "only report whether a newer version is available")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	channel, err := update.ParseChannel(*channelName)
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: %v\n", err)
+		return ExitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	available, err := update.Check(ctx, update.ManifestURL, modules.AppVersion(), channel)
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: failed to check for updates: %v\n", err)
+		return ExitFailure
+	}
+	if available == nil {
+		fmt.Fprintf(stdout, "GoDiag %s is the latest version.\n", modules.AppVersion())
+		return ExitOK
+	}
+	fmt.Fprintf(stdout, "GoDiag %s is available (running %s).\n", available.Version, modules.AppVersion())
+	if available.ReleaseNotes != "" {
+		fmt.Fprintf(stdout, "\n%s\n\n", available.ReleaseNotes)
+	}
+	if *checkOnly {
+		return ExitOK
+	}
+
+	publicKey, err := update.ParsePublicKey(update.ReleasePublicKey)
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: %v\n", err)
+		return ExitFailure
+	}
+	installer := &update.Installer{PublicKey: publicKey, Probe: update.ProbeVersion(available.Version)}
+	exe, err := installer.Install(ctx, available.VersionInfo)
+	if errors.Is(err, update.ErrNoAsset) {
+		fmt.Fprintf(stderr, "godiag: %v; download it from %s\n", err, available.URL)
+		return ExitFailure
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "godiag: %v\n", err)
+		return ExitFailure
+	}
+	fmt.Fprintf(stdout, "Updated %s to %s.\n", exe, available.Version)
+	return ExitOK
+}
+
+// selectCollectors resolves a comma-separated list of collector IDs. An empty list selects all collectors.
+func selectCollectors(only string) ([]modules.Collector, error) {
+	if strings.TrimSpace(only) == "" {
+		return modules.Collectors(), nil
+	}
+
+	var collectors []modules.Collector
+	seen := map[string]bool{}
+	for _, id := range strings.Split(only, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		c, ok := modules.LookupCollector(id)
+		if !ok {
+			return nil, fmt.Errorf("unknown collector %q (see 'godiag list')", id)
+		}
+		seen[id] = true
+		collectors = append(collectors, c)
+	}
+	return collectors, nil
+}
+
+// applyTimeouts parses the --timeout flag. A bare duration applies to every selected collector;
+// otherwise it is a comma-separated list of id=duration pairs.
+func applyTimeouts(spec string, collectors []modules.Collector) error {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil
+	}
+
+	if !strings.Contains(spec, "=") {
+		timeout, err := time.ParseDuration(spec)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout %q", spec)
+		}
+		for _, c := range collectors {
+			modules.SetCollectorTimeout(c.ID(), timeout)
+		}
+		return nil
+	}
+
+	for _, pair := range strings.Split(spec, ",") {
+		id, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return fmt.Errorf("invalid timeout %q (expected id=duration)", pair)
+		}
+		if _, known := modules.LookupCollector(id); !known {
+			return fmt.Errorf("unknown collector %q in --timeout (see 'godiag list')", id)
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout %q for collector %q", value, id)
+		}
+		modules.SetCollectorTimeout(id, timeout)
+	}
+	return nil
+}
+
+// redactionFlags adds the --redact and --redact-mode flags to fs. The returned function, called
+// after parsing, yields the requested redaction or nil when --redact was not given.
+func redactionFlags(fs *flag.FlagSet) func() (*modules.RedactionConfig, error) {
+	categories := fs.String("redact", "", "redact the bundle: \"all\" or a comma-separated list of serials, mac, ip, usernames, dns_cache")
+	mode := fs.String("redact-mode", string(modules.RedactMask), "replace redacted values with numbered tokens (mask) or salted hashes (hash)")
+	salt := fs.String("redact-salt", "", "salt for --redact-mode hash; pass the same salt to --preview and the bundle to get the same tokens (default: random per bundle)")
+	return func() (*modules.RedactionConfig, error) {
+		if *categories == "" {
+			return nil, nil
+		}
+		parsed, err := modules.ParseRedactionCategories(*categories)
+		if err != nil {
+			return nil, err
+		}
+		config := &modules.RedactionConfig{Categories: parsed, Mode: modules.RedactionMode(*mode), Salt: *salt}
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}
+}
+
+// eventFlags adds the --events preset flag and the --event-* flags for a custom query to fs.
+// The returned function, called after parsing, yields the queries to run, or nil to keep the
+// configured ones.
+func eventFlags(fs *flag.FlagSet) func() ([]modules.EventQuery, error) {
+	presetNames := make([]string, 0, len(modules.EventPresets()))
+	for _, preset := range modules.EventPresets() {
+		presetNames = append(presetNames, preset.Name)
+	}
+	preset := fs.String("events", "", "event log preset: "+strings.Join(presetNames, ", "))
+	channels := fs.String("event-channels", "", "custom event query: comma-separated channels (default System)")
+	levels := fs.String("event-levels", "", "custom event query: \"all\" or a comma-separated list of critical, error, warning, information, verbose (default critical,error,warning)")
+	since := fs.String("event-since", "", "custom event query: only events newer than this, e.g. 24h or 7d")
+	providers := fs.String("event-providers", "", "custom event query: comma-separated event sources to keep")
+	eventIDs := fs.String("event-ids", "", "custom event query: comma-separated event IDs to keep, e.g. 41,6008")
+	maxEvents := fs.Int("event-max", 0, fmt.Sprintf("custom event query: newest events to read per channel (default %d)", modules.DefaultMaxEvents))
+	return func() ([]modules.EventQuery, error) {
+		custom := *channels != "" || *levels != "" || *since != "" || *providers != "" || *eventIDs != "" || *maxEvents != 0
+		if !custom {
+			if *preset == "" {
+				return nil, nil
+			}
+			found, ok := modules.LookupEventPreset(*preset)
+			if !ok {
+				return nil, fmt.Errorf("unknown event preset %q (expected one of %s)", *preset, strings.Join(presetNames, ", "))
+			}
+			return found.Queries, nil
+		}
+		if *preset != "" && *preset != modules.EventPresetCustom {
+			return nil, fmt.Errorf("--events %s cannot be combined with the --event-* flags", *preset)
+		}
+
+		query := modules.EventQuery{Channels: modules.SplitList(*channels), Since: *since, Providers: modules.SplitList(*providers), MaxEvents: *maxEvents}
+		if len(query.Channels) == 0 {
+			query.Channels = []string{"System"}
+		}
+		ids, err := modules.ParseEventIDs(*eventIDs)
+		if err != nil {
+			return nil, err
+		}
+		query.EventIDs = ids
+		levelSpec := *levels
+		if levelSpec == "" {
+			levelSpec = "critical,error,warning"
+		}
+		parsed, err := modules.ParseEventLevels(levelSpec)
+		if err != nil {
+			return nil, err
+		}
+		query.Levels = parsed
+		if err := query.Validate(); err != nil {
+			return nil, err
+		}
+		return []modules.EventQuery{query}, nil
+	}
+}
+
+// registryFlags adds the registry export options to fs. The returned function, called after
+// parsing, loads the profiles file if one was given and replaces the configured profiles and
+// keys with those given, then checks that every selected profile exists.
+func registryFlags(fs *flag.FlagSet) func() error {
+	profilesFile := fs.String("profiles-file", "", "additional registry profiles file")
+	profiles := fs.String("registry-profile", "", "comma-separated registry profiles to export, see 'godiag list --registry-profiles'")
+	var keys []modules.RegistryKey
+	fs.Func("registry-key", `additional registry key to export, as "Name=HKLM\\Path" or a path (repeatable)`, func(spec string) error {
+		key, err := modules.ParseRegistryKey(spec)
+		if err == nil {
+			keys = append(keys, key)
+		}
+		return err
+	})
+
+	return func() error {
+		if *profilesFile != "" {
+			data, err := os.ReadFile(*profilesFile)
+			if err != nil {
+				return err
+			}
+			userProfiles, err := modules.ParseRegistryProfiles(data)
+			if err != nil {
+				return err
+			}
+			modules.SetUserRegistryProfiles(userProfiles)
+		}
+
+		selected, configuredKeys := modules.RegistryExportSelection()
+		if *profiles != "" {
+			selected = modules.SplitList(*profiles)
+		}
+		if keys == nil {
+			keys = configuredKeys
+		}
+		modules.SetRegistryExport(selected, keys)
+		_, err := modules.RegistryKeysToExport()
+		return err
+	}
+}
+
+// setOutputDir applies the --out flag, which cannot be used while a machine policy forces the
+// output directory.
+func setOutputDir(out string, stderr io.Writer) bool {
+	if out == "" {
+		return true
+	}
+	if managed := modules.ManagedOutputDir(); managed != "" {
+		fmt.Fprintf(stderr, "godiag: --out cannot be used, the output directory is set to %s by your organization\n", managed)
+		return false
+	}
+	modules.SetCustomOutputDir(out)
+	return true
+}
+
+func writeJSON(stdout, stderr io.Writer, v interface{}) int {
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintf(stderr, "godiag: failed to write JSON: %v\n", err)
+		return ExitFailure
+	}
+	return ExitOK
+}
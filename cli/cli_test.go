@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"GoDiag/modules"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// commandCollector saves the output of one command, which the tests answer from testdata/replay,
+// so that collect can be run on any platform with a collector that succeeds and one that fails.
+type commandCollector struct {
+	id      string
+	command []string
+}
+
+func (c commandCollector) ID() string            { return c.id }
+func (c commandCollector) Name() string          { return "Command " + c.id }
+func (c commandCollector) OutputFiles() []string { return []string{c.id + ".txt"} }
+func (c commandCollector) RequiresAdmin() bool   { return false }
+
+func (c commandCollector) Run(ctx context.Context, outputDir string) error {
+	output, err := modules.GetCommandRunner().Output(ctx, c.command[0], c.command[1:]...)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputDir, c.id+".txt"), output, 0644)
+}
+
+func init() {
+	modules.Register(commandCollector{"clitest-pass", []string{"selftest", "pass"}})
+	modules.Register(commandCollector{"clitest-fail", []string{"selftest", "fail"}})
+}
+
+// run runs the command line with the commands answered from testdata/replay and returns the exit
+// code and what was written to stdout and stderr.
+func run(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	modules.SetCommandRunner(modules.NewReplayRunner(filepath.Join("testdata", "replay")))
+	defer modules.SetCommandRunner(nil)
+	defer modules.SetCustomOutputDir(modules.GetCustomOutputDir())
+
+	var stdout, stderr bytes.Buffer
+	code := Run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stderr string // Part of what is written to stderr
+	}{
+		{"no command", nil, ExitUsage, "Usage: godiag <command>"},
+		{"unknown command", []string{"collet"}, ExitUsage, `unknown command "collet"`},
+		{"unknown flag", []string{"list", "--all"}, ExitUsage, "flag provided but not defined: -all"},
+		{"unexpected argument", []string{"collect", "network"}, ExitUsage, `unexpected argument "network"`},
+		{"unknown collector", []string{"collect", "--only", "clitest-pass,floppy"}, ExitUsage, `unknown collector "floppy"`},
+		{"invalid format", []string{"collect", "--only", "clitest-pass", "--format", "xml"}, ExitUsage, `unknown format "xml"`},
+		{"invalid list format", []string{"list", "--format", "yaml"}, ExitUsage, `unknown format "yaml"`},
+		{"redact without zip", []string{"collect", "--only", "clitest-pass", "--redact", "all"}, ExitUsage, "--redact only applies together with --zip"},
+		{"unknown redaction", []string{"collect", "--only", "clitest-pass", "--zip", "--redact", "passwords"}, ExitUsage, "passwords"},
+		{"invalid timeout", []string{"collect", "--only", "clitest-pass", "--timeout", "clitest-pass=soon"}, ExitUsage, "soon"},
+		{"collector succeeds", []string{"collect", "--only", "clitest-pass"}, ExitOK, "[1/1] clitest-pass..."},
+		{"collector fails", []string{"collect", "--only", "clitest-pass,clitest-fail"}, ExitFailure, "[2/2] clitest-fail failed: exit status 1"},
+		{"help", []string{"collect", "-h"}, ExitOK, "-profiles-file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if len(args) > 0 && args[0] == "collect" {
+				args = append(args, "--out", t.TempDir())
+			}
+			code, _, stderr := run(t, args...)
+			if code != tt.code {
+				t.Errorf("exit code %d, want %d; stderr:\n%s", code, tt.code, stderr)
+			}
+			if !strings.Contains(stderr, tt.stderr) {
+				t.Errorf("stderr does not contain %q:\n%s", tt.stderr, stderr)
+			}
+		})
+	}
+}
+
+func TestRunCollectJSON(t *testing.T) {
+	out := t.TempDir()
+	code, stdout, stderr := run(t, "collect", "--only", "clitest-fail,clitest-pass", "--format", "json", "--no-history", "--out", out)
+	if code != ExitFailure {
+		t.Errorf("exit code %d, want %d; stderr:\n%s", code, ExitFailure, stderr)
+	}
+
+	// stdout holds nothing but the manifest
+	var manifest modules.RunManifest
+	if err := json.Unmarshal([]byte(stdout), &manifest); err != nil {
+		t.Fatalf("stdout is not a run manifest: %v\n%s", err, stdout)
+	}
+	statuses := map[string]modules.CollectorStatus{}
+	for _, result := range manifest.Results {
+		statuses[result.ID] = result.Status
+	}
+	if statuses["clitest-pass"] != modules.StatusSuccess || statuses["clitest-fail"] != modules.StatusFailed {
+		t.Errorf("statuses = %v, want clitest-pass succeeded and clitest-fail failed", statuses)
+	}
+	if manifest.OutputDir != out {
+		t.Errorf("output directory = %s, want %s", manifest.OutputDir, out)
+	}
+	if data, err := os.ReadFile(filepath.Join(out, "clitest-pass.txt")); err != nil || string(data) != "selftest: all checks passed\n" {
+		t.Errorf("clitest-pass.txt holds %q, %v", data, err)
+	}
+}
+
+func TestRunList(t *testing.T) {
+	code, stdout, stderr := run(t, "list", "--format", "json")
+	if code != ExitOK {
+		t.Fatalf("exit code %d; stderr:\n%s", code, stderr)
+	}
+	var collectors []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &collectors); err != nil {
+		t.Fatal(err)
+	}
+	if len(collectors) != len(modules.Collectors()) {
+		t.Errorf("listed %d collectors, want %d", len(collectors), len(modules.Collectors()))
+	}
+
+	code, stdout, _ = run(t, "list", "--registry-profiles")
+	if code != ExitOK || !strings.Contains(stdout, modules.DefaultRegistryProfile) {
+		t.Errorf("list --registry-profiles = %d:\n%s", code, stdout)
+	}
+}
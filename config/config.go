@@ -0,0 +1,435 @@
+package config
+
+import (
+	"GoDiag/modules"
+	"GoDiag/update"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileName is the name of the settings file in Dir.
+const FileName = "settings.json"
+
+// CurrentSchemaVersion is the layout Save writes. Files written before the field existed are
+// version 1; Load migrates older files forward.
+const CurrentSchemaVersion = 2
+
+// FormatJSON adds a .json file per collector to the text, HTML and godiag_report.json reports
+// every run writes.
+const FormatJSON = "json"
+
+// ReportFormats returns the optional report formats.
+func ReportFormats() []string {
+	return []string{FormatJSON}
+}
+
+// Settings are the user's preferences, saved as settings.json.
+type Settings struct {
+	SchemaVersion int `json:"schema_version"`
+
+	RPCEnabled        bool              `json:"rpc_enabled"`
+	SelectedOutputDir string            `json:"selected_output_dir"` // The default output directory when empty; relative to the executable in portable mode
+	ReportFormats     []string          `json:"report_formats"`      // Optional formats written on top of text and HTML
+	EnabledCollectors []string          `json:"enabled_collectors"`  // IDs run by "Run All Diagnostics"; every collector when empty
+	Timeouts          map[string]string `json:"timeouts,omitempty"`  // Collector ID to a duration such as "2m"
+
+	RedactBundles bool                    `json:"redact_bundles"`
+	Redaction     modules.RedactionConfig `json:"redaction"`
+
+	EventPreset  string             `json:"event_preset"`
+	CustomEvents modules.EventQuery `json:"custom_events"` // Used when EventPreset is "custom"
+
+	Retention modules.RetentionPolicy `json:"retention"`
+
+	RegistryProfiles []string              `json:"registry_profiles"`
+	RegistryKeys     []modules.RegistryKey `json:"registry_keys,omitempty"` // Exported on top of the profiles
+
+	UpdateChannel update.Channel `json:"update_channel,omitempty"` // Stable when empty
+}
+
+// defaultCustomEvents is the custom event query offered before the user has built one.
+var defaultCustomEvents = modules.EventQuery{
+	Channels: []string{"System", "Application"},
+	Levels:   []modules.EventLevel{modules.LevelCritical, modules.LevelError, modules.LevelWarning},
+	Since:    "24h",
+}
+
+// Defaults returns the settings used before the user has changed anything. Fields missing from
+// a settings file keep these values.
+func Defaults() Settings {
+	return Settings{
+		SchemaVersion:     CurrentSchemaVersion,
+		ReportFormats:     []string{},
+		EnabledCollectors: []string{},
+		Redaction:         modules.DefaultRedactionConfig(),
+		EventPreset:       modules.EventPresetDefault,
+		CustomEvents:      defaultCustomEvents,
+		Retention:         modules.DefaultRetentionPolicy(),
+		RegistryProfiles:  []string{modules.DefaultRegistryProfile},
+		UpdateChannel:     update.ChannelStable,
+	}
+}
+
+// Load reads the settings file at path, migrates it to the current schema and checks it, without
+// touching the file or anything else on disk. It always returns usable settings: a missing file
+// yields the defaults and no error. A file that cannot be parsed yields the defaults and an
+// error; Save keeps it with an ".invalid" suffix rather than overwrite it. Invalid options are
+// reset to their defaults, and the error then lists them. The output directory is not checked
+// here: a folder that is unavailable, such as one on a disconnected drive, is reported by
+// Validate and when a run starts.
+func Load(path string) (Settings, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Defaults(), nil
+	}
+	if err != nil {
+		return Defaults(), fmt.Errorf("failed to read settings: %w", err)
+	}
+
+	settings, err := parse(data)
+	if err != nil {
+		return Defaults(), fmt.Errorf("%w; the default settings are used and the file will be kept as %s.invalid when they are saved", err, FileName)
+	}
+	if problems := settings.sanitize(); len(problems) > 0 {
+		return settings, fmt.Errorf("some settings were reset to their defaults: %w", errors.Join(problems...))
+	}
+	return settings, nil
+}
+
+// parse decodes a settings file of any schema version onto the defaults.
+func parse(data []byte) (Settings, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Settings{}, fmt.Errorf("failed to parse settings: %w", err)
+	}
+	version := 1
+	if raw, ok := fields["schema_version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return Settings{}, fmt.Errorf("failed to parse settings: invalid schema_version %s", raw)
+		}
+	}
+	if version < 1 {
+		return Settings{}, fmt.Errorf("failed to parse settings: invalid schema_version %d", version)
+	}
+	if version > CurrentSchemaVersion {
+		return Settings{}, fmt.Errorf("settings were saved by a newer version of GoDiag (schema %d, this version reads up to %d)", version, CurrentSchemaVersion)
+	}
+	for ; version < CurrentSchemaVersion; version++ {
+		if err := migrations[version](fields); err != nil {
+			return Settings{}, fmt.Errorf("failed to migrate settings from schema %d: %w", version, err)
+		}
+	}
+	delete(fields, "schema_version")
+
+	migrated, err := json.Marshal(fields)
+	if err != nil {
+		return Settings{}, err
+	}
+	settings := Defaults()
+	if err := json.Unmarshal(migrated, &settings); err != nil {
+		return Settings{}, fmt.Errorf("failed to parse settings: %w", err)
+	}
+	return settings, nil
+}
+
+// migrations[v] turns the fields of a schema v file into those of schema v+1.
+var migrations = map[int]func(fields map[string]json.RawMessage) error{
+	1: migrateV1,
+}
+
+// migrateV1 replaces the export_json switch with report_formats, and drops the empty redaction
+// mode and event preset that files saved before those options existed can hold, so that the
+// defaults apply instead.
+func migrateV1(fields map[string]json.RawMessage) error {
+	if raw, ok := fields["export_json"]; ok {
+		var exportJSON bool
+		if err := json.Unmarshal(raw, &exportJSON); err != nil {
+			return fmt.Errorf("export_json: %w", err)
+		}
+		formats := []string{}
+		if exportJSON {
+			formats = append(formats, FormatJSON)
+		}
+		fields["report_formats"], _ = json.Marshal(formats)
+		delete(fields, "export_json")
+	}
+	if raw, ok := fields["redaction"]; ok {
+		var redaction modules.RedactionConfig
+		if json.Unmarshal(raw, &redaction) == nil && redaction.Mode == "" {
+			delete(fields, "redaction")
+		}
+	}
+	if raw, ok := fields["event_preset"]; ok && string(raw) == `""` {
+		delete(fields, "event_preset")
+	}
+	return nil
+}
+
+// check is one option's validation, with the way to reset the option if it fails.
+type check struct {
+	validate func() error
+	reset    func()
+}
+
+func (s *Settings) checks() []check {
+	defaults := Defaults()
+	return []check{
+		{s.validateReportFormats, func() { s.ReportFormats = defaults.ReportFormats }},
+		{s.validateCollectors, func() { s.EnabledCollectors = defaults.EnabledCollectors }},
+		{s.validateTimeouts, func() { s.Timeouts = defaults.Timeouts }},
+		{s.Redaction.Validate, func() { s.Redaction = defaults.Redaction }},
+		{s.validateEvents, func() { s.EventPreset, s.CustomEvents = defaults.EventPreset, defaults.CustomEvents }},
+		{s.Retention.Validate, func() { s.Retention = defaults.Retention }},
+		{s.validateRegistryKeys, func() { s.RegistryKeys = defaults.RegistryKeys }},
+		{s.validateUpdateChannel, func() { s.UpdateChannel = defaults.UpdateChannel }},
+	}
+}
+
+// Validate checks every option and returns all the problems found. Unlike Load, it also checks
+// that the selected output directory can be created and written to, creating it if needed.
+func (s Settings) Validate() error {
+	var problems []error
+	for _, c := range s.checks() {
+		if err := c.validate(); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	if err := s.validateOutputDir(); err != nil {
+		problems = append(problems, err)
+	}
+	return errors.Join(problems...)
+}
+
+// validateOutputDir checks the selected output directory. It is not one of the checks Load
+// applies, as Load leaves the disk alone and a folder on a drive that is not connected right
+// now should be kept rather than reset.
+func (s Settings) validateOutputDir() error {
+	if s.SelectedOutputDir == "" {
+		return nil
+	}
+	return modules.CheckOutputDir(s.OutputDir())
+}
+
+// sanitize resets every invalid option to its default and returns the problems found.
+func (s *Settings) sanitize() []error {
+	var problems []error
+	for _, c := range s.checks() {
+		if err := c.validate(); err != nil {
+			problems = append(problems, err)
+			c.reset()
+		}
+	}
+	return problems
+}
+
+func (s *Settings) validateReportFormats() error {
+	for _, format := range s.ReportFormats {
+		if format != FormatJSON {
+			return fmt.Errorf("unknown report format %q", format)
+		}
+	}
+	return nil
+}
+
+func (s *Settings) validateCollectors() error {
+	for _, id := range s.EnabledCollectors {
+		if _, ok := modules.LookupCollector(id); !ok {
+			return fmt.Errorf("unknown collector %q in enabled collectors", id)
+		}
+	}
+	return nil
+}
+
+func (s *Settings) validateTimeouts() error {
+	for id, value := range s.Timeouts {
+		if _, ok := modules.LookupCollector(id); !ok {
+			return fmt.Errorf("unknown collector %q in timeouts", id)
+		}
+		if timeout, err := time.ParseDuration(value); err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout %q for collector %q", value, id)
+		}
+	}
+	return nil
+}
+
+func (s *Settings) validateEvents() error {
+	if s.EventPreset == modules.EventPresetCustom {
+		return s.CustomEvents.Validate()
+	}
+	if _, ok := modules.LookupEventPreset(s.EventPreset); !ok {
+		return fmt.Errorf("unknown event preset %q", s.EventPreset)
+	}
+	return nil
+}
+
+func (s *Settings) validateRegistryKeys() error {
+	for _, key := range s.RegistryKeys {
+		if err := key.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Settings) validateUpdateChannel() error {
+	_, err := update.ParseChannel(string(s.UpdateChannel))
+	return err
+}
+
+// ParseTimeouts parses collector timeouts written as a comma-separated list of id=duration
+// pairs, such as "network=2m, eventlogs=5m".
+func ParseTimeouts(spec string) (map[string]string, error) {
+	timeouts := map[string]string{}
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		id, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid timeout %q (expected id=duration)", strings.TrimSpace(pair))
+		}
+		timeouts[strings.TrimSpace(id)] = strings.TrimSpace(value)
+	}
+	settings := Settings{Timeouts: timeouts}
+	if err := settings.validateTimeouts(); err != nil {
+		return nil, err
+	}
+	return timeouts, nil
+}
+
+// HasFormat reports whether the optional report format is turned on.
+func (s Settings) HasFormat(format string) bool {
+	for _, f := range s.ReportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// SetFormat turns the optional report format on or off.
+func (s *Settings) SetFormat(format string, enabled bool) {
+	formats := []string{}
+	for _, f := range s.ReportFormats {
+		if f != format {
+			formats = append(formats, f)
+		}
+	}
+	if enabled {
+		formats = append(formats, format)
+	}
+	s.ReportFormats = formats
+}
+
+// Collectors returns the collectors "Run All Diagnostics" runs, sorted by ID. Collectors
+// turned off by policy are left out.
+func (s Settings) Collectors() []modules.Collector {
+	enabled := make(map[string]bool)
+	for _, id := range s.EnabledCollectors {
+		enabled[id] = true
+	}
+	var collectors []modules.Collector
+	for _, c := range modules.Collectors() {
+		if (len(enabled) == 0 || enabled[c.ID()]) && !modules.CollectorDisabled(c.ID()) {
+			collectors = append(collectors, c)
+		}
+	}
+	return collectors
+}
+
+// OutputDir returns the selected output directory as an absolute path, or an empty string when
+// the default is used.
+func (s Settings) OutputDir() string {
+	return resolvePath(s.SelectedOutputDir)
+}
+
+// Apply passes the settings to the modules package, which reads them while collecting.
+func (s Settings) Apply() {
+	modules.SetDefaultOutputDir(DefaultOutputDir())
+	modules.SetCustomOutputDir(s.OutputDir())
+	modules.SetJSONExport(s.HasFormat(FormatJSON))
+	s.ApplyEvents()
+	modules.SetRetentionPolicy(s.Retention)
+	update.SetDefaultChannel(s.UpdateChannel)
+	modules.SetRegistryExport(s.RegistryProfiles, s.RegistryKeys)
+	for _, c := range modules.Collectors() {
+		timeout, _ := time.ParseDuration(s.Timeouts[c.ID()]) // Zero, removing any override, when unset
+		modules.SetCollectorTimeout(c.ID(), timeout)
+	}
+}
+
+// ApplyEvents passes the selected event preset, or the custom query, to the event log collector.
+func (s Settings) ApplyEvents() {
+	if s.EventPreset == modules.EventPresetCustom {
+		modules.SetEventQueries([]modules.EventQuery{s.CustomEvents})
+		return
+	}
+	preset, ok := modules.LookupEventPreset(s.EventPreset)
+	if !ok {
+		preset, _ = modules.LookupEventPreset(modules.EventPresetDefault)
+	}
+	modules.SetEventQueries(preset.Queries)
+}
+
+// Save writes the settings to path atomically: they are written to a temporary file in the same
+// folder, which then replaces the settings file, so a crash or full disk never leaves a
+// half-written file behind. A settings file Load could not parse is kept with an ".invalid"
+// suffix instead of being replaced.
+func (s Settings) Save(path string) error {
+	s.SchemaVersion = CurrentSchemaVersion
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create settings directory: %w", err)
+	}
+	file, err := os.CreateTemp(dir, ".settings-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	tmp := file.Name()
+	defer os.Remove(tmp) // Already gone once it has replaced the settings file
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	if err := keepInvalid(path); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	return nil
+}
+
+// keepInvalid renames the settings file at path with an ".invalid" suffix if it cannot be parsed,
+// so the user can still recover it once Save has written the new settings.
+func keepInvalid(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil // Nothing to keep; a file that cannot be read is reported by Load
+	}
+	if _, err := parse(data); err == nil {
+		return nil
+	}
+	if err := os.Rename(path, path+".invalid"); err != nil {
+		return fmt.Errorf("failed to keep the unreadable settings file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"GoDiag/modules"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeSettings writes data as a settings file in a new folder and returns its path.
+func writeSettings(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// folderNames returns the names of the files in dir.
+func folderNames(t *testing.T, dir string) string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return strings.Join(names, " ")
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	settings, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(settings, Defaults()) {
+		t.Errorf("Load() = %+v, want the defaults", settings)
+	}
+}
+
+func TestLoadMigratesV1(t *testing.T) {
+	path := writeSettings(t, `{
+		"rpc_enabled": true,
+		"export_json": true,
+		"enabled_collectors": ["network", "bios"],
+		"redaction": {"mode": ""},
+		"event_preset": ""
+	}`)
+	settings, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Defaults()
+	want.RPCEnabled = true
+	want.ReportFormats = []string{FormatJSON}
+	want.EnabledCollectors = []string{"network", "bios"}
+	if !reflect.DeepEqual(settings, want) {
+		t.Errorf("Load() = %+v\nwant %+v", settings, want)
+	}
+}
+
+func TestLoadResetsInvalidOptions(t *testing.T) {
+	path := writeSettings(t, `{
+		"schema_version": 2,
+		"rpc_enabled": true,
+		"report_formats": ["pdf"],
+		"enabled_collectors": ["network", "floppy"],
+		"timeouts": {"network": "soon"},
+		"update_channel": "nightly"
+	}`)
+	settings, err := Load(path)
+	if err == nil {
+		t.Fatal("Load() reported no problems")
+	}
+	for _, problem := range []string{`"pdf"`, `"floppy"`, `"soon"`, `"nightly"`} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("error %q does not mention %s", err, problem)
+		}
+	}
+	// Only the invalid options are reset
+	want := Defaults()
+	want.RPCEnabled = true
+	if !reflect.DeepEqual(settings, want) {
+		t.Errorf("Load() = %+v\nwant %+v", settings, want)
+	}
+}
+
+func TestLoadKeepsUnavailableOutputDir(t *testing.T) {
+	// A folder on a drive that is not connected right now
+	missing := filepath.Join(t.TempDir(), "unplugged", "GoDiag")
+	quoted, _ := json.Marshal(missing)
+	path := writeSettings(t, `{"schema_version": 2, "selected_output_dir": `+string(quoted)+`}`)
+
+	settings, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.OutputDir() != missing {
+		t.Errorf("output directory = %q, want %q", settings.OutputDir(), missing)
+	}
+	if _, err := os.Stat(filepath.Dir(missing)); !os.IsNotExist(err) {
+		t.Errorf("Load() created the output directory: %v", err)
+	}
+}
+
+func TestLoadUnparseable(t *testing.T) {
+	for name, data := range map[string]string{
+		"malformed":   `{"rpc_enabled": tru`,
+		"newer":       `{"schema_version": 99}`,
+		"schema zero": `{"schema_version": 0}`,
+		"negative":    `{"schema_version": -1}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := writeSettings(t, data)
+			settings, err := Load(path)
+			if err == nil {
+				t.Fatal("Load() succeeded")
+			}
+			if !reflect.DeepEqual(settings, Defaults()) {
+				t.Errorf("Load() = %+v, want the defaults", settings)
+			}
+			// Loading leaves the file alone
+			if got := folderNames(t, filepath.Dir(path)); got != FileName {
+				t.Errorf("folder holds %s, want %s", got, FileName)
+			}
+
+			// Saving keeps it next to the new settings
+			if err := settings.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := folderNames(t, filepath.Dir(path)), FileName+" "+FileName+".invalid"; got != want {
+				t.Errorf("folder holds %s, want %s", got, want)
+			}
+			if kept, err := os.ReadFile(path + ".invalid"); err != nil || string(kept) != data {
+				t.Errorf("kept %q, %v; want the unparseable file", kept, err)
+			}
+			if _, err := Load(path); err != nil {
+				t.Errorf("Load() after Save() = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateOutputDir(t *testing.T) {
+	settings := Defaults()
+	if err := settings.Validate(); err != nil {
+		t.Errorf("Validate() of the defaults = %v", err)
+	}
+
+	settings.SelectedOutputDir = filepath.Join(t.TempDir(), "reports", "GoDiag")
+	if err := settings.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+	if info, err := os.Stat(settings.SelectedOutputDir); err != nil || !info.IsDir() {
+		t.Errorf("Validate() did not create the output directory: %v", err)
+	}
+
+	// A file where a parent folder should be cannot be created on any platform
+	file := filepath.Join(t.TempDir(), "drive")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	settings.SelectedOutputDir = filepath.Join(file, "GoDiag")
+	settings.ReportFormats = []string{"pdf"}
+	err := settings.Validate()
+	if err == nil || !strings.Contains(err.Error(), "cannot be created") || !strings.Contains(err.Error(), `"pdf"`) {
+		t.Errorf("Validate() = %v, want both the output directory and the report format reported", err)
+	}
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", FileName)
+	settings := Defaults()
+	settings.RPCEnabled = true
+	settings.SetFormat(FormatJSON, true)
+	settings.Timeouts = map[string]string{"network": "2m"}
+	if err := settings.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	// Saving again replaces the valid file without keeping a copy
+	if err := settings.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := folderNames(t, filepath.Dir(path)); got != FileName {
+		t.Errorf("folder holds %s, want %s", got, FileName)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, settings) {
+		t.Errorf("Load() = %+v\nwant %+v", loaded, settings)
+	}
+}
+
+func TestCollectorsSortedByID(t *testing.T) {
+	settings := Defaults()
+	settings.EnabledCollectors = []string{"network", "drivers", "bios"}
+	var ids []string
+	for _, c := range settings.Collectors() {
+		ids = append(ids, c.ID())
+	}
+	if want := []string{"bios", "drivers", "network"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Collectors() = %q, want %q", ids, want)
+	}
+	if all := Defaults().Collectors(); len(all) != len(modules.Collectors()) {
+		t.Errorf("no enabled collectors runs %d collectors, want all %d", len(all), len(modules.Collectors()))
+	}
+}
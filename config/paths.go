@@ -0,0 +1,136 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// PortableMarker is the file that, placed next to the executable, turns on portable mode: the
+// settings, the user's other configuration files and the output are all kept next to the
+// executable, so GoDiag can be carried on a thumb drive without leaving anything behind.
+const PortableMarker = "GoDiag.portable"
+
+// outputFolderName is the name of the default output folder.
+const outputFolderName = "DiagnosticsFiles"
+
+var (
+	pathsOnce sync.Once
+	exeDir    string // Folder of the executable; empty if it cannot be found
+	portable  bool
+	configDir string
+)
+
+// resolvePaths finds the executable and the configuration folder once per process.
+func resolvePaths() {
+	pathsOnce.Do(func() {
+		if exe, err := os.Executable(); err == nil {
+			if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+				exe = resolved
+			}
+			exeDir = filepath.Dir(exe)
+			_, err := os.Stat(filepath.Join(exeDir, PortableMarker))
+			portable = err == nil
+		}
+		if portable {
+			configDir = exeDir
+			return
+		}
+		configDir = userConfigDir()
+	})
+}
+
+// userConfigDir returns the GoDiag folder in the user's configuration directory. Versions before
+// it was used kept their files in %LOCALAPPDATA%\GoDiag, which is moved there the first time, or
+// kept using if it cannot be moved. Without a usable configuration directory, as on some
+// locked-down profiles, the temporary directory is used.
+func userConfigDir() string {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "GoDiag")
+	}
+	dir := filepath.Join(base, "GoDiag")
+
+	localAppData := os.Getenv("LOCALAPPDATA")
+	if localAppData == "" {
+		return dir
+	}
+	legacy := filepath.Join(localAppData, "GoDiag")
+	if strings.EqualFold(filepath.Clean(legacy), filepath.Clean(dir)) || !exists(legacy) || exists(dir) {
+		return dir
+	}
+	if err := os.MkdirAll(base, os.ModePerm); err != nil {
+		return legacy
+	}
+	if err := os.Rename(legacy, dir); err != nil {
+		return legacy
+	}
+	return dir
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Portable reports whether GoDiag runs in portable mode; see PortableMarker.
+func Portable() bool {
+	resolvePaths()
+	return portable
+}
+
+// Dir returns the folder holding settings.json and the user's other configuration files, such
+// as known_issues.json: the folder of the executable in portable mode, and a GoDiag folder in
+// the user's configuration directory otherwise.
+func Dir() string {
+	resolvePaths()
+	return configDir
+}
+
+// Path returns the path of settings.json.
+func Path() string {
+	return filepath.Join(Dir(), FileName)
+}
+
+// DefaultOutputDir returns where runs are written when no output directory is selected: a
+// DiagnosticsFiles folder next to the executable in portable mode, and in the user's cache
+// directory otherwise. Output written by versions before this one, in
+// %LOCALAPPDATA%\Temp\DiagnosticsFiles, stays where it is and keeps being used.
+func DefaultOutputDir() string {
+	if Portable() {
+		return filepath.Join(exeDir, outputFolderName)
+	}
+	if localAppData := os.Getenv("LOCALAPPDATA"); localAppData != "" {
+		if legacy := filepath.Join(localAppData, "Temp", outputFolderName); exists(legacy) {
+			return legacy
+		}
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), outputFolderName)
+	}
+	return filepath.Join(base, "GoDiag", outputFolderName)
+}
+
+// PortablePath returns path as it should be saved in the settings. In portable mode, a path on
+// the drive GoDiag runs from is made relative to the executable, so that it still points to the
+// same place when the drive gets another letter on the next machine. Other paths are returned
+// unchanged.
+func PortablePath(path string) string {
+	if !Portable() || !filepath.IsAbs(path) || !strings.EqualFold(filepath.VolumeName(path), filepath.VolumeName(exeDir)) {
+		return path
+	}
+	if rel, err := filepath.Rel(exeDir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// resolvePath turns a path saved by PortablePath back into an absolute one.
+func resolvePath(path string) string {
+	if path == "" || filepath.IsAbs(path) || !Portable() {
+		return path
+	}
+	return filepath.Join(exeDir, path)
+}
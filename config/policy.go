@@ -0,0 +1,228 @@
+package config
+
+import (
+	"GoDiag/modules"
+	"GoDiag/update"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PolicyFileName is the name of the machine-wide policy file; see PolicyPath.
+const PolicyFileName = "policy.json"
+
+// Policy is what an administrator enforces on every user of a machine. It overrides the user's
+// settings without changing them, so lifting the policy brings the user's own choices back.
+type Policy struct {
+	OutputDir           string                      `json:"output_dir,omitempty"`           // Every run is written here
+	DisabledCollectors  []string                    `json:"disabled_collectors,omitempty"`  // IDs of collectors that never run
+	RequireRedaction    bool                        `json:"require_redaction,omitempty"`    // Every zip bundle is redacted
+	RedactionCategories []modules.RedactionCategory `json:"redaction_categories,omitempty"` // Redacted at least; every category when empty
+	DisableRPC          bool                        `json:"disable_rpc,omitempty"`          // Discord Rich Presence stays off
+	DisableUpdateCheck  bool                        `json:"disable_update_check,omitempty"` // No update checks or installs
+
+	Sources []string `json:"-"` // The file and registry key the policy was read from
+}
+
+// Managed reports whether the policy locks anything.
+func (p Policy) Managed() bool {
+	return p.OutputDir != "" || len(p.DisabledCollectors) > 0 || p.RequireRedaction || p.DisableRPC || p.DisableUpdateCheck
+}
+
+// CollectorDisabled reports whether the policy turns off the collector with the given ID.
+func (p Policy) CollectorDisabled(id string) bool {
+	for _, disabled := range p.DisabledCollectors {
+		if disabled == id {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiredRedaction returns the categories every bundle must hide, or nil when redaction is not
+// required.
+func (p Policy) RequiredRedaction() []modules.RedactionCategory {
+	if !p.RequireRedaction {
+		return nil
+	}
+	if len(p.RedactionCategories) == 0 {
+		return modules.RedactionCategories()
+	}
+	return p.RedactionCategories
+}
+
+// sanitize drops the parts of the policy that cannot be enforced as written and returns the
+// problems found. Unknown redaction categories widen the requirement to every category rather
+// than weakening it; unknown collector IDs are kept, since one policy may cover platforms with
+// different collectors.
+func (p *Policy) sanitize() []error {
+	var problems []error
+	if p.OutputDir != "" && !filepath.IsAbs(p.OutputDir) {
+		problems = append(problems, fmt.Errorf("policy output directory %q is not an absolute path", p.OutputDir))
+		p.OutputDir = ""
+	}
+	if err := (modules.RedactionConfig{Mode: modules.RedactMask, Categories: p.RedactionCategories}).Validate(); err != nil {
+		problems = append(problems, fmt.Errorf("policy: %w", err))
+		p.RedactionCategories = nil
+	}
+	return problems
+}
+
+// merge adds other on top of p: other's output directory wins, and everything else adds up, so
+// that the result is at least as strict as either.
+func (p *Policy) merge(other Policy) {
+	if other.OutputDir != "" {
+		p.OutputDir = other.OutputDir
+	}
+	for _, id := range other.DisabledCollectors {
+		if !p.CollectorDisabled(id) {
+			p.DisabledCollectors = append(p.DisabledCollectors, id)
+		}
+	}
+	switch {
+	case !other.RequireRedaction:
+	case !p.RequireRedaction:
+		p.RedactionCategories = other.RedactionCategories
+	case len(p.RedactionCategories) == 0 || len(other.RedactionCategories) == 0:
+		p.RedactionCategories = nil // One of them requires every category
+	default:
+		p.RedactionCategories = append(p.RedactionCategories, other.RedactionCategories...)
+	}
+	p.RequireRedaction = p.RequireRedaction || other.RequireRedaction
+	p.DisableRPC = p.DisableRPC || other.DisableRPC
+	p.DisableUpdateCheck = p.DisableUpdateCheck || other.DisableUpdateCheck
+	p.Sources = append(p.Sources, other.Sources...)
+}
+
+// ParsePolicy parses a policy file.
+func ParsePolicy(data []byte) (Policy, error) {
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return Policy{}, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	return policy, nil
+}
+
+// LoadPolicyFile reads a policy file. A missing file is not an error and yields an empty policy.
+func LoadPolicyFile(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Policy{}, nil
+	}
+	if err != nil {
+		return Policy{}, err
+	}
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return Policy{}, fmt.Errorf("%s: %w", path, err)
+	}
+	policy.Sources = []string{path}
+	return policy, nil
+}
+
+// PolicyPath returns the path of the machine-wide policy file: policy.json in
+// %ProgramData%\GoDiag on Windows and in /etc/godiag elsewhere.
+func PolicyPath() string {
+	return policyPath()
+}
+
+// LoadPolicy reads the machine-wide policy file and, on Windows, the policy registry key (see
+// PolicyKey), which is applied on top of the file. Like Load it always returns a usable policy:
+// when a source cannot be read, or part of it is invalid, the error says so and the rest is
+// still enforced.
+func LoadPolicy() (Policy, error) {
+	var problems []error
+	policy, err := LoadPolicyFile(PolicyPath())
+	if err != nil {
+		problems = append(problems, err)
+	}
+	registry, err := readRegistryPolicy()
+	if err != nil {
+		problems = append(problems, err)
+	}
+	policy.merge(registry)
+	problems = append(problems, policy.sanitize()...)
+	return policy, errors.Join(problems...)
+}
+
+// Apply passes the policy to the modules and update packages, which enforce it.
+func (p Policy) Apply() {
+	modules.SetManagedOutputDir(p.OutputDir)
+	modules.SetDisabledCollectors(p.DisabledCollectors)
+	modules.SetRequiredRedaction(p.RequiredRedaction())
+	update.SetDisabled(p.DisableUpdateCheck)
+}
+
+// regValuePattern matches a value line in `reg query` output: "    Name    REG_SZ    Data".
+var regValuePattern = regexp.MustCompile(`^\s{4}(.*?)\s{4}(REG_[A-Z_]+)(?:\s{4}(.*))?$`)
+
+// envPattern matches a %VARIABLE% reference in a REG_EXPAND_SZ value.
+var envPattern = regexp.MustCompile(`%([^%]+)%`)
+
+// parseRegistryPolicy reads a policy from the `reg query` output of the policy key. Values are
+// named like the policy's fields: OutputDir (REG_SZ or REG_EXPAND_SZ), DisabledCollectors
+// (REG_MULTI_SZ, or a comma-separated REG_SZ), RedactionCategories (comma-separated REG_SZ) and
+// the REG_DWORD switches RequireRedaction, DisableRPC and DisableUpdateCheck. Other values are
+// ignored.
+func parseRegistryPolicy(output string) (Policy, error) {
+	var policy Policy
+	var problems []error
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		m := regValuePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name, kind, data := m[1], m[2], m[3]
+		if kind == "REG_EXPAND_SZ" {
+			data = envPattern.ReplaceAllStringFunc(data, func(ref string) string {
+				if value, ok := os.LookupEnv(strings.Trim(ref, "%")); ok {
+					return value
+				}
+				return ref
+			})
+		}
+
+		switch strings.ToLower(name) {
+		case "outputdir":
+			policy.OutputDir = data
+		case "disabledcollectors":
+			policy.DisabledCollectors = registryList(data)
+		case "redactioncategories":
+			for _, category := range registryList(data) {
+				policy.RedactionCategories = append(policy.RedactionCategories, modules.RedactionCategory(category))
+			}
+		case "requireredaction", "disablerpc", "disableupdatecheck":
+			n, err := strconv.ParseUint(data, 0, 32)
+			if kind != "REG_DWORD" || err != nil {
+				problems = append(problems, fmt.Errorf("policy value %s must be a REG_DWORD", name))
+				continue
+			}
+			switch strings.ToLower(name) {
+			case "requireredaction":
+				policy.RequireRedaction = n != 0
+			case "disablerpc":
+				policy.DisableRPC = n != 0
+			case "disableupdatecheck":
+				policy.DisableUpdateCheck = n != 0
+			}
+		}
+	}
+	return policy, errors.Join(problems...)
+}
+
+// registryList splits a REG_MULTI_SZ value, which `reg query` shows separated by "\0", or a
+// comma-separated REG_SZ value, dropping empty entries.
+func registryList(data string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(strings.ReplaceAll(data, `\0`, ","), func(r rune) bool { return r == ',' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
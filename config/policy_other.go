@@ -0,0 +1,12 @@
+//go:build !windows
+
+package config
+
+func policyPath() string {
+	return "/etc/godiag/" + PolicyFileName
+}
+
+// readRegistryPolicy has no registry to read outside Windows.
+func readRegistryPolicy() (Policy, error) {
+	return Policy{}, nil
+}
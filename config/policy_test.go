@@ -0,0 +1,221 @@
+package config
+
+import (
+	"GoDiag/modules"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRegistryPolicy(t *testing.T) {
+	t.Setenv("GODIAG_TEST_SHARE", `\\fileserver\diag`)
+	output := "\r\nHKEY_LOCAL_MACHINE\\SOFTWARE\\Policies\\GoDiag\r\n" +
+		"    OutputDir    REG_EXPAND_SZ    %GODIAG_TEST_SHARE%\\%GODIAG_TEST_UNSET%\r\n" +
+		"    DisabledCollectors    REG_MULTI_SZ    dxdiag\\0event_logs\\0\r\n" +
+		"    redactioncategories    REG_SZ    usernames, ip\r\n" +
+		"    RequireRedaction    REG_DWORD    0x1\r\n" +
+		"    DisableRPC    REG_DWORD    0x0\r\n" +
+		"    DISABLEUPDATECHECK    REG_DWORD    0x1\r\n" +
+		"    Comment    REG_SZ    Set by the IT department\r\n\r\n"
+	got, err := parseRegistryPolicy(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Unset variables are left as they are
+	want := Policy{
+		OutputDir:           `\\fileserver\diag\%GODIAG_TEST_UNSET%`,
+		DisabledCollectors:  []string{"dxdiag", "event_logs"},
+		RedactionCategories: []modules.RedactionCategory{modules.RedactUserNames, modules.RedactIP},
+		RequireRedaction:    true,
+		DisableUpdateCheck:  true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRegistryPolicy() = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseRegistryPolicyTypes(t *testing.T) {
+	tests := []struct {
+		name, line string
+		want       Policy
+		wantErr    string
+	}{
+		{"string", "    OutputDir    REG_SZ    D:\\Diagnostics", Policy{OutputDir: `D:\Diagnostics`}, ""},
+		{"comma list", "    DisabledCollectors    REG_SZ    dxdiag,,startup_programs ", Policy{DisabledCollectors: []string{"dxdiag", "startup_programs"}}, ""},
+		{"empty list", "    DisabledCollectors    REG_MULTI_SZ", Policy{}, ""},
+		{"decimal switch", "    DisableRPC    REG_DWORD    1", Policy{DisableRPC: true}, ""},
+		{"switch as string", "    DisableRPC    REG_SZ    1", Policy{}, "policy value DisableRPC must be a REG_DWORD"},
+		{"unreadable switch", "    RequireRedaction    REG_DWORD    yes", Policy{}, "policy value RequireRedaction must be a REG_DWORD"},
+		{"not a value", "OutputDir    REG_SZ    D:\\Diagnostics", Policy{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRegistryPolicy(tt.line + "\r\n")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRegistryPolicy() = %+v, want %+v", got, tt.want)
+			}
+			if (err == nil) != (tt.wantErr == "") || err != nil && err.Error() != tt.wantErr {
+				t.Errorf("parseRegistryPolicy() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A bad switch does not stop the other values from being read
+	got, err := parseRegistryPolicy("    DisableRPC    REG_SZ    1\r\n    DisableUpdateCheck    REG_DWORD    0x1\r\n")
+	if err == nil || !got.DisableUpdateCheck {
+		t.Errorf("parseRegistryPolicy() = %+v, %v; want the valid switch and an error", got, err)
+	}
+}
+
+func TestPolicyMerge(t *testing.T) {
+	usernames, ip := modules.RedactUserNames, modules.RedactIP
+	tests := []struct {
+		name        string
+		base, other Policy
+		want        Policy
+	}{
+		{
+			"other output directory wins",
+			Policy{OutputDir: "/srv/file", Sources: []string{"policy.json"}},
+			Policy{OutputDir: "/srv/registry", Sources: []string{`HKLM\SOFTWARE\Policies\GoDiag`}},
+			Policy{OutputDir: "/srv/registry", Sources: []string{"policy.json", `HKLM\SOFTWARE\Policies\GoDiag`}},
+		},
+		{
+			"empty other keeps the output directory",
+			Policy{OutputDir: "/srv/file"},
+			Policy{},
+			Policy{OutputDir: "/srv/file"},
+		},
+		{
+			"collectors add up",
+			Policy{DisabledCollectors: []string{"dxdiag", "event_logs"}},
+			Policy{DisabledCollectors: []string{"event_logs", "startup_programs"}},
+			Policy{DisabledCollectors: []string{"dxdiag", "event_logs", "startup_programs"}},
+		},
+		{
+			"switches add up",
+			Policy{DisableRPC: true},
+			Policy{DisableUpdateCheck: true},
+			Policy{DisableRPC: true, DisableUpdateCheck: true},
+		},
+		{
+			"redaction required by other only",
+			Policy{RedactionCategories: []modules.RedactionCategory{usernames}},
+			Policy{RequireRedaction: true, RedactionCategories: []modules.RedactionCategory{ip}},
+			Policy{RequireRedaction: true, RedactionCategories: []modules.RedactionCategory{ip}},
+		},
+		{
+			"redaction not required by other",
+			Policy{RequireRedaction: true, RedactionCategories: []modules.RedactionCategory{usernames}},
+			Policy{RedactionCategories: []modules.RedactionCategory{ip}},
+			Policy{RequireRedaction: true, RedactionCategories: []modules.RedactionCategory{usernames}},
+		},
+		{
+			"categories add up",
+			Policy{RequireRedaction: true, RedactionCategories: []modules.RedactionCategory{usernames}},
+			Policy{RequireRedaction: true, RedactionCategories: []modules.RedactionCategory{ip}},
+			Policy{RequireRedaction: true, RedactionCategories: []modules.RedactionCategory{usernames, ip}},
+		},
+		{
+			"every category wins",
+			Policy{RequireRedaction: true, RedactionCategories: []modules.RedactionCategory{usernames}},
+			Policy{RequireRedaction: true},
+			Policy{RequireRedaction: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.base
+			got.merge(tt.other)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merge() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicySanitize(t *testing.T) {
+	dir := t.TempDir()
+	policy := Policy{OutputDir: dir, RequireRedaction: true, RedactionCategories: []modules.RedactionCategory{modules.RedactIP}}
+	if problems := policy.sanitize(); problems != nil {
+		t.Errorf("sanitize() of a valid policy = %v", problems)
+	}
+
+	// A relative directory is dropped; unknown categories widen redaction to every category
+	policy = Policy{OutputDir: "diagnostics", RequireRedaction: true, RedactionCategories: []modules.RedactionCategory{modules.RedactIP, "passwords"}}
+	problems := policy.sanitize()
+	if len(problems) != 2 || !strings.Contains(problems[0].Error(), `"diagnostics" is not an absolute path`) ||
+		!strings.HasPrefix(problems[1].Error(), "policy: ") {
+		t.Errorf("sanitize() = %v, want the output directory and category problems", problems)
+	}
+	if want := (Policy{RequireRedaction: true}); !reflect.DeepEqual(policy, want) {
+		t.Errorf("sanitized policy = %+v, want %+v", policy, want)
+	}
+	if got := policy.RequiredRedaction(); !reflect.DeepEqual(got, modules.RedactionCategories()) {
+		t.Errorf("RequiredRedaction() = %v, want every category", got)
+	}
+}
+
+func TestPolicyLocks(t *testing.T) {
+	if (Policy{}).Managed() {
+		t.Error("an empty policy is managed")
+	}
+	if (Policy{Sources: []string{"policy.json"}, RedactionCategories: []modules.RedactionCategory{modules.RedactIP}}).Managed() {
+		t.Error("a policy that locks nothing is managed")
+	}
+	for _, policy := range []Policy{
+		{OutputDir: "/srv/diag"},
+		{DisabledCollectors: []string{"dxdiag"}},
+		{RequireRedaction: true},
+		{DisableRPC: true},
+		{DisableUpdateCheck: true},
+	} {
+		if !policy.Managed() {
+			t.Errorf("%+v is not managed", policy)
+		}
+	}
+
+	policy := Policy{DisabledCollectors: []string{"dxdiag"}}
+	if !policy.CollectorDisabled("dxdiag") || policy.CollectorDisabled("event_logs") {
+		t.Errorf("CollectorDisabled() does not match %v", policy.DisabledCollectors)
+	}
+	// Categories only count when redaction is required
+	policy = Policy{RedactionCategories: []modules.RedactionCategory{modules.RedactIP}}
+	if got := policy.RequiredRedaction(); got != nil {
+		t.Errorf("RequiredRedaction() = %v, want nil", got)
+	}
+	policy.RequireRedaction = true
+	if got, want := policy.RequiredRedaction(), []modules.RedactionCategory{modules.RedactIP}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RequiredRedaction() = %v, want %v", got, want)
+	}
+}
+
+func TestPolicyOverridesSettings(t *testing.T) {
+	defer Policy{}.Apply()
+	defer modules.SetCustomOutputDir(modules.GetCustomOutputDir())
+
+	settings := Settings{SelectedOutputDir: filepath.Join(t.TempDir(), "mine")}
+	modules.SetCustomOutputDir(settings.OutputDir())
+	managed := filepath.Join(t.TempDir(), "managed")
+	Policy{OutputDir: managed, DisabledCollectors: []string{"dxdiag"}, RequireRedaction: true}.Apply()
+
+	if got := modules.OutputDir(); got != managed {
+		t.Errorf("OutputDir() = %q, want the policy's %q", got, managed)
+	}
+	if !modules.CollectorDisabled("dxdiag") {
+		t.Error("the policy's collector is not disabled")
+	}
+	if got := modules.RequiredRedaction(); !reflect.DeepEqual(got, modules.RedactionCategories()) {
+		t.Errorf("RequiredRedaction() = %v, want every category", got)
+	}
+
+	// Lifting the policy brings the user's own choice back
+	Policy{}.Apply()
+	if got := modules.OutputDir(); got != settings.OutputDir() {
+		t.Errorf("OutputDir() = %q, want the user's %q", got, settings.OutputDir())
+	}
+	if modules.CollectorDisabled("dxdiag") || modules.RequiredRedaction() != nil {
+		t.Error("the lifted policy is still enforced")
+	}
+}
@@ -0,0 +1,52 @@
+//go:build windows
+
+package config
+
+import (
+	"GoDiag/modules"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// PolicyKey is the registry key Group Policy, or any other deployment tool, sets policy values
+// under; see parseRegistryPolicy for the values read.
+const PolicyKey = `HKLM\SOFTWARE\Policies\GoDiag`
+
+func policyPath() string {
+	programData := os.Getenv("ProgramData")
+	if programData == "" {
+		programData = `C:\ProgramData`
+	}
+	return filepath.Join(programData, "GoDiag", PolicyFileName)
+}
+
+// readRegistryPolicy reads the policy key. A missing key is an empty policy.
+func readRegistryPolicy() (Policy, error) {
+	// reg says a key is missing only in the system's language, so look for the key first
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, strings.TrimPrefix(PolicyKey, `HKLM\`), registry.QUERY_VALUE)
+	if errors.Is(err, registry.ErrNotExist) {
+		return Policy{}, nil
+	}
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to read %s: %w", PolicyKey, err)
+	}
+	key.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	output, err := modules.GetCommandRunner().CombinedOutput(ctx, "reg", "query", PolicyKey)
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to read %s: %v: %s", PolicyKey, err, strings.TrimSpace(string(output)))
+	}
+	policy, err := parseRegistryPolicy(string(output))
+	policy.Sources = []string{PolicyKey}
+	return policy, err
+}
@@ -5,6 +5,7 @@ go 1.23.2
 require (
 	fyne.io/fyne/v2 v2.5.2
 	github.com/altfoxie/drpc v0.0.0-20240929140334-e714e6291275
+	golang.org/x/sys v0.20.0
 )
 
 require (
@@ -35,7 +36,6 @@ require (
 	golang.org/x/image v0.18.0 // indirect
 	golang.org/x/mobile v0.0.0-20231127183840-76ac6878050a // indirect
 	golang.org/x/net v0.25.0 // indirect
-	golang.org/x/sys v0.20.0 // indirect
 	golang.org/x/text v0.16.0 // indirect
 	gopkg.in/natefinch/npipe.v2 v2.0.0-20160621034901-c1b8fa8bdcce // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
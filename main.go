@@ -1,152 +1,615 @@
 package main
 
 import (
+	"GoDiag/cli"
+	"GoDiag/config"
 	"GoDiag/modules"
 	"GoDiag/rpc"
-	"encoding/json"
+	"GoDiag/update"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
-	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/widget"
 
 	sqdialog "github.com/sqweek/dialog"
 )
 
-const (
-	currentVersion = "1.0.9"
-	updateCheckURL = "https://raw.githubusercontent.com/LewdLillyVT/godiag/refs/heads/main/version.json"
-)
+const currentVersion = "1.0.9"
 
-type VersionInfo struct {
-	Version string `json:"version"`
-	URL     string `json:"url"`
+// checkForUpdate looks for a release newer than this one on the selected update channel. It
+// returns nil when GoDiag is up to date.
+func checkForUpdate(channel update.Channel) (*update.Update, error) {
+	return update.Check(context.Background(), update.ManifestURL, currentVersion, channel)
 }
 
-type Settings struct {
-	RPCEnabled        bool   `json:"rpc_enabled"`
-	SelectedOutputDir string `json:"selected_output_dir"`
-}
+// promptForUpdate offers to open the release page of a newer version, showing its release notes.
+// Required updates, those that are mandatory or raise the minimum supported version above this
+// one, say so.
+func promptForUpdate(available *update.Update, myWindow fyne.Window) {
+	parsedURL, err := url.Parse(available.URL) // Parse the URL string
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("invalid update URL: %s", available.URL), myWindow)
+		return
+	}
 
-const settingsFileName = "settings.json"
+	title := "Update Available"
+	message := fmt.Sprintf("A new version (%s) is available. You are running %s.", available.Version, currentVersion)
+	if available.Required {
+		title = "Update Required"
+		message = fmt.Sprintf("Version %s is required; %s is no longer supported.", available.Version, currentVersion)
+	}
+	content := container.NewVBox(widget.NewLabel(message))
+	if available.ReleaseNotes != "" {
+		notes := widget.NewLabel(available.ReleaseNotes)
+		notes.Wrapping = fyne.TextWrapWord
+		notesScroll := container.NewVScroll(notes)
+		notesScroll.SetMinSize(fyne.NewSize(400, 150))
+		content.Add(widget.NewLabelWithStyle("What's new:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		content.Add(notesScroll)
+	}
 
-func checkForUpdate() (*VersionInfo, error) {
-	resp, err := http.Get(updateCheckURL)
+	dialog.ShowCustomConfirm(title, "Update Now", "Later", content,
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if _, ok := available.AssetFor(runtime.GOOS, runtime.GOARCH); ok {
+				installUpdate(available, myWindow)
+				return
+			}
+			// Releases without a download for this system are installed by hand
+			err := fyne.CurrentApp().OpenURL(parsedURL) // Pass the *url.URL type here
+			if err != nil {
+				dialog.ShowError(err, myWindow)
+			}
+		},
+		myWindow,
+	)
+}
+
+// installUpdate downloads and verifies the update in the background, swaps it in for the running
+// executable and offers to restart into it. Closing the progress dialog cancels the download.
+func installUpdate(available *update.Update, myWindow fyne.Window) {
+	publicKey, err := update.ParsePublicKey(update.ReleasePublicKey)
 	if err != nil {
-		return nil, err
+		dialog.ShowError(err, myWindow)
+		return
+	}
+
+	progressBar := widget.NewProgressBar()
+	ctx, cancel := context.WithCancel(context.Background())
+	progressDialog := dialog.NewCustom("Updating GoDiag", "Cancel",
+		container.NewVBox(widget.NewLabel(fmt.Sprintf("Downloading GoDiag %s...", available.Version)), progressBar), myWindow)
+	progressDialog.SetOnClosed(cancel)
+	progressDialog.Resize(fyne.NewSize(350, 0))
+	progressDialog.Show()
+
+	installer := &update.Installer{
+		PublicKey: publicKey,
+		Probe:     update.ProbeVersion(available.Version),
+		Progress: func(done, total int64) {
+			if total > 0 {
+				progressBar.SetValue(float64(done) / float64(total))
+			}
+		},
 	}
-	defer resp.Body.Close()
+	go func() {
+		exe, err := installer.Install(ctx, available.VersionInfo)
+		progressDialog.Hide()
+
+		switch {
+		case ctx.Err() != nil:
+			// Cancelled by the user; the current version is untouched
+		case err != nil:
+			dialog.ShowError(fmt.Errorf("failed to update GoDiag, the current version is still installed: %w", err), myWindow)
+		default:
+			dialog.ShowConfirm("Update Installed", fmt.Sprintf("GoDiag %s has been installed. Restart now?", available.Version),
+				func(restart bool) {
+					if !restart {
+						return
+					}
+					if err := update.Relaunch(exe); err != nil {
+						dialog.ShowError(err, myWindow)
+						return
+					}
+					myWindow.Close()
+				}, myWindow)
+		}
+	}()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch version info: %s", resp.Status)
+// saveSettings writes the settings to settings.json.
+func saveSettings(settings config.Settings) error {
+	return settings.Save(config.Path())
+}
+
+// loadKnownIssues loads the user's additions to the known-issue knowledge base, kept next to
+// the settings file. Not having any is fine.
+func loadKnownIssues() error {
+	issues, err := modules.LoadKnownIssuesFile(filepath.Join(config.Dir(), modules.KnownIssuesFileName))
+	if err != nil {
+		return err
 	}
+	modules.SetUserKnownIssues(issues)
+	return nil
+}
 
-	data, err := ioutil.ReadAll(resp.Body)
+// loadRegistryProfiles loads the user's registry export profiles, kept next to the settings
+// file. Not having any is fine.
+func loadRegistryProfiles() error {
+	profiles, err := modules.LoadRegistryProfilesFile(filepath.Join(config.Dir(), modules.RegistryProfilesFileName))
 	if err != nil {
-		return nil, err
+		return err
 	}
+	modules.SetUserRegistryProfiles(profiles)
+	return nil
+}
 
-	var versionInfo VersionInfo
-	err = json.Unmarshal(data, &versionInfo)
+// loadFindingRules loads the user's findings rules, kept next to the settings file. Not having
+// any is fine.
+func loadFindingRules() error {
+	rules, err := modules.LoadFindingRulesFile(filepath.Join(config.Dir(), modules.FindingRulesFileName))
 	if err != nil {
-		return nil, err
+		return err
 	}
+	modules.SetUserFindingRules(rules)
+	return nil
+}
 
-	return &versionInfo, nil
+// findingsView lists the findings of a run, most severe first, as the opening of the run summary.
+func findingsView(findings []modules.Finding) fyne.CanvasObject {
+	if len(findings) == 0 {
+		return widget.NewLabel("No problems found.")
+	}
+	box := container.NewVBox(widget.NewLabelWithStyle(fmt.Sprintf("%d findings:", len(findings)), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	for _, finding := range findings {
+		title := widget.NewLabel(fmt.Sprintf("%s: %s", finding.Severity.Label(), finding.Title))
+		title.Wrapping = fyne.TextWrapWord
+		switch finding.Severity {
+		case modules.SeverityCritical:
+			title.Importance = widget.DangerImportance
+		case modules.SeverityWarning:
+			title.Importance = widget.WarningImportance
+		}
+		box.Add(title)
+		if finding.Advice != "" {
+			advice := widget.NewLabel(finding.Advice)
+			advice.Wrapping = fyne.TextWrapWord
+			box.Add(advice)
+		}
+	}
+	scroll := container.NewVScroll(box)
+	scroll.SetMinSize(fyne.NewSize(500, 250))
+	return scroll
 }
 
-func promptForUpdate(versionInfo *VersionInfo, myWindow fyne.Window) {
-	parsedURL, err := url.Parse(versionInfo.URL) // Parse the URL string
+// knownIssueHits returns the known issues found by an event log report, or nil for any other report.
+func knownIssueHits(report modules.Report) []modules.KnownIssueHit {
+	if events, ok := report.(*modules.EventLogReport); ok {
+		return events.KnownIssues
+	}
+	return nil
+}
+
+// showKnownIssues explains the known issues found in the event logs and what to do about them.
+func showKnownIssues(hits []modules.KnownIssueHit, myWindow fyne.Window) {
+	var text strings.Builder
+	for _, hit := range hits {
+		text.WriteString(fmt.Sprintf("%s (%d events, last %s)\n%s\n", hit.Issue.Title, hit.Count, hit.Last, hit.Issue.Explanation))
+		for _, step := range hit.Issue.NextSteps {
+			text.WriteString("  • " + step + "\n")
+		}
+		text.WriteString("\n")
+	}
+	label := widget.NewLabel(strings.TrimSpace(text.String()))
+	label.Wrapping = fyne.TextWrapWord
+	scroll := container.NewVScroll(label)
+	scroll.SetMinSize(fyne.NewSize(500, 300))
+	dialog.ShowCustom("Known Issues Found", "Close", scroll, myWindow)
+}
+
+// runAllDiagnostics runs the enabled collectors in the background while a progress dialog
+// tracks which one is running, then shows a summary of the run manifest.
+func runAllDiagnostics(collectors []modules.Collector, myWindow fyne.Window) {
+	root, err := modules.EnsureOutputDir()
+	if err != nil {
+		dialog.ShowError(err, myWindow) // E.g. a folder on a drive that is no longer connected
+		return
+	}
+	outputDir, err := modules.NewRunDir(root)
 	if err != nil {
-		dialog.ShowError(fmt.Errorf("invalid update URL: %s", versionInfo.URL), myWindow)
+		dialog.ShowError(err, myWindow)
 		return
 	}
 
-	dialog.ShowConfirm(
-		"Update Available",
-		fmt.Sprintf("A new version (%s) is available. Update now?", versionInfo.Version),
-		func(confirmed bool) {
-			if confirmed {
-				err := fyne.CurrentApp().OpenURL(parsedURL) // Pass the *url.URL type here
-				if err != nil {
-					dialog.ShowError(err, myWindow)
+	progressBar := widget.NewProgressBar()
+	progressBar.Max = float64(len(collectors))
+	currentLabel := widget.NewLabel("Starting...")
+
+	// Closing the dialog through its Cancel button stops the running collector and skips the rest
+	ctx, cancel := context.WithCancel(context.Background())
+	progressDialog := dialog.NewCustom("Running All Diagnostics", "Cancel",
+		container.NewVBox(currentLabel, progressBar), myWindow)
+	progressDialog.SetOnClosed(cancel)
+	progressDialog.Resize(fyne.NewSize(350, 0))
+	progressDialog.Show()
+
+	go func() {
+		manifest, err := modules.RunCollectors(ctx, outputDir, collectors,
+			func(index, total int, c modules.Collector, result *modules.CollectorResult) {
+				if result == nil {
+					currentLabel.SetText(fmt.Sprintf("(%d/%d) %s", index+1, total, c.Name()))
+					return
 				}
+				progressBar.SetValue(float64(index + 1))
+			})
+		progressDialog.Hide()
+
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		if _, err := modules.RecordRun(root, manifest); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to record run history: %w", err), myWindow)
+		}
+
+		var summary strings.Builder
+		summary.WriteString(fmt.Sprintf("%d succeeded, %d failed, %d timed out, %d skipped.\n",
+			manifest.Count(modules.StatusSuccess), manifest.Count(modules.StatusFailed),
+			manifest.Count(modules.StatusTimedOut), manifest.Count(modules.StatusSkipped)))
+		for _, result := range manifest.Results {
+			if result.Status != modules.StatusSuccess {
+				summary.WriteString(fmt.Sprintf("\n%s (%s): %s", result.Name, result.Status, result.Error))
 			}
-		},
-		myWindow,
-	)
+		}
+		summary.WriteString(fmt.Sprintf("\n\nSee %s and %s for details.", modules.FindingsFileName, modules.ManifestFileName))
+		for _, result := range manifest.Results {
+			if hits := knownIssueHits(result.Report); len(hits) > 0 {
+				summary.WriteString("\n\nKnown issues found in the event logs:")
+				for _, hit := range hits {
+					summary.WriteString(fmt.Sprintf("\n• %s (%d events)", hit.Issue.Title, hit.Count))
+				}
+			}
+		}
+
+		reportPath := filepath.Join(outputDir, modules.HTMLReportFileName)
+		content := container.NewVBox(findingsView(manifest.Findings), widget.NewSeparator(), widget.NewLabel(summary.String()))
+		dialog.ShowCustomConfirm("Diagnostics Complete", "Open HTML Report", "Close", content,
+			func(open bool) {
+				if open {
+					openFile(reportPath, myWindow)
+				}
+			}, myWindow)
+	}()
 }
 
-func loadSettings() (Settings, error) { // Signature changed to return error
-	settingsPath := filepath.Join(os.Getenv("LOCALAPPDATA"), "GoDiag", settingsFileName)
-	data, err := ioutil.ReadFile(settingsPath)
-	if err != nil {
-		// If file not found or any read error, return default settings and the error
-		return Settings{RPCEnabled: false, SelectedOutputDir: ""}, err
+// previewRedaction shows what redacting the bundle will hide and creates it once the user agrees.
+// The preview and the bundle share one salt, so hashed tokens in the preview match the bundle.
+func previewRedaction(outputDir string, redaction modules.RedactionConfig, myWindow fyne.Window) {
+	redaction = redaction.Salted()
+	ctx, cancel := context.WithCancel(context.Background())
+	progressDialog := dialog.NewCustom("Create Zip Bundle", "Cancel",
+		container.NewVBox(widget.NewLabel("Looking for values to redact..."), widget.NewProgressBarInfinite()), myWindow)
+	progressDialog.SetOnClosed(cancel)
+	progressDialog.Show()
+
+	go func() {
+		preview, err := modules.PreviewRedaction(ctx, outputDir, redaction)
+		progressDialog.Hide()
+
+		switch {
+		case ctx.Err() != nil:
+			// Cancelled by the user; nothing to report
+		case err != nil:
+			dialog.ShowError(fmt.Errorf("failed to preview redaction: %w", err), myWindow)
+		default:
+			var text bytes.Buffer
+			preview.WriteText(&text)
+			previewScroll := container.NewVScroll(widget.NewLabel(strings.TrimSpace(text.String())))
+			previewScroll.SetMinSize(fyne.NewSize(500, 300))
+
+			confirm := dialog.NewCustomConfirm("Redaction Preview", "Create Bundle", "Cancel",
+				container.NewBorder(widget.NewLabel("These values will be replaced in the bundle. Your original files are not changed."), nil, nil, nil, previewScroll),
+				func(create bool) {
+					if create {
+						createBundle(outputDir, &redaction, myWindow)
+					}
+				}, myWindow)
+			confirm.Show()
+		}
+	}()
+}
+
+// createBundle packs the output directory into a zip in the background and tells the user where it
+// went. redaction, when not nil, is applied to the files as they are packed.
+func createBundle(outputDir string, redaction *modules.RedactionConfig, myWindow fyne.Window) {
+	ctx, cancel := context.WithCancel(context.Background())
+	progressDialog := dialog.NewCustom("Create Zip Bundle", "Cancel",
+		container.NewVBox(widget.NewLabel("Packing diagnostics files, please wait..."), widget.NewProgressBarInfinite()), myWindow)
+	progressDialog.SetOnClosed(cancel)
+	progressDialog.Show()
+
+	go func() {
+		bundlePath, err := modules.CreateBundle(ctx, outputDir, redaction)
+		progressDialog.Hide()
+
+		switch {
+		case ctx.Err() != nil:
+			// Cancelled by the user; nothing to report
+		case err != nil:
+			dialog.ShowError(fmt.Errorf("failed to create bundle: %w", err), myWindow)
+		default:
+			dialog.ShowCustomConfirm("Bundle Created", "Open Folder", "Close",
+				widget.NewLabel(fmt.Sprintf("Send this file to support:\n%s", bundlePath)),
+				func(open bool) {
+					if open {
+						openFile(outputDir, myWindow)
+					}
+				}, myWindow)
+		}
+	}()
+}
+
+// historyView builds the History tab, which lists the runs kept in the output directory with
+// buttons to open or delete each one. The returned function reloads the list, from the output
+// directory selected at that time.
+func historyView(myWindow fyne.Window) (fyne.CanvasObject, func()) {
+	runsBox := container.NewVBox()
+	retentionLabel := widget.NewLabel("")
+
+	var refresh func()
+	refresh = func() {
+		root := modules.OutputDir()
+		retentionLabel.SetText(fmt.Sprintf("Keeping %s in %s", modules.GetRetentionPolicy(), root))
+		runsBox.RemoveAll()
+		runs, err := modules.LoadHistory(root)
+		if err != nil {
+			runsBox.Add(widget.NewLabel(err.Error()))
+			return
+		}
+		if len(runs) == 0 {
+			runsBox.Add(widget.NewLabel("No runs yet. Every run is kept in its own folder and listed here."))
+			return
+		}
+		for _, run := range runs {
+			runDir := filepath.Join(root, run.ID)
+			collectors := fmt.Sprintf("%d collectors", len(run.Collectors))
+			if len(run.Collectors) == 1 {
+				collectors = run.Collectors[0]
+			}
+			summary := widget.NewLabel(fmt.Sprintf("%s  %s\n%s, %d findings (GoDiag %s on %s)",
+				run.Started.Local().Format("2006-01-02 15:04:05"), strings.ToUpper(string(run.Outcome)),
+				collectors, run.Findings, run.GoDiagVersion, run.HostName))
+			summary.Wrapping = fyne.TextWrapWord
+			if run.Outcome != modules.OutcomeSuccess {
+				summary.Importance = widget.WarningImportance
+			}
+
+			openButton := widget.NewButton("Open", func() { openFile(runDir, myWindow) })
+			reportButton := widget.NewButton("Report", func() {
+				openFile(filepath.Join(runDir, modules.HTMLReportFileName), myWindow)
+			})
+			if _, err := os.Stat(filepath.Join(runDir, modules.HTMLReportFileName)); err != nil {
+				reportButton.Disable() // Only "Run All Diagnostics" writes the HTML report
+			}
+			deleteButton := widget.NewButton("Delete", func() {
+				dialog.ShowConfirm("Delete Run", fmt.Sprintf("Delete the run from %s and all its files?", run.Started.Local().Format("2006-01-02 15:04")),
+					func(confirmed bool) {
+						if !confirmed {
+							return
+						}
+						if err := modules.DeleteRun(root, run.ID); err != nil {
+							dialog.ShowError(err, myWindow)
+						}
+						refresh()
+					}, myWindow)
+			})
+			deleteButton.Importance = widget.DangerImportance
+
+			runsBox.Add(container.NewBorder(nil, nil, nil, container.NewHBox(openButton, reportButton, deleteButton), summary))
+			runsBox.Add(widget.NewSeparator())
+		}
 	}
 
-	var settings Settings
-	if err := json.Unmarshal(data, &settings); err != nil {
-		// If unmarshalling fails, return default settings and the error
-		return Settings{RPCEnabled: false, SelectedOutputDir: ""}, err
+	pruneButton := widget.NewButton("Delete Old Runs Now", func() {
+		removed, err := modules.PruneHistory(modules.OutputDir())
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+		} else {
+			dialog.ShowInformation("Run History", fmt.Sprintf("Deleted %d runs.", len(removed)), myWindow)
+		}
+		refresh()
+	})
+	toolbar := container.NewBorder(nil, nil, nil,
+		container.NewHBox(widget.NewButton("Refresh", refresh), pruneButton), retentionLabel)
+
+	refresh()
+	return container.NewBorder(toolbar, nil, nil, nil, container.NewVScroll(runsBox)), refresh
+}
+
+// compareView builds the Compare tab, which diffs two runs picked as folders, bundles or JSON
+// reports and shows what changed between them.
+func compareView(myWindow fyne.Window) fyne.CanvasObject {
+	result := widget.NewLabelWithStyle("Pick two runs to compare: output folders, .zip bundles or .json reports.",
+		fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	resultScroll := container.NewVScroll(result)
+
+	pathRow := func(title string) (*widget.Entry, fyne.CanvasObject) {
+		entry := widget.NewEntry()
+		entry.SetPlaceHolder(title + " run: folder, .zip bundle or .json report")
+		browse := func(path string, err error) {
+			if err != nil {
+				if !errors.Is(err, sqdialog.ErrCancelled) {
+					dialog.ShowError(fmt.Errorf("failed to open file dialog: %w", err), myWindow)
+				}
+				return
+			}
+			entry.SetText(path)
+		}
+		folder := widget.NewButton("Folder...", func() {
+			browse(sqdialog.Directory().Title("Select " + title + " Run").Browse())
+		})
+		file := widget.NewButton("File...", func() {
+			browse(sqdialog.File().Title("Select "+title+" Run").Filter("Bundles and reports", "zip", "json").Load())
+		})
+		return entry, container.NewBorder(nil, nil, widget.NewLabel(title+":"), container.NewHBox(folder, file), entry)
 	}
-	modules.SetCustomOutputDir(settings.SelectedOutputDir)
-	return settings, nil // Successfully loaded, return nil error
+	oldEntry, oldRow := pathRow("Old")
+	newEntry, newRow := pathRow("New")
+
+	compareButton := widget.NewButton("Compare", func() {
+		if oldEntry.Text == "" || newEntry.Text == "" {
+			dialog.ShowError(errors.New("pick both runs to compare"), myWindow)
+			return
+		}
+		older, err := modules.LoadSnapshot(oldEntry.Text)
+		if err == nil {
+			var newer *modules.Snapshot
+			if newer, err = modules.LoadSnapshot(newEntry.Text); err == nil {
+				var text bytes.Buffer
+				modules.CompareSnapshots(older, newer).WriteText(&text)
+				result.SetText(strings.TrimSpace(text.String()))
+				resultScroll.ScrollToTop()
+				return
+			}
+		}
+		dialog.ShowError(err, myWindow)
+	})
+
+	return container.NewBorder(container.NewVBox(oldRow, newRow, compareButton), nil, nil, nil, resultScroll)
 }
 
-func saveSettings(settings Settings) error {
-	settingsDir := filepath.Join(os.Getenv("LOCALAPPDATA"), "GoDiag")
-	if err := os.MkdirAll(settingsDir, os.ModePerm); err != nil {
-		return fmt.Errorf("failed to create settings directory: %w", err)
+// openFile opens path with the default application for its type, e.g. the browser for .html files.
+func openFile(path string, myWindow fyne.Window) {
+	fileURL, err := url.Parse(storage.NewFileURI(path).String())
+	if err == nil {
+		err = fyne.CurrentApp().OpenURL(fileURL)
 	}
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("failed to open %s: %w", path, err), myWindow)
+	}
+}
 
-	settingsPath := filepath.Join(settingsDir, settingsFileName)
-	data, err := json.MarshalIndent(settings, "", "  ")
+// runSingleCollector runs one collector in the background, with its timeout applied, so a slow
+// command such as msinfo32 does not freeze the window. The dialog's Cancel button stops it early.
+// Its report goes into the session's run folder, next to those of the collectors run before it.
+func runSingleCollector(collector modules.Collector, session *modules.RunSession, myWindow fyne.Window) {
+	root, err := modules.EnsureOutputDir()
+	if err != nil {
+		dialog.ShowError(err, myWindow)
+		return
+	}
+	outputDir, err := session.Dir(root)
 	if err != nil {
-		return fmt.Errorf("failed to marshal settings: %w", err)
+		dialog.ShowError(err, myWindow)
+		return
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	progressDialog := dialog.NewCustom(collector.Name(), "Cancel",
+		container.NewVBox(widget.NewLabel("Collecting, please wait..."), widget.NewProgressBarInfinite()), myWindow)
+	progressDialog.SetOnClosed(cancel)
+	progressDialog.Show()
 
-	return ioutil.WriteFile(settingsPath, data, 0644)
+	go func() {
+		result := modules.RunCollector(ctx, outputDir, collector)
+		progressDialog.Hide()
+		if _, err := session.Record(root, result); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to record run history: %w", err), myWindow)
+		}
+
+		switch result.Status {
+		case modules.StatusSuccess:
+			if hits := knownIssueHits(result.Report); len(hits) > 0 {
+				showKnownIssues(hits, myWindow)
+				return
+			}
+			dialog.ShowInformation("Success", fmt.Sprintf("%s created successfully", strings.Join(collector.OutputFiles(), ", ")), myWindow)
+		case modules.StatusSkipped:
+			// Cancelled by the user; nothing to report
+		default:
+			dialog.ShowError(fmt.Errorf("%s %s: %s", collector.Name(), result.Status, result.Error), myWindow)
+		}
+	}()
 }
 
 func main() {
+	modules.SetAppVersion(currentVersion)
+	update.CleanupBackup() // The version replaced by the last update, if any
+
+	// Any arguments switch GoDiag into headless command-line mode; Fyne is never initialised
+	if len(os.Args) > 1 {
+		settings, err := config.Load(config.Path())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "godiag: %v\n", err) // The defaults, or what was valid, still apply
+		}
+		settings.Apply()
+		policy, err := config.LoadPolicy()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "godiag: %v\n", err) // What could be read is still enforced
+		}
+		policy.Apply()
+		if err := loadKnownIssues(); err != nil {
+			fmt.Fprintf(os.Stderr, "godiag: %v\n", err)
+		}
+		if err := loadFindingRules(); err != nil {
+			fmt.Fprintf(os.Stderr, "godiag: %v\n", err)
+		}
+		if err := loadRegistryProfiles(); err != nil {
+			fmt.Fprintf(os.Stderr, "godiag: %v\n", err)
+		}
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	myApp := app.NewWithID("tv.lewdlilly.GoDiag")
 	myWindow := myApp.NewWindow("GoDiag by LewdLillyVT")
 	myWindow.Resize(fyne.NewSize(400, 600))
 
-	settings, err := loadSettings()
+	settings, err := config.Load(config.Path())
 	if err != nil {
-		dialog.ShowError(err, myWindow)
-		return
+		dialog.ShowError(err, myWindow) // The defaults, or what was valid, still apply
 	}
-
-	// Ensure the output directory exists
-	outputDir, err := modules.EnsureOutputDir()
+	settings.Apply()
+	policy, err := config.LoadPolicy()
 	if err != nil {
-		dialog.ShowError(err, myWindow)
-		return
+		dialog.ShowError(err, myWindow) // What could be read is still enforced
+	}
+	policy.Apply()
+	if err := loadKnownIssues(); err != nil {
+		dialog.ShowError(err, myWindow) // The built-in knowledge base still applies
+	}
+	if err := loadFindingRules(); err != nil {
+		dialog.ShowError(err, myWindow) // The built-in rules still apply
+	}
+	if err := loadRegistryProfiles(); err != nil {
+		dialog.ShowError(err, myWindow) // The built-in profiles still apply
 	}
 
 	// Check for updates
 	go func() {
-		versionInfo, err := checkForUpdate()
-		if err == nil && versionInfo.Version > currentVersion {
-			promptForUpdate(versionInfo, myWindow)
+		available, err := checkForUpdate(settings.UpdateChannel)
+		if err == nil && available != nil {
+			promptForUpdate(available, myWindow)
 		}
 	}()
 
 	// Track RPC state
-	var rpcRunning = settings.RPCEnabled
+	var rpcRunning = settings.RPCEnabled && !policy.DisableRPC
 
 	// Start RPC if enabled
 	if rpcRunning {
@@ -179,150 +642,56 @@ func main() {
 		cleanup()
 	})
 
-	// Diagnostics buttons...
-	msInfoButton := widget.NewButton("Generate msinfo32.nfo", func() {
-		err := modules.GenerateMsinfo32(outputDir)
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "msinfo32.nfo created successfully", myWindow)
-		}
-	})
-
-	dxdiagButton := widget.NewButton("Generate dxdiag.txt", func() {
-		err := modules.GenerateDxdiag(outputDir)
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "dxdiag.txt created successfully", myWindow)
-		}
-	})
-
-	etlLogButton := widget.NewButton("Generate Event Trace Log (ETL)", func() {
-		err := modules.GenerateETLLog(outputDir)
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "ETL log generated successfully", myWindow)
-		}
-	})
-
-	biosReportButton := widget.NewButton("Generate BIOS/UEFI Version Report", func() {
-		err := modules.GenerateBIOSReport(outputDir)
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "BIOS_Report.txt created successfully", myWindow)
-		}
-	})
-
-	sysInfoButton := widget.NewButton("Generate Quick System Info", func() {
-		err := modules.GenerateQuickSysInfo(outputDir)
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "Quick_System_Info.txt created successfully", myWindow)
-		}
-	})
+	// Collectors run from their own buttons share one run folder until the next full run
+	session := &modules.RunSession{}
 
-	eventLogButton := widget.NewButton("Dump Latest Event Logs", func() {
-		err := modules.DumpEventLogs(outputDir)
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "Event_Log_Dump.txt created successfully", myWindow)
-		}
+	// Run every collector in one go, showing per-collector progress
+	runAllButton := widget.NewButton("Run All Diagnostics", func() {
+		session.End()
+		runAllDiagnostics(settings.Collectors(), myWindow)
 	})
+	runAllButton.Importance = widget.HighImportance
 
-	healthButton := widget.NewButton("Generate Drive Health Report", func() {
-		err := modules.GenerateHealthAndUsageReport(outputDir)
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "Health_Report.txt created successfully", myWindow)
-		}
-	})
-
-	securityLogsButton := widget.NewButtonWithIcon("Generate Security and Antivirus Logs", theme.GridIcon(), func() {
-		err := modules.GenerateSecurityAndAntivirusLogs(outputDir)
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "Security_Antivirus_Logs.txt created successfully", myWindow)
-		}
-	})
-
-	networkDiagButton := widget.NewButton("Generate Network Diagnostics Report", func() {
-		err := modules.GenerateNetworkReport(outputDir)
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "Network_Diagnostics_Report.txt created successfully", myWindow)
+	// Diagnostics buttons, one per registered collector
+	diagnosticsBox := container.NewVBox(runAllButton, widget.NewSeparator())
+	for _, collector := range modules.Collectors() {
+		button := widget.NewButton(collector.Name(), func() {
+			runSingleCollector(collector, session, myWindow)
+		})
+		if modules.CollectorDisabled(collector.ID()) {
+			button.SetText(collector.Name() + " (disabled by your organization)")
+			button.Disable()
 		}
-	})
+		diagnosticsBox.Add(button)
+	}
 
 	flushDNSButton := widget.NewButton("Flush DNS Cache", func() {
-		err := modules.FlushDNSCache()
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "DNS cache flushed successfully.", myWindow)
-		}
-	})
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
 
-	softwareDiagButton := widget.NewButton("Generate Software & Application Report", func() {
-		err := modules.GenerateSoftwareReport(outputDir)
+		err := modules.FlushDNSCache(ctx)
 		if err != nil {
 			dialog.ShowError(err, myWindow)
 		} else {
-			dialog.ShowInformation("Success", "Software_Diagnostics_Report.txt created successfully", myWindow)
-		}
-	})
-
-	hardwareButton := widget.NewButton("Generate Hardware & Peripherals Report", func() {
-		err := modules.GenerateHardwareReport(outputDir)
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "Hardware_Peripherals_Report.txt created successfully", myWindow)
-		}
-	})
-
-	driverManagementButton := widget.NewButton("Generate Driver Report", func() {
-		err := modules.GenerateDriverReport(outputDir)
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "Driver_Report.txt created successfully", myWindow)
+			dialog.ShowInformation("Success", "DNS cache flushed successfully.", myWindow)
 		}
 	})
-
-	registryExportButton := widget.NewButton("Export Common Registry Keys", func() {
-		err := modules.GenerateRegistryExport(outputDir)
+	bundleButton := widget.NewButton("Create Zip Bundle for Support", func() {
+		runDir, err := modules.LatestRunDir(modules.OutputDir())
 		if err != nil {
 			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "Common registry keys exported successfully.\nSee Registry_Export_Summary.txt for details on exported files.", myWindow)
+			return
 		}
-	})
-
-	startupProgramsButton := widget.NewButton("Generate Startup Programs Report", func() {
-		err := modules.GenerateStartupProgramsReport(outputDir) // Call the new function
-		if err != nil {
-			dialog.ShowError(err, myWindow)
+		if settings.RedactBundles || policy.RequireRedaction {
+			previewRedaction(runDir, settings.Redaction, myWindow)
 		} else {
-			dialog.ShowInformation("Success", "Startup_Programs_Report.txt created successfully", myWindow)
+			createBundle(runDir, nil, myWindow)
 		}
 	})
 
-	runningProcessesButton := widget.NewButton("Generate Running Processes Report", func() {
-		err := modules.GenerateRunningProcessesReport(outputDir) // Call the new function
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-		} else {
-			dialog.ShowInformation("Success", "Running_Processes_Report.txt created successfully", myWindow)
-		}
-	})
+	diagnosticsBox.Add(widget.NewSeparator())
+	diagnosticsBox.Add(flushDNSButton)
+	diagnosticsBox.Add(bundleButton)
 
 	// Help Tab
 	linkURL := &url.URL{
@@ -340,28 +709,58 @@ func main() {
 	)
 
 	// Settings Tab
+	// Options locked by a machine policy are greyed out and say so
+	const managedNote = "managed by your organization"
+	managedLabel := widget.NewLabel("")
+	managedLabel.Wrapping = fyne.TextWrapWord
+	if policy.Managed() {
+		managedLabel.SetText(fmt.Sprintf("Some settings are %s and cannot be changed here (%s).", managedNote, strings.Join(policy.Sources, ", ")))
+		managedLabel.Importance = widget.WarningImportance
+	} else {
+		managedLabel.Hide()
+	}
+
 	// Display for current output directory
-	currentOutputDirLabel := widget.NewLabel(fmt.Sprintf("Current Output Path: %s", modules.GetCustomOutputDir()))
+	outputDirText := func() string {
+		if dir := modules.ManagedOutputDir(); dir != "" {
+			return fmt.Sprintf("Current Output Path: %s (%s)", dir, managedNote)
+		}
+		if dir := modules.GetCustomOutputDir(); dir != "" {
+			return fmt.Sprintf("Current Output Path: %s", dir)
+		}
+		return fmt.Sprintf("Current Output Path: %s (default)", modules.DefaultOutputDir())
+	}
+	currentOutputDirLabel := widget.NewLabel(outputDirText())
 	// Update the label whenever the output directory changes
 	updateOutputDirLabel := func() {
-		currentOutputDirLabel.SetText(fmt.Sprintf("Current Output Path: %s", modules.GetCustomOutputDir()))
+		currentOutputDirLabel.SetText(outputDirText())
 	}
+	currentOutputDirLabel.Wrapping = fyne.TextWrapBreak
+
+	// Where the settings live; in portable mode everything stays next to the executable
+	settingsLocation := fmt.Sprintf("Settings file: %s", config.Path())
+	if config.Portable() {
+		settingsLocation = fmt.Sprintf("Portable mode: settings and output are kept in %s", config.Dir())
+	}
+	settingsLocationLabel := widget.NewLabel(settingsLocation)
+	settingsLocationLabel.Wrapping = fyne.TextWrapBreak
 
 	selectDirButton := widget.NewButton("Select Output Directory", func() {
 		// Use sqdialog.Directory() for directory selection
 		selectedPath, err := sqdialog.Directory().Title("Select Output Directory").Browse()
 		if err != nil {
-			// sqweek/dialog returns an error if cancelled. Check specifically for this.
-			if err.Error() == "dialog cancelled" {
-				fmt.Println("Directory selection cancelled by user.")
-			} else {
-				dialog.ShowError(fmt.Errorf("failed to open directory dialog: %w", err), myWindow) // Use Fyne's dialog for actual errors
+			if !errors.Is(err, sqdialog.ErrCancelled) {
+				dialog.ShowError(fmt.Errorf("failed to open directory dialog: %w", err), myWindow)
 			}
 			return
 		}
 		if selectedPath != "" {
-			settings.SelectedOutputDir = selectedPath // Store in settings struct
-			modules.SetCustomOutputDir(selectedPath)  // Update in modules package
+			if err := modules.CheckOutputDir(selectedPath); err != nil {
+				dialog.ShowError(err, myWindow) // E.g. a read-only folder
+				return
+			}
+			settings.SelectedOutputDir = config.PortablePath(selectedPath) // Relative to the drive GoDiag runs from in portable mode
+			modules.SetCustomOutputDir(selectedPath)                       // Update in modules package
 			if err := saveSettings(settings); err != nil {
 				dialog.ShowError(err, myWindow) // Use Fyne's dialog for error
 			} else {
@@ -403,46 +802,480 @@ func main() {
 			rpc.StopRPC()
 		}
 	})
-	rpcToggle.SetChecked(settings.RPCEnabled)
+	if policy.DisableRPC {
+		rpcToggle.SetText(fmt.Sprintf("Enable Discord RPC (turned off, %s)", managedNote))
+		rpcToggle.Disable() // Left unchecked without saving, so the user's choice returns with the policy lifted
+	} else {
+		rpcToggle.SetChecked(settings.RPCEnabled)
+	}
+	if policy.OutputDir != "" {
+		selectDirButton.Disable()
+		resetDirButton.Disable()
+	}
+
+	jsonToggle := widget.NewCheck("Also write a JSON file next to each report", func(checked bool) {
+		settings.SetFormat(config.FormatJSON, checked)
+		modules.SetJSONExport(checked)
+		if err := saveSettings(settings); err != nil {
+			dialog.ShowError(err, myWindow)
+		}
+	})
+	jsonToggle.SetChecked(settings.HasFormat(config.FormatJSON))
+
+	// Collectors run by "Run All Diagnostics"; selecting all of them saves an empty list so that
+	// collectors added by later versions are included too
+	collectorLabels := []string{}
+	collectorsByLabel := map[string]string{}
+	var disabledCollectors []string
+	for _, collector := range modules.Collectors() {
+		if modules.CollectorDisabled(collector.ID()) {
+			disabledCollectors = append(disabledCollectors, collector.Name())
+			continue
+		}
+		collectorLabels = append(collectorLabels, collector.Name())
+		collectorsByLabel[collector.Name()] = collector.ID()
+	}
+	disabledCollectorsLabel := widget.NewLabel(fmt.Sprintf("Turned off (%s): %s", managedNote, strings.Join(disabledCollectors, ", ")))
+	disabledCollectorsLabel.Wrapping = fyne.TextWrapWord
+	if len(disabledCollectors) == 0 {
+		disabledCollectorsLabel.Hide()
+	}
+	enabledCollectors := widget.NewCheckGroup(collectorLabels, nil)
+	for _, collector := range settings.Collectors() {
+		enabledCollectors.Selected = append(enabledCollectors.Selected, collector.Name())
+	}
+	enabledCollectors.OnChanged = func(selected []string) {
+		if len(selected) == 0 {
+			dialog.ShowInformation("Collectors", "At least one collector must stay enabled.", myWindow)
+			var previous []string
+			for _, collector := range settings.Collectors() {
+				previous = append(previous, collector.Name())
+			}
+			enabledCollectors.SetSelected(previous) // Checks the last one again
+			return
+		}
+		settings.EnabledCollectors = []string{}
+		if len(selected) < len(collectorLabels) {
+			for _, label := range selected {
+				settings.EnabledCollectors = append(settings.EnabledCollectors, collectorsByLabel[label])
+			}
+		}
+		if err := saveSettings(settings); err != nil {
+			dialog.ShowError(err, myWindow)
+		}
+	}
+
+	// Per-collector timeouts, overriding each collector's own default
+	timeouts := widget.NewEntry()
+	timeouts.SetPlaceHolder("Timeouts, e.g. network=2m, eventlogs=5m (IDs as in 'godiag list')")
+	var timeoutPairs []string
+	for _, collector := range modules.Collectors() {
+		if value, ok := settings.Timeouts[collector.ID()]; ok {
+			timeoutPairs = append(timeoutPairs, collector.ID()+"="+value)
+		}
+	}
+	timeouts.SetText(strings.Join(timeoutPairs, ", "))
+	applyTimeouts := widget.NewButton("Apply Timeouts", func() {
+		parsed, err := config.ParseTimeouts(timeouts.Text)
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		settings.Timeouts = parsed
+		settings.Apply()
+		if err := saveSettings(settings); err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		dialog.ShowInformation("Success", fmt.Sprintf("%d collector timeouts set.", len(parsed)), myWindow)
+	})
+
+	// Redaction of zip bundles; the category and mode choices only matter while redaction is on
+	categoryLabels := make([]string, 0, len(modules.RedactionCategories()))
+	categoriesByLabel := map[string]modules.RedactionCategory{}
+	for _, category := range modules.RedactionCategories() {
+		categoryLabels = append(categoryLabels, category.Label())
+		categoriesByLabel[category.Label()] = category
+	}
+	redactCategories := widget.NewCheckGroup(categoryLabels, func(selected []string) {
+		settings.Redaction.Categories = make([]modules.RedactionCategory, 0, len(selected))
+		for _, label := range selected {
+			settings.Redaction.Categories = append(settings.Redaction.Categories, categoriesByLabel[label])
+		}
+		if err := saveSettings(settings); err != nil {
+			dialog.ShowError(err, myWindow)
+		}
+	})
+	redactCategories.Horizontal = true
+	for _, category := range settings.Redaction.Categories {
+		redactCategories.Selected = append(redactCategories.Selected, category.Label())
+	}
+
+	redactMode := widget.NewRadioGroup([]string{"Mask", "Hash"}, func(selected string) {
+		if selected == "" {
+			return
+		}
+		settings.Redaction.Mode = modules.RedactionMode(strings.ToLower(selected))
+		if err := saveSettings(settings); err != nil {
+			dialog.ShowError(err, myWindow)
+		}
+	})
+	redactMode.Horizontal = true
+	redactMode.Required = true
+	redactMode.Selected = strings.ToUpper(string(settings.Redaction.Mode[:1])) + string(settings.Redaction.Mode[1:])
+
+	redactToggle := widget.NewCheck("Redact zip bundles before sharing", func(checked bool) {
+		settings.RedactBundles = checked
+		if checked {
+			redactCategories.Enable()
+			redactMode.Enable()
+		} else {
+			redactCategories.Disable()
+			redactMode.Disable()
+		}
+		if err := saveSettings(settings); err != nil {
+			dialog.ShowError(err, myWindow)
+		}
+	})
+	requiredRedactionLabel := widget.NewLabel("")
+	requiredRedactionLabel.Wrapping = fyne.TextWrapWord
+	requiredRedactionLabel.Hide()
+	if required := policy.RequiredRedaction(); required != nil {
+		// Always on; the user may still pick extra categories and the mode
+		redactToggle.SetText(fmt.Sprintf("Redact zip bundles before sharing (required, %s)", managedNote))
+		redactToggle.Checked = true // Set without saving, so the user's choice returns with the policy lifted
+		redactToggle.Disable()
+		labels := make([]string, 0, len(required))
+		for _, category := range required {
+			labels = append(labels, category.Label())
+		}
+		requiredRedactionLabel.SetText("Always redacted: " + strings.Join(labels, ", "))
+		requiredRedactionLabel.Show()
+	} else {
+		redactToggle.SetChecked(settings.RedactBundles)
+		if !settings.RedactBundles {
+			redactCategories.Disable()
+			redactMode.Disable()
+		}
+	}
+
+	// Event log presets; the custom query fields only matter while "Custom query" is selected
+	eventPresetLabels := []string{}
+	eventPresetsByLabel := map[string]string{}
+	for _, preset := range modules.EventPresets() {
+		eventPresetLabels = append(eventPresetLabels, preset.Description)
+		eventPresetsByLabel[preset.Description] = preset.Name
+	}
+	const customEventsLabel = "Custom query"
+	eventPresetLabels = append(eventPresetLabels, customEventsLabel)
+	eventPresetsByLabel[customEventsLabel] = modules.EventPresetCustom
+
+	eventChannels := widget.NewEntry()
+	eventChannels.SetPlaceHolder("Channels, e.g. System, Application, Microsoft-Windows-Diagnostics-Performance/Operational")
+	eventChannels.SetText(strings.Join(settings.CustomEvents.Channels, ", "))
+
+	levelLabels := []string{}
+	levelsByLabel := map[string]modules.EventLevel{}
+	for _, level := range modules.EventLevels() {
+		levelLabels = append(levelLabels, level.Label())
+		levelsByLabel[level.Label()] = level
+	}
+	eventLevels := widget.NewCheckGroup(levelLabels, nil)
+	eventLevels.Horizontal = true
+	for _, level := range settings.CustomEvents.Levels {
+		eventLevels.Selected = append(eventLevels.Selected, level.Label())
+	}
+
+	sinceOptions := []string{"Any time", "Last hour", "Last 24 hours", "Last 7 days", "Last 30 days"}
+	sinceValues := map[string]string{"Any time": "", "Last hour": "1h", "Last 24 hours": "24h", "Last 7 days": "7d", "Last 30 days": "30d"}
+	eventSince := widget.NewSelect(sinceOptions, nil)
+	eventSince.SetSelected(sinceOptions[0])
+	for label, value := range sinceValues {
+		if value == settings.CustomEvents.Since {
+			eventSince.SetSelected(label)
+		}
+	}
+
+	eventProviders := widget.NewEntry()
+	eventProviders.SetPlaceHolder("Providers (optional), e.g. Microsoft-Windows-Kernel-Power")
+	eventProviders.SetText(strings.Join(settings.CustomEvents.Providers, ", "))
+
+	eventIDs := widget.NewEntry()
+	eventIDs.SetPlaceHolder("Event IDs (optional), e.g. 41, 6008")
+	if len(settings.CustomEvents.EventIDs) > 0 {
+		ids := make([]string, 0, len(settings.CustomEvents.EventIDs))
+		for _, id := range settings.CustomEvents.EventIDs {
+			ids = append(ids, strconv.Itoa(id))
+		}
+		eventIDs.SetText(strings.Join(ids, ", "))
+	}
+
+	eventMax := widget.NewEntry()
+	eventMax.SetPlaceHolder(fmt.Sprintf("Events per channel (default %d)", modules.DefaultMaxEvents))
+	if settings.CustomEvents.MaxEvents > 0 {
+		eventMax.SetText(strconv.Itoa(settings.CustomEvents.MaxEvents))
+	}
+
+	applyCustomEvents := widget.NewButton("Apply Custom Query", func() {
+		query := modules.EventQuery{
+			Channels:  modules.SplitList(eventChannels.Text),
+			Since:     sinceValues[eventSince.Selected],
+			Providers: modules.SplitList(eventProviders.Text),
+		}
+		for _, label := range eventLevels.Selected {
+			query.Levels = append(query.Levels, levelsByLabel[label])
+		}
+		ids, err := modules.ParseEventIDs(eventIDs.Text)
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		query.EventIDs = ids
+		if text := strings.TrimSpace(eventMax.Text); text != "" {
+			n, err := strconv.Atoi(text)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("invalid number of events %q", text), myWindow)
+				return
+			}
+			query.MaxEvents = n
+		}
+		if err := query.Validate(); err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		settings.CustomEvents = query
+		settings.ApplyEvents()
+		if err := saveSettings(settings); err != nil {
+			dialog.ShowError(err, myWindow)
+		}
+	})
+
+	customEventWidgets := []fyne.Disableable{eventChannels, eventLevels, eventSince, eventProviders, eventIDs, eventMax, applyCustomEvents}
+	setCustomEventsEnabled := func(enabled bool) {
+		for _, w := range customEventWidgets {
+			if enabled {
+				w.Enable()
+			} else {
+				w.Disable()
+			}
+		}
+	}
+
+	eventPreset := widget.NewSelect(eventPresetLabels, func(selected string) {
+		settings.EventPreset = eventPresetsByLabel[selected]
+		setCustomEventsEnabled(settings.EventPreset == modules.EventPresetCustom)
+		settings.ApplyEvents()
+		if err := saveSettings(settings); err != nil {
+			dialog.ShowError(err, myWindow)
+		}
+	})
+	for label, name := range eventPresetsByLabel {
+		if name == settings.EventPreset {
+			eventPreset.Selected = label
+		}
+	}
+	setCustomEventsEnabled(settings.EventPreset == modules.EventPresetCustom)
+
+	// Retention of past runs; zero or empty means no limit
+	keepRuns := widget.NewEntry()
+	keepRuns.SetPlaceHolder("Runs to keep (empty for no limit)")
+	keepDays := widget.NewEntry()
+	keepDays.SetPlaceHolder("Days to keep runs (empty for no limit)")
+	if settings.Retention.KeepRuns > 0 {
+		keepRuns.SetText(strconv.Itoa(settings.Retention.KeepRuns))
+	}
+	if settings.Retention.KeepDays > 0 {
+		keepDays.SetText(strconv.Itoa(settings.Retention.KeepDays))
+	}
+	applyRetention := widget.NewButton("Apply Retention", func() {
+		var policy modules.RetentionPolicy
+		for _, limit := range []struct {
+			entry *widget.Entry
+			value *int
+		}{{keepRuns, &policy.KeepRuns}, {keepDays, &policy.KeepDays}} {
+			if text := strings.TrimSpace(limit.entry.Text); text != "" {
+				n, err := strconv.Atoi(text)
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("invalid number %q", text), myWindow)
+					return
+				}
+				*limit.value = n
+			}
+		}
+		if err := policy.Validate(); err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		settings.Retention = policy
+		modules.SetRetentionPolicy(policy)
+		if err := saveSettings(settings); err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		dialog.ShowInformation("Success", fmt.Sprintf("Keeping %s. Older runs are deleted after the next run.", policy), myWindow)
+	})
+
+	// Registry export profiles, plus keys of the user's own
+	profileLabels := []string{}
+	profilesByLabel := map[string]string{}
+	for _, profile := range modules.RegistryProfiles() {
+		label := fmt.Sprintf("%s: %s", profile.Name, profile.Description)
+		profileLabels = append(profileLabels, label)
+		profilesByLabel[label] = profile.Name
+	}
+	registryProfiles := widget.NewCheckGroup(profileLabels, func(selected []string) {
+		settings.RegistryProfiles = []string{}
+		for _, label := range selected {
+			settings.RegistryProfiles = append(settings.RegistryProfiles, profilesByLabel[label])
+		}
+		modules.SetRegistryExport(settings.RegistryProfiles, settings.RegistryKeys)
+		if err := saveSettings(settings); err != nil {
+			dialog.ShowError(err, myWindow)
+		}
+	})
+	for label, name := range profilesByLabel {
+		for _, selected := range settings.RegistryProfiles {
+			if name == selected {
+				registryProfiles.Selected = append(registryProfiles.Selected, label)
+			}
+		}
+	}
+
+	registryKeys := widget.NewMultiLineEntry()
+	registryKeys.SetPlaceHolder("Additional keys, one per line: Name=HKLM\\SOFTWARE\\Vendor or just the path")
+	registryKeys.SetMinRowsVisible(3)
+	var keyLines []string
+	for _, key := range settings.RegistryKeys {
+		keyLines = append(keyLines, key.Name+"="+key.Path)
+	}
+	registryKeys.SetText(strings.Join(keyLines, "\n"))
+	applyRegistryKeys := widget.NewButton("Apply Registry Keys", func() {
+		keys := []modules.RegistryKey{}
+		for _, line := range strings.Split(registryKeys.Text, "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			key, err := modules.ParseRegistryKey(line)
+			if err != nil {
+				dialog.ShowError(err, myWindow)
+				return
+			}
+			keys = append(keys, key)
+		}
+		settings.RegistryKeys = keys
+		modules.SetRegistryExport(settings.RegistryProfiles, settings.RegistryKeys)
+		if err := saveSettings(settings); err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		dialog.ShowInformation("Success", fmt.Sprintf("%d additional registry keys will be exported.", len(keys)), myWindow)
+	})
+
+	// Update channel, with a button to check right away
+	channelLabels := map[update.Channel]string{update.ChannelStable: "Stable releases", update.ChannelBeta: "Beta: stable and pre-releases"}
+	channelOptions := []string{}
+	channelsByLabel := map[string]update.Channel{}
+	for _, channel := range update.Channels() {
+		channelOptions = append(channelOptions, channelLabels[channel])
+		channelsByLabel[channelLabels[channel]] = channel
+	}
+	updateChannel := widget.NewSelect(channelOptions, nil)
+	if channel, err := update.ParseChannel(string(settings.UpdateChannel)); err == nil {
+		updateChannel.SetSelected(channelLabels[channel])
+	}
+	updateChannel.OnChanged = func(label string) {
+		settings.UpdateChannel = channelsByLabel[label]
+		if err := saveSettings(settings); err != nil {
+			dialog.ShowError(err, myWindow)
+		}
+	}
+	updatesLabel := widget.NewLabel(fmt.Sprintf("Updates (running %s):", currentVersion))
+	checkUpdatesButton := widget.NewButton("Check for Updates", func() {
+		go func() {
+			available, err := checkForUpdate(settings.UpdateChannel)
+			switch {
+			case err != nil:
+				dialog.ShowError(fmt.Errorf("failed to check for updates: %w", err), myWindow)
+			case available == nil:
+				dialog.ShowInformation("Up to Date", fmt.Sprintf("GoDiag %s is the latest version.", currentVersion), myWindow)
+			default:
+				promptForUpdate(available, myWindow)
+			}
+		}()
+	})
+	if policy.DisableUpdateCheck {
+		updatesLabel.SetText(fmt.Sprintf("Updates (running %s): update checks are turned off, %s.", currentVersion, managedNote))
+		updateChannel.Disable()
+		checkUpdatesButton.Disable()
+	}
 
 	settingsTab := container.NewTabItem("Settings",
-		container.NewVBox(
+		container.NewVScroll(container.NewVBox(
+			managedLabel,
+			settingsLocationLabel,
 			currentOutputDirLabel, // Display current path
 			selectDirButton,       // Button to select new path
 			resetDirButton,        // Button to reset to default
 			widget.NewSeparator(), // Separator for better organization
 			rpcToggle,
+			jsonToggle,
+			widget.NewSeparator(),
+			widget.NewLabel("Collectors run by Run All Diagnostics:"),
+			enabledCollectors,
+			disabledCollectorsLabel,
+			timeouts,
+			applyTimeouts,
+			widget.NewSeparator(),
+			updatesLabel,
+			updateChannel,
+			checkUpdatesButton,
+			widget.NewSeparator(),
+			widget.NewLabel("Past runs to keep:"),
+			keepRuns,
+			keepDays,
+			applyRetention,
+			widget.NewSeparator(),
+			widget.NewLabel("Registry profiles to export:"),
+			registryProfiles,
+			registryKeys,
+			applyRegistryKeys,
+			widget.NewSeparator(),
+			redactToggle,
+			requiredRedactionLabel,
+			redactCategories,
+			redactMode,
+			widget.NewSeparator(),
+			widget.NewLabel("Event logs to collect:"),
+			eventPreset,
+			eventChannels,
+			eventLevels,
+			eventSince,
+			eventProviders,
+			eventIDs,
+			eventMax,
+			applyCustomEvents,
 			// Add any other existing settings here
-		),
+		)),
 	)
 
 	// Diagnostics/Main Tab
-	mainTab := container.NewTabItem("Main",
-		container.NewVBox(
-			msInfoButton,
-			dxdiagButton,
-			sysInfoButton,
-			eventLogButton,
-			healthButton,
-			etlLogButton,
-			biosReportButton,
-			securityLogsButton,
-			networkDiagButton,
-			flushDNSButton,
-			softwareDiagButton,
-			hardwareButton,
-			driverManagementButton,
-			registryExportButton,
-			startupProgramsButton,
-			runningProcessesButton,
-		),
-	)
+	mainTab := container.NewTabItem("Main", diagnosticsBox)
+
+	history, refreshHistory := historyView(myWindow)
+	historyTab := container.NewTabItem("History", history)
 
 	tabs := container.NewAppTabs(
 		mainTab,
+		historyTab,
+		container.NewTabItem("Compare", compareView(myWindow)),
 		helpTab,
 		settingsTab,
 	)
+	tabs.OnSelected = func(tab *container.TabItem) {
+		if tab == historyTab {
+			refreshHistory() // Picks up runs made since the tab was last shown
+		}
+	}
 
 	myWindow.SetContent(tabs)
 	myWindow.ShowAndRun()
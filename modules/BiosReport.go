@@ -2,27 +2,59 @@ package modules
 
 import (
 	"bytes"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"context"
 )
 
-// generateBIOSReport gathers BIOS/UEFI version information and saves it to a text file.
-func GenerateBIOSReport(outputDir string) error {
-	var output bytes.Buffer
-	outputPath := filepath.Join(outputDir, "BIOS_Report.txt")
-
-	output.WriteString("BIOS/UEFI Version Information:\n")
-	biosInfo, err := exec.Command("wmic", "bios", "get", "Manufacturer,SMBIOSBIOSVersion,ReleaseDate").Output()
-	if err != nil {
-		output.WriteString("Error gathering BIOS information.\n")
-	} else {
-		output.Write(biosInfo)
-	}
+var biosCollector = &reportCollector{
+	collectorInfo: collectorInfo{
+		id:          "bios",
+		name:        "Generate BIOS/UEFI Version Report",
+		outputFiles: []string{"BIOS_Report.txt"},
+	},
+	collect: collectFunc(CollectBIOSReport),
+}
+
+func init() {
+	Register(biosCollector)
+}
 
-	// Append footer
-	output.WriteString("\n\nReport generated by GoDiag. Learn more at https://github.com/LewdLillyVT/godiag")
+// BIOSInfo describes the system firmware as reported by Win32_BIOS or /sys/class/dmi/id.
+type BIOSInfo struct {
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Version      string `json:"version,omitempty"`      // SMBIOSBIOSVersion, the version string vendors publish
+	ReleaseDate  string `json:"release_date,omitempty"` // YYYY-MM-DD
+	SerialNumber string `json:"serial_number,omitempty"`
+}
+
+// BIOSReport is the structured result of the BIOS/UEFI version report.
+type BIOSReport struct {
+	BIOS   []BIOSInfo    `json:"bios"`
+	Errors SectionErrors `json:"errors,omitempty"`
+}
+
+const sectionBIOS = "BIOS Information"
+
+// WriteText renders the report in GoDiag's plain-text layout.
+func (r *BIOSReport) WriteText(output *bytes.Buffer) {
+	output.WriteString("BIOS/UEFI Version Information:\n\n")
+	if errText, failed := r.Errors.For(sectionBIOS); failed {
+		output.WriteString("Error gathering BIOS information: " + errText + "\n")
+		return
+	}
+	if len(r.BIOS) == 0 {
+		writeNone(output, "BIOS information")
+		return
+	}
+	for _, bios := range r.BIOS {
+		writeFields(output,
+			field{"Manufacturer", bios.Manufacturer},
+			field{"Version", bios.Version},
+			field{"Release Date", bios.ReleaseDate},
+		)
+	}
+}
 
-	// Save to file
-	return os.WriteFile(outputPath, output.Bytes(), 0644)
+// GenerateBIOSReport gathers BIOS/UEFI version information and saves it to a text file.
+func GenerateBIOSReport(ctx context.Context, outputDir string) error {
+	return biosCollector.Run(ctx, outputDir)
 }
@@ -0,0 +1,25 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// CollectBIOSReport gathers BIOS/UEFI version information.
+func CollectBIOSReport(ctx context.Context) (*BIOSReport, error) {
+	report := &BIOSReport{}
+
+	// The kernel exports the SMBIOS BIOS fields under /sys/class/dmi/id
+	if _, err := os.Stat(dmiDir); err != nil {
+		report.Errors.Add(sectionBIOS, fmt.Errorf("firmware information is not available: %w", err))
+		return report, nil
+	}
+	report.BIOS = append(report.BIOS, BIOSInfo{
+		Manufacturer: readDMI("bios_vendor"),
+		Version:      readDMI("bios_version"),
+		ReleaseDate:  formatDMIDate(readDMI("bios_date")),
+	})
+
+	return report, nil
+}
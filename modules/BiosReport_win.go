@@ -0,0 +1,18 @@
+package modules
+
+import "context"
+
+// collectWindowsBIOSReport gathers BIOS/UEFI version information.
+func collectWindowsBIOSReport(ctx context.Context) (*BIOSReport, error) {
+	report := &BIOSReport{}
+
+	for _, values := range wmiRecords(ctx, &report.Errors, sectionBIOS, "Win32_BIOS", "Manufacturer", "SMBIOSBIOSVersion", "ReleaseDate") {
+		report.BIOS = append(report.BIOS, BIOSInfo{
+			Manufacturer: values["Manufacturer"],
+			Version:      values["SMBIOSBIOSVersion"],
+			ReleaseDate:  formatWMIDate(values["ReleaseDate"]),
+		})
+	}
+
+	return report, nil
+}
@@ -2,49 +2,71 @@ package modules
 
 import (
 	"bytes"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"context"
+	"fmt"
 )
 
+var driverCollector = &reportCollector{
+	collectorInfo: collectorInfo{
+		id:          "drivers",
+		name:        "Generate Driver Report",
+		outputFiles: []string{"Driver_Report.txt"},
+	},
+	collect: collectFunc(CollectDriverReport),
+}
+
+func init() {
+	Register(driverCollector)
+}
+
+// DriverEntry describes an installed driver as listed by `driverquery /v`, or a loaded Linux kernel module.
+type DriverEntry struct {
+	ModuleName  string `json:"module_name"`
+	DisplayName string `json:"display_name,omitempty"`
+	Description string `json:"description,omitempty"`
+	DriverType  string `json:"driver_type,omitempty"`
+	StartMode   string `json:"start_mode,omitempty"`
+	State       string `json:"state,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Version     string `json:"version,omitempty"`   // Kernel modules only
+	LinkDate    string `json:"link_date,omitempty"` // Closest to an install date driverquery offers
+	Path        string `json:"path,omitempty"`
+}
+
+// DriverReport is the structured result of the driver report.
+type DriverReport struct {
+	Drivers []DriverEntry `json:"drivers"`
+	Errors  SectionErrors `json:"errors,omitempty"`
+}
+
+const sectionDrivers = "All Installed Drivers and Details"
+
+// WriteText renders the report in GoDiag's plain-text layout.
+func (r *DriverReport) WriteText(output *bytes.Buffer) {
+	writeSection(output, sectionDrivers, r.Errors, func() {
+		if len(r.Drivers) == 0 {
+			writeNone(output, "drivers")
+			return
+		}
+		output.WriteString(fmt.Sprintf("%d drivers installed.\n\n", len(r.Drivers)))
+		for _, driver := range r.Drivers {
+			writeFields(output,
+				field{"Module Name", driver.ModuleName},
+				field{"Display Name", driver.DisplayName},
+				field{"Description", driver.Description},
+				field{"Driver Type", driver.DriverType},
+				field{"Start Mode", driver.StartMode},
+				field{"State", driver.State},
+				field{"Status", driver.Status},
+				field{"Version", driver.Version},
+				field{"Link Date", driver.LinkDate},
+				field{"Path", driver.Path},
+			)
+		}
+	})
+}
+
 // GenerateDriverReport gathers information about installed drivers and saves it to a text file.
-func GenerateDriverReport(outputDir string) error {
-	var output bytes.Buffer
-	outputPath := filepath.Join(outputDir, "Driver_Report.txt")
-
-	// --- 1. List All Installed Drivers with Details ---
-	output.WriteString("--- All Installed Drivers and Details ---\n\n")
-	// Using 'driverquery /FO LIST /v' to get verbose driver information in a list format.
-	// This typically includes:
-	//   Module Name
-	//   Display Name
-	//   Description
-	//   Product Type
-	//   Service Type
-	//   Running State
-	//   Start Mode
-	//   Status
-	//   Accept Stop
-	//   Accept Pause
-	//   Paged Pool(Bytes)
-	//   Code(Bytes)
-	//   BSS(Bytes)
-	//   Link Date (closest to install date available via this command)
-	//   Path
-	//   Init (Bytes)
-	//   Version
-	//   Company
-	driverInfo, err := exec.Command("driverquery", "/FO", "LIST", "/v").Output()
-	if err != nil {
-		output.WriteString("Error gathering driver information: " + err.Error() + "\n\n")
-	} else {
-		output.Write(driverInfo)
-	}
-	output.WriteString("\n\n")
-
-	// --- Footer ---
-	output.WriteString("\n\nReport generated by GoDiag. Learn more at https://github.com/LewdLillyVT/godiag")
-
-	// Save to file
-	return os.WriteFile(outputPath, output.Bytes(), 0644)
+func GenerateDriverReport(ctx context.Context, outputDir string) error {
+	return driverCollector.Run(ctx, outputDir)
 }
@@ -0,0 +1,62 @@
+package modules
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CollectDriverReport gathers information about loaded and built-in kernel modules.
+func CollectDriverReport(ctx context.Context) (*DriverReport, error) {
+	report := &DriverReport{}
+
+	// /proc/modules is what lsmod prints: "name size refcount used-by state address [taint]"
+	modules, err := os.ReadFile("/proc/modules")
+	if err != nil {
+		report.Errors.Add(sectionDrivers, err)
+		return report, nil
+	}
+	loaded := make(map[string]bool)
+	for _, line := range strings.Split(normalizeOutput(modules), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		driver := DriverEntry{
+			ModuleName: fields[0],
+			DriverType: "Loadable Module",
+			State:      fields[4],
+			Status:     "OK",
+			Version:    readSysFile("/sys/module", fields[0], "version"),
+		}
+		if usedBy := strings.Trim(fields[3], "-,"); usedBy != "" {
+			driver.Description = "Used by: " + strings.ReplaceAll(usedBy, ",", ", ")
+		}
+		if len(fields) > 6 {
+			driver.Status = "Tainted " + fields[6]
+		}
+		loaded[fields[0]] = true
+		report.Drivers = append(report.Drivers, driver)
+	}
+
+	// Modules compiled into the kernel only appear under /sys/module
+	builtIn, _ := filepath.Glob("/sys/module/*")
+	sort.Strings(builtIn)
+	for _, dir := range builtIn {
+		name := filepath.Base(dir)
+		if loaded[name] {
+			continue
+		}
+		report.Drivers = append(report.Drivers, DriverEntry{
+			ModuleName: name,
+			DriverType: "Built-in",
+			State:      "Built-in",
+			Status:     "OK",
+			Version:    readSysFile(dir, "version"),
+		})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,37 @@
+package modules
+
+import "context"
+
+// collectWindowsDriverReport gathers information about installed drivers.
+func collectWindowsDriverReport(ctx context.Context) (*DriverReport, error) {
+	report := &DriverReport{}
+
+	// 'driverquery /FO CSV /v' lists every driver with verbose details. The columns are always
+	// Module Name, Display Name, Description, Driver Type, Start Mode, State, Status, Accept Stop,
+	// Accept Pause, Paged Pool, Code, BSS, Link Date, Path, Init; the headers themselves are
+	// localized, so columns are read by position.
+	driverInfo, err := runner.Output(ctx, "driverquery", "/FO", "CSV", "/v")
+	if err == nil {
+		var rows [][]string
+		if rows, err = parseCSVRecords(driverInfo); err == nil {
+			for _, row := range rows {
+				report.Drivers = append(report.Drivers, DriverEntry{
+					ModuleName:  column(row, 0),
+					DisplayName: column(row, 1),
+					Description: column(row, 2),
+					DriverType:  column(row, 3),
+					StartMode:   column(row, 4),
+					State:       column(row, 5),
+					Status:      column(row, 6),
+					LinkDate:    column(row, 12),
+					Path:        column(row, 13),
+				})
+			}
+		}
+	}
+	if err != nil {
+		report.Errors.Add(sectionDrivers, err)
+	}
+
+	return report, nil
+}
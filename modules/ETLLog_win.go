@@ -0,0 +1,29 @@
+package modules
+
+import (
+	"context"
+	"path/filepath"
+)
+
+var etlCollector = &toolCollector{
+	collectorInfo: collectorInfo{
+		id:          "etl",
+		name:        "Generate Event Trace Log (ETL)",
+		outputFiles: []string{"event_trace_log.evtx"},
+	},
+	run: GenerateETLLog,
+}
+
+// generateETLLog generates an Event Trace Log (ETL) file and saves it to the output directory.
+func GenerateETLLog(ctx context.Context, outputDir string) error {
+	outputPath := filepath.Join(outputDir, "event_trace_log.evtx")
+
+	// Use wevtutil to export the system log to an ETL file
+	_, err := runner.CombinedOutput(ctx, "wevtutil", "epl", "System", outputPath)
+	if err != nil {
+		return err
+	}
+
+	// If successful, we can optionally log the success message to the output
+	return nil
+}
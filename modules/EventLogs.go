@@ -2,46 +2,287 @@ package modules
 
 import (
 	"bytes"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// dumpEventLogs extracts the last 10 warnings, errors, and critical errors from the event logs.
-func DumpEventLogs(outputDir string) error {
-	var output bytes.Buffer
-	outputPath := filepath.Join(outputDir, "Event_Log_Dump.txt")
+var eventLogCollector = &reportCollector{
+	collectorInfo: collectorInfo{
+		id:          "eventlogs",
+		name:        "Dump Latest Event Logs",
+		outputFiles: []string{"Event_Log_Dump.txt"},
+	},
+	collect: collectFunc(CollectEventLogs),
+}
 
-	// Gather last 10 warnings
-	output.WriteString("Last 10 Warning Events:\n")
-	warnings, err := exec.Command("wevtutil", "qe", "System", "/q:*[System[(Level=3)]]", "/c:10", "/f:text").Output()
-	if err != nil {
-		output.WriteString("Error gathering warning events.\n")
-	} else {
-		output.Write(warnings)
+func init() {
+	Register(eventLogCollector)
+}
+
+// Event is a single Windows event log entry or systemd journal entry.
+type Event struct {
+	LogName  string `json:"log_name,omitempty"`
+	Provider string `json:"provider"`
+	EventID  int64  `json:"event_id"`            // Zero for journal entries, which have none
+	RecordID int64  `json:"record_id,omitempty"` // Position of the event in its log
+	Level    string `json:"level,omitempty"`
+	Time     string `json:"time,omitempty"` // Local time in eventTimeLayout
+	Computer string `json:"computer,omitempty"`
+	User     string `json:"user,omitempty"` // Account SID on Windows
+	Message  string `json:"message,omitempty"`
+
+	Data       []EventData `json:"data,omitempty"`        // The event's insertion strings, from <EventData> or <UserData>
+	KnownIssue string      `json:"known_issue,omitempty"` // ID of the KnownIssue the event is an occurrence of
+}
+
+// EventData is a single named value carried by an event.
+type EventData struct {
+	Name  string `json:"name,omitempty"` // Empty for classic events, whose values are positional
+	Value string `json:"value"`
+}
+
+// EventGroup is the result of one event log query, such as the latest warnings from the System log.
+type EventGroup struct {
+	Title   string  `json:"title"`
+	Channel string  `json:"channel"`
+	Query   string  `json:"query"`
+	Events  []Event `json:"events"`
+}
+
+// EventLogReport is the structured result of the event log dump.
+type EventLogReport struct {
+	KnownIssues []KnownIssueHit `json:"known_issues,omitempty"`
+	Summary     *EventSummary   `json:"summary,omitempty"`
+	Groups      []EventGroup    `json:"groups"`
+	Errors      SectionErrors   `json:"errors,omitempty"`
+}
+
+// topEventsLimit is how many recurring event IDs the summary lists.
+const topEventsLimit = 10
+
+// EventCount counts the events from one provider, or with one provider and event ID.
+type EventCount struct {
+	Provider string `json:"provider"`
+	EventID  int64  `json:"event_id,omitempty"`
+	Level    string `json:"level,omitempty"`   // Level of the latest event
+	Message  string `json:"message,omitempty"` // Message of the latest event
+	Count    int    `json:"count"`
+
+	KnownIssue string `json:"known_issue,omitempty"` // ID of the KnownIssue the latest event is an occurrence of
+	First      string `json:"first,omitempty"`
+	Last       string `json:"last,omitempty"`
+}
+
+// EventSummary aggregates the events of every group, counting each event once even when
+// several queries returned it.
+type EventSummary struct {
+	Total     int          `json:"total"`
+	TopEvents []EventCount `json:"top_events"` // Most frequent event IDs first, at most topEventsLimit
+	Providers []EventCount `json:"providers"`  // Every provider, most frequent first
+}
+
+// summarizeEvents aggregates the events in groups, or returns nil when there are none.
+func summarizeEvents(groups []EventGroup) *EventSummary {
+	summary := &EventSummary{}
+	seen := make(map[string]bool)
+	byID := make(map[string]*EventCount)
+	byProvider := make(map[string]*EventCount)
+	var ids, providers []*EventCount
+
+	for _, group := range groups {
+		for _, event := range group.Events {
+			key := eventKey(event)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			summary.Total++
+
+			provider := byProvider[event.Provider]
+			if provider == nil {
+				provider = &EventCount{Provider: event.Provider}
+				byProvider[event.Provider] = provider
+				providers = append(providers, provider)
+			}
+			provider.add(event)
+
+			// Journal entries have no event IDs to group by
+			if event.EventID == 0 {
+				continue
+			}
+			idKey := event.Provider + "\x00" + strconv.FormatInt(event.EventID, 10)
+			id := byID[idKey]
+			if id == nil {
+				id = &EventCount{Provider: event.Provider, EventID: event.EventID}
+				byID[idKey] = id
+				ids = append(ids, id)
+			}
+			id.add(event)
+		}
+	}
+	if summary.Total == 0 {
+		return nil
 	}
 
-	// Gather last 10 errors
-	output.WriteString("\nLast 10 Error Events:\n")
-	errors, err := exec.Command("wevtutil", "qe", "System", "/q:*[System[(Level=2)]]", "/c:10", "/f:text").Output()
-	if err != nil {
-		output.WriteString("Error gathering error events.\n")
-	} else {
-		output.Write(errors)
+	sortEventCounts(ids)
+	sortEventCounts(providers)
+	for i, id := range ids {
+		if i == topEventsLimit {
+			break
+		}
+		summary.TopEvents = append(summary.TopEvents, *id)
+	}
+	for _, provider := range providers {
+		summary.Providers = append(summary.Providers, *provider)
 	}
+	return summary
+}
 
-	// Gather last 10 critical errors
-	output.WriteString("\nLast 10 Critical Events:\n")
-	criticals, err := exec.Command("wevtutil", "qe", "System", "/q:*[System[(Level=1)]]", "/c:10", "/f:text").Output()
+// eventKey identifies an event across groups, so an event returned by several queries is counted once.
+func eventKey(event Event) string {
+	if event.RecordID == 0 {
+		return event.Time + "\x00" + event.Provider + "\x00" + event.Message
+	}
+	return event.LogName + "\x00" + strconv.FormatInt(event.RecordID, 10)
+}
+
+// add counts event, widening the first/last range and keeping the latest event's details.
+func (c *EventCount) add(event Event) {
+	c.Count++
+	t, err := time.ParseInLocation(eventTimeLayout, event.Time, time.Local)
 	if err != nil {
-		output.WriteString("Error gathering critical events.\n")
-	} else {
-		output.Write(criticals)
+		return
+	}
+	if first, err := time.ParseInLocation(eventTimeLayout, c.First, time.Local); err != nil || t.Before(first) {
+		c.First = event.Time
 	}
+	if last, err := time.ParseInLocation(eventTimeLayout, c.Last, time.Local); err != nil || !t.Before(last) {
+		c.Last = event.Time
+		if c.EventID != 0 {
+			c.Level = event.Level
+			c.Message = event.Message
+			c.KnownIssue = event.KnownIssue
+		}
+	}
+}
 
-	// Append footer
-	output.WriteString("\n\nReport generated by GoDiag. Learn more at https://github.com/LewdLillyVT/godiag")
+// sortEventCounts orders counts from most to least frequent, the most recent first among equals.
+func sortEventCounts(counts []*EventCount) {
+	sort.SliceStable(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Last > counts[j].Last
+	})
+}
+
+// firstLine returns the first line of s, for messages shown in a summary.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// writeEvents renders events in GoDiag's plain-text layout.
+func writeEvents(output *bytes.Buffer, events []Event) {
+	if len(events) == 0 {
+		writeNone(output, "events")
+		return
+	}
+	for _, event := range events {
+		eventID := ""
+		if event.EventID != 0 {
+			eventID = fmt.Sprint(event.EventID)
+		}
+		writeFields(output,
+			field{"Time", event.Time},
+			field{"Log", event.LogName},
+			field{"Source", event.Provider},
+			field{"Event ID", eventID},
+			field{"Level", event.Level},
+			field{"Computer", event.Computer},
+			field{"User", event.User},
+			field{"Message", event.Message},
+			field{"Known Issue", event.KnownIssue},
+		)
+	}
+}
+
+// WriteText renders the report in GoDiag's plain-text layout.
+func (r *EventLogReport) WriteText(output *bytes.Buffer) {
+	if len(r.KnownIssues) > 0 {
+		writeSection(output, "Known Issues", nil, func() {
+			writeKnownIssues(output, r.KnownIssues)
+		})
+	}
+	if r.Summary != nil {
+		r.Summary.WriteText(output)
+	}
+	for _, group := range r.Groups {
+		writeSection(output, group.Title, r.Errors, func() {
+			writeEvents(output, group.Events)
+		})
+	}
+}
+
+// WriteText renders the summary as the "Event Summary" section.
+func (s *EventSummary) WriteText(output *bytes.Buffer) {
+	writeSection(output, "Event Summary", nil, func() {
+		output.WriteString(fmt.Sprintf("%d events from %d providers.\n\n", s.Total, len(s.Providers)))
+
+		if len(s.TopEvents) > 0 {
+			output.WriteString("Top recurring event IDs:\n\n")
+			for _, id := range s.TopEvents {
+				writeFields(output,
+					field{"Event ID", fmt.Sprintf("%d (%s)", id.EventID, id.Provider)},
+					field{"Level", id.Level},
+					field{"Count", strconv.Itoa(id.Count)},
+					field{"First", id.First},
+					field{"Last", id.Last},
+					field{"Message", firstLine(id.Message)},
+					field{"Known Issue", id.KnownIssue},
+				)
+			}
+		}
+
+		output.WriteString("Events per provider:\n\n")
+		width := 0
+		for _, provider := range s.Providers {
+			width = max(width, len(provider.Provider))
+		}
+		for _, provider := range s.Providers {
+			output.WriteString(fmt.Sprintf("%-*s %6d  %s - %s\n", width, provider.Provider, provider.Count, provider.First, provider.Last))
+		}
+	})
+}
+
+// writeKnownIssues explains each known issue found, with its occurrences and next steps.
+func writeKnownIssues(output *bytes.Buffer, hits []KnownIssueHit) {
+	for _, hit := range hits {
+		output.WriteString(fmt.Sprintf("[%s] %s\n", hit.Issue.ID, hit.Issue.Title))
+		occurrences := fmt.Sprintf("%d events", hit.Count)
+		if hit.Count == 1 {
+			occurrences = "1 event"
+		}
+		if hit.Last != "" {
+			occurrences += fmt.Sprintf(", %s - %s", hit.First, hit.Last)
+		}
+		output.WriteString(fmt.Sprintf("Seen: %s\n", occurrences))
+		output.WriteString(hit.Issue.Explanation + "\n")
+		if len(hit.Issue.NextSteps) > 0 {
+			output.WriteString("Next steps:\n")
+			for _, step := range hit.Issue.NextSteps {
+				output.WriteString("  - " + step + "\n")
+			}
+		}
+		output.WriteString("\n")
+	}
+}
 
-	// Save to file
-	return os.WriteFile(outputPath, output.Bytes(), 0644)
+// DumpEventLogs extracts the last 10 warnings, errors, and critical errors from the event logs.
+func DumpEventLogs(ctx context.Context, outputDir string) error {
+	return eventLogCollector.Run(ctx, outputDir)
 }
@@ -0,0 +1,132 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CollectEventLogs runs the configured event queries (by default the last 10 warnings, errors,
+// and critical errors) against the systemd journal. The journal has no channels, so each query
+// runs once whatever channels it names.
+func CollectEventLogs(ctx context.Context) (*EventLogReport, error) {
+	report := &EventLogReport{}
+
+	for _, query := range EventQueries() {
+		if err := query.Validate(); err != nil {
+			return nil, err
+		}
+		matches := journalMatches(query)
+		group := EventGroup{Title: query.DisplayTitle(), Channel: "journal", Query: strings.Join(matches, " ")}
+
+		args := []string{"--no-pager", "-o", "json", "-r", "-n", strconv.Itoa(query.Limit())}
+		if window, _ := ParseEventWindow(query.Since); window > 0 {
+			args = append(args, "--since", time.Now().Add(-window).Format("2006-01-02 15:04:05"))
+		}
+		events, err := runner.Output(ctx, "journalctl", append(args, matches...)...)
+		if err == nil {
+			group.Events, err = parseJournalEvents(events)
+		}
+		if err != nil {
+			report.Errors.Add(group.Title, err)
+		}
+		report.Groups = append(report.Groups, group)
+	}
+
+	report.KnownIssues = annotateKnownIssues(report.Groups)
+	report.Summary = summarizeEvents(report.Groups)
+	return report, nil
+}
+
+// journalPriorities maps event levels to syslog priorities; emerg, alert and crit together
+// match the Critical level.
+var journalPriorities = map[EventLevel][]int{
+	LevelCritical:    {0, 1, 2},
+	LevelError:       {3},
+	LevelWarning:     {4},
+	LevelInformation: {5, 6},
+	LevelVerbose:     {7},
+}
+
+// journalMatches turns a query's level and provider filters into journalctl field matches.
+// Matches on the same field are ORed and different fields ANDed, just like the XPath filter.
+// The journal has nothing like Windows event IDs, so that filter is ignored.
+func journalMatches(query EventQuery) []string {
+	var matches []string
+	for _, level := range query.Levels {
+		for _, priority := range journalPriorities[level] {
+			matches = append(matches, "PRIORITY="+strconv.Itoa(priority))
+		}
+	}
+	for _, provider := range query.Providers {
+		matches = append(matches, "SYSLOG_IDENTIFIER="+strings.TrimSpace(provider))
+	}
+	return matches
+}
+
+// journalLevels maps syslog priorities to the Windows event levels GoDiag reports.
+var journalLevels = []string{"Critical", "Critical", "Critical", "Error", "Warning", "Information", "Information", "Verbose"}
+
+// parseJournalEvents parses `journalctl -o json` output: one JSON object per line with the
+// journal's upper-case field names.
+func parseJournalEvents(data []byte) ([]Event, error) {
+	var events []Event
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			return nil, fmt.Errorf("failed to parse journal entry: %w", err)
+		}
+
+		event := Event{
+			LogName:  journalField(fields, "_TRANSPORT"),
+			Provider: journalField(fields, "SYSLOG_IDENTIFIER"),
+			Computer: journalField(fields, "_HOSTNAME"),
+			Message:  journalField(fields, "MESSAGE"),
+		}
+		if event.Provider == "" {
+			event.Provider = journalField(fields, "_COMM")
+		}
+		if priority, err := strconv.Atoi(journalField(fields, "PRIORITY")); err == nil && priority >= 0 && priority < len(journalLevels) {
+			event.Level = journalLevels[priority]
+		}
+		if micros, err := strconv.ParseInt(journalField(fields, "__REALTIME_TIMESTAMP"), 10, 64); err == nil {
+			event.Time = time.UnixMicro(micros).Format(eventTimeLayout)
+		}
+		if uid := journalField(fields, "_UID"); uid != "" {
+			event.User = lookupUserName(uid)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// journalField returns a journal field as text. journalctl prints fields that are not valid
+// UTF-8 as arrays of byte values, and repeated fields as arrays of strings.
+func journalField(fields map[string]interface{}, name string) string {
+	switch value := fields[name].(type) {
+	case string:
+		return value
+	case []interface{}:
+		var raw []byte
+		var values []string
+		for _, v := range value {
+			switch v := v.(type) {
+			case float64:
+				raw = append(raw, byte(v))
+			case string:
+				values = append(values, v)
+			}
+		}
+		if len(values) > 0 {
+			return strings.Join(values, "\n")
+		}
+		return strings.ToValidUTF8(string(raw), "�")
+	}
+	return ""
+}
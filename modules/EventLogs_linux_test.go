@@ -0,0 +1,72 @@
+package modules
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseJournalEvents(t *testing.T) {
+	// Event times are shown in the local time zone
+	defer func(local *time.Location) { time.Local = local }(time.Local)
+	time.Local = time.UTC
+
+	got, err := parseJournalEvents(readLinuxFixture(t, "journal.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Event{
+		{
+			LogName: "kernel", Provider: "kernel", Level: "Critical", Time: "2024-03-04T08:15:42.641",
+			Computer: "build-07", Message: "mce: [Hardware Error]: Machine check events logged",
+		},
+		// Logged without an identifier, so the command name stands in for it
+		{
+			LogName: "syslog", Provider: "sshd", Level: "Error", Time: "2024-03-04T08:15:37.000",
+			Computer: "build-07", User: "root", Message: "error: kex_exchange_identification: Connection closed by remote host",
+		},
+		// A message that is not valid UTF-8 is printed as bytes
+		{
+			LogName: "journal", Provider: "backup.sh", Level: "Warning", Time: "2024-03-04T07:56:40.123",
+			Computer: "build-07", Message: "disk full�",
+		},
+		// An unknown priority leaves the level empty, and a repeated field is joined
+		{
+			LogName: "stdout", Provider: "app", Time: "2024-03-04T07:40:00.000",
+			Message: "first line\nsecond line",
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("event %d = %+v\nwant %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseJournalEventsMalformed(t *testing.T) {
+	if events, err := parseJournalEvents([]byte("\n\n")); err != nil || events != nil {
+		t.Errorf("parseJournalEvents() of no output = %+v, %v", events, err)
+	}
+	data := []byte(`{"MESSAGE":"ok"}` + "\n" + `-- No entries --`)
+	if _, err := parseJournalEvents(data); err == nil {
+		t.Error("parseJournalEvents() succeeded")
+	}
+}
+
+func TestJournalMatches(t *testing.T) {
+	query := EventQuery{
+		Levels:    []EventLevel{LevelCritical, LevelWarning},
+		Providers: []string{"sshd", " kernel "},
+		EventIDs:  []int{41},
+	}
+	want := []string{"PRIORITY=0", "PRIORITY=1", "PRIORITY=2", "PRIORITY=4", "SYSLOG_IDENTIFIER=sshd", "SYSLOG_IDENTIFIER=kernel"}
+	if got := journalMatches(query); !reflect.DeepEqual(got, want) {
+		t.Errorf("journalMatches() = %q, want %q", got, want)
+	}
+	if got := journalMatches(EventQuery{}); got != nil {
+		t.Errorf("journalMatches() without filters = %q", got)
+	}
+}
@@ -0,0 +1,90 @@
+package modules
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// collectWindowsEventLogs runs the configured event queries (by default the last 10 warnings, errors,
+// and critical errors from the System event log), one wevtutil query per channel. Events are
+// read as XML; RenderedXml adds the formatted message and level name to what /f:xml returns.
+func collectWindowsEventLogs(ctx context.Context) (*EventLogReport, error) {
+	report := &EventLogReport{}
+
+	for _, query := range EventQueries() {
+		xpath, err := query.XPath()
+		if err != nil {
+			return nil, err
+		}
+		for _, channel := range query.Channels {
+			group := EventGroup{Title: query.DisplayTitle(), Channel: channel, Query: xpath}
+			if len(query.Channels) > 1 {
+				group.Title += " (" + channel + ")"
+			}
+			count := "/c:" + strconv.Itoa(query.Limit())
+			events, err := runner.Output(ctx, "wevtutil", "qe", channel, "/q:"+xpath, count, "/rd:true", "/f:RenderedXml")
+			if err == nil {
+				group.Events, err = ParseEventXML(events)
+			}
+			if err != nil {
+				report.Errors.Add(group.Title, err)
+			}
+			report.Groups = append(report.Groups, group)
+		}
+	}
+
+	report.KnownIssues = annotateKnownIssues(report.Groups)
+	report.Summary = summarizeEvents(report.Groups)
+	return report, nil
+}
+
+// parseTextEvents parses the output of `wevtutil qe ... /f:text`, as read by the security report: an "Event[n]:" line per event,
+// followed by indented "Name: value" lines and a free-text description.
+func parseTextEvents(data []byte) []Event {
+	var events []Event
+	var current *Event
+	inDescription := false
+
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		if strings.HasPrefix(line, "Event[") {
+			events = append(events, Event{})
+			current = &events[len(events)-1]
+			inDescription = false
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if inDescription {
+			current.Message = strings.TrimSpace(current.Message + "\n" + line)
+			continue
+		}
+
+		name, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch name {
+		case "Log Name":
+			current.LogName = value
+		case "Source":
+			current.Provider = value
+		case "Date":
+			current.Time = value
+		case "Event ID":
+			current.EventID = parseInt(value)
+		case "Level":
+			current.Level = value
+		case "User Name":
+			current.User = value
+		case "Computer":
+			current.Computer = value
+		case "Description":
+			current.Message = value
+			inDescription = true
+		}
+	}
+	return events
+}
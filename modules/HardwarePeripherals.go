@@ -2,57 +2,114 @@ package modules
 
 import (
 	"bytes"
-	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"context"
 )
 
-// GenerateHardwareReport gathers various hardware and peripheral-related information and saves it to a text file.
-func GenerateHardwareReport(outputDir string) error {
-	var output bytes.Buffer
-	outputPath := filepath.Join(outputDir, "Hardware_Peripherals_Report.txt")
-
-	// --- 1. Connected USB Devices ---
-	output.WriteString("--- Connected USB Devices ---\n\n")
-	// Using wmic to get USB device captions and device IDs
-	usbDevices, err := exec.Command("wmic", "path", "Win32_PnPEntity", "where", "PNPClass='USB'", "get", "Caption,DeviceID", "/format:list").Output()
-	if err != nil {
-		output.WriteString("Error gathering USB device information: " + err.Error() + "\n\n")
-	} else {
-		output.Write(usbDevices)
-	}
-	output.WriteString("\n\n")
-
-	// --- 2. Printer Information ---
-	output.WriteString("--- Printer Information ---\n\n")
-	// Using wmic to get printer details
-	printers, err := exec.Command("wmic", "printer", "get", "Name,PortName,DriverName,PrinterStatus,Shared", "/format:list").Output()
-	if err != nil {
-		output.WriteString("Error gathering printer information: " + err.Error() + "\n\n")
-	} else {
-		output.Write(printers)
-	}
-	output.WriteString("\n\n")
-
-	// --- 3. Battery Health (for Laptops) ---
-	output.WriteString("--- Battery Health Information ---\n\n")
-	// Using wmic to get battery details. This command will only return data on devices with a battery.
-	batteryInfo, err := exec.Command("wmic", "path", "Win32_Battery", "get", "DesignCapacity,FullChargeCapacity,EstimatedChargeRemaining,BatteryStatus", "/format:list").Output()
-	if err != nil {
-		// If there's an error (e.g., no battery found), print a more informative message.
-		output.WriteString(fmt.Sprintf("Error gathering battery information (may not apply to desktop PCs): %v\n\n", err.Error()))
-	} else if len(bytes.TrimSpace(batteryInfo)) == 0 {
-		// If command ran but returned no data (e.g., on a desktop)
-		output.WriteString("No battery information found (this is common for desktop computers).\n\n")
-	} else {
-		output.Write(batteryInfo)
+var hardwareCollector = &reportCollector{
+	collectorInfo: collectorInfo{
+		id:          "hardware",
+		name:        "Generate Hardware & Peripherals Report",
+		outputFiles: []string{"Hardware_Peripherals_Report.txt"},
+	},
+	collect: collectFunc(CollectHardwareReport),
+}
+
+func init() {
+	Register(hardwareCollector)
+}
+
+// USBDevice is a connected USB device.
+type USBDevice struct {
+	Caption  string `json:"caption" wmi:"Caption"`
+	DeviceID string `json:"device_id,omitempty" wmi:"DeviceID"`
+}
+
+// Printer is an installed printer.
+type Printer struct {
+	Name          string `json:"name" wmi:"Name"`
+	PortName      string `json:"port_name,omitempty" wmi:"PortName"`
+	DriverName    string `json:"driver_name,omitempty" wmi:"DriverName"`
+	PrinterStatus string `json:"printer_status,omitempty" wmi:"PrinterStatus"`
+	Shared        string `json:"shared,omitempty" wmi:"Shared"`
+}
+
+// Battery describes a laptop battery. Capacities are in mWh and zero when the firmware does not report them.
+type Battery struct {
+	DesignCapacity           int64  `json:"design_capacity_mwh,omitempty" wmi:"DesignCapacity"`
+	FullChargeCapacity       int64  `json:"full_charge_capacity_mwh,omitempty" wmi:"FullChargeCapacity"`
+	EstimatedChargeRemaining int64  `json:"estimated_charge_remaining_percent,omitempty" wmi:"EstimatedChargeRemaining"`
+	BatteryStatus            string `json:"battery_status,omitempty" wmi:"BatteryStatus"`
+	WearPercent              int64  `json:"wear_percent,omitempty"` // Share of the design capacity the battery has lost
+}
+
+// HardwareReport is the structured result of the hardware and peripherals report.
+type HardwareReport struct {
+	USBDevices []USBDevice   `json:"usb_devices"`
+	Printers   []Printer     `json:"printers"`
+	Batteries  []Battery     `json:"batteries"`
+	Errors     SectionErrors `json:"errors,omitempty"`
+}
+
+const (
+	sectionUSB      = "Connected USB Devices"
+	sectionPrinters = "Printer Information"
+	sectionBattery  = "Battery Health Information"
+)
+
+// setBatteryWear fills in WearPercent for batteries that report both capacities.
+func setBatteryWear(batteries []Battery) {
+	for i := range batteries {
+		b := &batteries[i]
+		if b.DesignCapacity > 0 && b.FullChargeCapacity > 0 && b.FullChargeCapacity < b.DesignCapacity {
+			b.WearPercent = 100 - b.FullChargeCapacity*100/b.DesignCapacity
+		}
 	}
-	output.WriteString("\n\n")
+}
+
+// WriteText renders the report in GoDiag's plain-text layout.
+func (r *HardwareReport) WriteText(output *bytes.Buffer) {
+	writeSection(output, sectionUSB, r.Errors, func() {
+		if len(r.USBDevices) == 0 {
+			writeNone(output, "USB devices")
+		}
+		for _, device := range r.USBDevices {
+			writeFields(output, field{"Caption", device.Caption}, field{"Device ID", device.DeviceID})
+		}
+	})
 
-	// --- Footer ---
-	output.WriteString("\n\nReport generated by GoDiag. Learn more at https://github.com/LewdLillyVT/godiag")
+	writeSection(output, sectionPrinters, r.Errors, func() {
+		if len(r.Printers) == 0 {
+			writeNone(output, "printers")
+		}
+		for _, printer := range r.Printers {
+			writeFields(output,
+				field{"Name", printer.Name},
+				field{"Port", printer.PortName},
+				field{"Driver", printer.DriverName},
+				field{"Status", printer.PrinterStatus},
+				field{"Shared", printer.Shared},
+			)
+		}
+	})
 
-	// Save to file
-	return os.WriteFile(outputPath, output.Bytes(), 0644)
+	writeSection(output, sectionBattery, r.Errors, func() {
+		if len(r.Batteries) == 0 {
+			// Common on desktops, which have no battery to report on
+			output.WriteString("No battery information found (this is common for desktop computers).\n")
+		}
+		for _, battery := range r.Batteries {
+			writeFields(output,
+				field{"Design Capacity", formatUnit(battery.DesignCapacity, "mWh")},
+				field{"Full Charge Capacity", formatUnit(battery.FullChargeCapacity, "mWh")},
+				field{"Estimated Charge Remaining", formatUnit(battery.EstimatedChargeRemaining, "%")},
+				field{"Battery Status", battery.BatteryStatus},
+				field{"Wear", formatUnit(battery.WearPercent, "%")},
+			)
+		}
+	})
+}
+
+// GenerateHardwareReport gathers various hardware and peripheral-related information and saves it to a text file.
+func GenerateHardwareReport(ctx context.Context, outputDir string) error {
+	return hardwareCollector.Run(ctx, outputDir)
 }
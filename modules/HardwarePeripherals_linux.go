@@ -0,0 +1,113 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// CollectHardwareReport gathers various hardware and peripheral-related information.
+func CollectHardwareReport(ctx context.Context) (*HardwareReport, error) {
+	report := &HardwareReport{}
+
+	// --- 1. Connected USB Devices ---
+	// Devices have an idVendor file; their interfaces ("1-1:1.0") do not.
+	devices, err := filepath.Glob("/sys/bus/usb/devices/*/idVendor")
+	if err != nil {
+		report.Errors.Add(sectionUSB, err)
+	}
+	for _, vendorFile := range devices {
+		dir := filepath.Dir(vendorFile)
+		ids := readSysFile(vendorFile) + ":" + readSysFile(dir, "idProduct")
+		caption := strings.TrimSpace(readSysFile(dir, "manufacturer") + " " + readSysFile(dir, "product"))
+		if caption == "" {
+			caption = "USB device " + ids
+		}
+		report.USBDevices = append(report.USBDevices, USBDevice{Caption: caption, DeviceID: filepath.Base(dir) + " " + ids})
+	}
+
+	// --- 2. Printer Information ---
+	if printers, err := collectCUPSPrinters(ctx); err != nil {
+		report.Errors.Add(sectionPrinters, err)
+	} else {
+		report.Printers = printers
+	}
+
+	// --- 3. Battery Health (for Laptops) ---
+	// Only devices with a battery have a power supply of type "Battery".
+	supplies, _ := filepath.Glob("/sys/class/power_supply/*")
+	for _, dir := range supplies {
+		if readSysFile(dir, "type") != "Battery" {
+			continue
+		}
+		report.Batteries = append(report.Batteries, Battery{
+			DesignCapacity:           batteryEnergy(dir, "design"),
+			FullChargeCapacity:       batteryEnergy(dir, ""),
+			EstimatedChargeRemaining: readSysInt(dir, "capacity"),
+			BatteryStatus:            readSysFile(dir, "status"),
+		})
+	}
+	setBatteryWear(report.Batteries)
+
+	return report, nil
+}
+
+// batteryEnergy reads energy_full[_design] in mWh. Batteries that report charge (µAh) rather
+// than energy (µWh) are converted using their design voltage.
+func batteryEnergy(dir, suffix string) int64 {
+	name := "full"
+	if suffix != "" {
+		name += "_" + suffix
+	}
+	if energy := readSysInt(dir, "energy_"+name); energy > 0 {
+		return energy / 1000
+	}
+	charge, voltage := readSysInt(dir, "charge_"+name), readSysInt(dir, "voltage_min_design")
+	return charge * voltage / 1e9
+}
+
+// collectCUPSPrinters lists the CUPS print queues with `lpstat`.
+func collectCUPSPrinters(ctx context.Context) ([]Printer, error) {
+	status, err := runner.CombinedOutput(ctx, "lpstat", "-p")
+	if err != nil {
+		message := strings.TrimSpace(string(status))
+		if strings.Contains(message, "No destinations added") {
+			return nil, nil
+		}
+		if message == "" {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%v: %s", err, message)
+	}
+
+	// "printer Office is idle.  enabled since ..." or "printer Office disabled since ..."
+	var printers []Printer
+	for _, line := range strings.Split(normalizeOutput(status), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "printer" {
+			continue
+		}
+		state := fields[2]
+		if state == "is" && len(fields) > 3 {
+			state = strings.TrimSuffix(fields[3], ".")
+		}
+		printers = append(printers, Printer{Name: fields[1], PrinterStatus: state})
+	}
+
+	// "device for Office: ipp://printer.local/ipp/print"
+	if devices, err := runner.Output(ctx, "lpstat", "-v"); err == nil {
+		for _, line := range strings.Split(normalizeOutput(devices), "\n") {
+			name, uri, found := strings.Cut(strings.TrimPrefix(line, "device for "), ": ")
+			if !found {
+				continue
+			}
+			for i := range printers {
+				if printers[i].Name == name {
+					printers[i].PortName = uri
+				}
+			}
+		}
+	}
+	return printers, nil
+}
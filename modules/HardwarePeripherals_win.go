@@ -0,0 +1,21 @@
+package modules
+
+import "context"
+
+// collectWindowsHardwareReport gathers various hardware and peripheral-related information.
+func collectWindowsHardwareReport(ctx context.Context) (*HardwareReport, error) {
+	report := &HardwareReport{}
+
+	// --- 1. Connected USB Devices ---
+	queryWMI(ctx, &report.Errors, sectionUSB, &report.USBDevices, "Win32_PnPEntity", "PNPClass='USB'")
+
+	// --- 2. Printer Information ---
+	queryWMI(ctx, &report.Errors, sectionPrinters, &report.Printers, "Win32_Printer", "")
+
+	// --- 3. Battery Health (for Laptops) ---
+	// This command only returns data on devices with a battery.
+	queryWMI(ctx, &report.Errors, sectionBattery, &report.Batteries, "Win32_Battery", "")
+	setBatteryWear(report.Batteries)
+
+	return report, nil
+}
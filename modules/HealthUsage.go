@@ -2,39 +2,103 @@ package modules
 
 import (
 	"bytes"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"context"
+	"fmt"
 )
 
-// generateHealthAndUsageReport gathers detailed health information of drives.
-func GenerateHealthAndUsageReport(outputDir string) error {
-	var output bytes.Buffer
-	outputPath := filepath.Join(outputDir, "Health_Report.txt")
+var healthCollector = &reportCollector{
+	collectorInfo: collectorInfo{
+		id:          "health",
+		name:        "Generate Drive Health Report",
+		outputFiles: []string{"Health_Report.txt"},
+	},
+	collect: collectFunc(CollectHealthAndUsageReport),
+}
 
-	// Collect Drive Health Information
-	output.WriteString("Drive Health Information:\n")
+func init() {
+	Register(healthCollector)
+}
 
-	// Retrieve detailed drive information including model, serial number, size, and status
-	driveInfo, err := exec.Command("wmic", "diskdrive", "get", "Model,SerialNumber,Size,Status").Output()
-	if err != nil {
-		output.WriteString("Error gathering drive health information.\n")
-	} else {
-		output.Write(driveInfo)
-	}
+// DriveInfo describes a physical disk drive and its health as reported by Win32_DiskDrive or /sys/block.
+type DriveInfo struct {
+	Model                  string `json:"model" wmi:"Model"`
+	SerialNumber           string `json:"serial_number,omitempty" wmi:"SerialNumber"`
+	SizeBytes              int64  `json:"size_bytes,omitempty" wmi:"Size"`
+	Status                 string `json:"status,omitempty" wmi:"Status"` // "OK", "Pred Fail", "Degraded", ... ("Pred Fail" is a SMART prediction)
+	LastErrorCode          string `json:"last_error_code,omitempty" wmi:"LastErrorCode"`
+	Capabilities           string `json:"capabilities,omitempty" wmi:"Capabilities"`
+	CapabilityDescriptions string `json:"capability_descriptions,omitempty" wmi:"CapabilityDescriptions"`
+}
 
-	// Collect SMART data if available for each drive
-	output.WriteString("\nSMART Data for Drives:\n")
-	smartData, err := exec.Command("wmic", "diskdrive", "get", "Status,LastErrorCode,Capabilities,CapabilityDescriptions").Output()
-	if err != nil {
-		output.WriteString("Error gathering SMART data.\n")
-	} else {
-		output.Write(smartData)
+// VolumeInfo describes a mounted file system and how full it is, as reported by
+// Win32_LogicalDisk or statfs.
+type VolumeInfo struct {
+	Name        string `json:"name" wmi:"DeviceID"` // Drive letter such as "C:", or mount point
+	Label       string `json:"label,omitempty" wmi:"VolumeName"`
+	FileSystem  string `json:"file_system,omitempty" wmi:"FileSystem"`
+	SizeBytes   int64  `json:"size_bytes" wmi:"Size"`
+	FreeBytes   int64  `json:"free_bytes" wmi:"FreeSpace"`
+	FreePercent int64  `json:"free_percent"`
+}
+
+// HealthReport is the structured result of the drive health report.
+type HealthReport struct {
+	Drives  []DriveInfo   `json:"drives"`
+	Volumes []VolumeInfo  `json:"volumes"`
+	Errors  SectionErrors `json:"errors,omitempty"`
+}
+
+const (
+	sectionDrives  = "Drive Health Information"
+	sectionVolumes = "Volumes"
+)
+
+// setFreePercent fills in FreePercent from the sizes of each volume.
+func setFreePercent(volumes []VolumeInfo) {
+	for i := range volumes {
+		if volumes[i].SizeBytes > 0 {
+			volumes[i].FreePercent = volumes[i].FreeBytes * 100 / volumes[i].SizeBytes
+		}
 	}
+}
+
+// WriteText renders the report in GoDiag's plain-text layout.
+func (r *HealthReport) WriteText(output *bytes.Buffer) {
+	writeSection(output, sectionDrives, r.Errors, func() {
+		if len(r.Drives) == 0 {
+			writeNone(output, "drives")
+			return
+		}
+		for _, drive := range r.Drives {
+			writeFields(output,
+				field{"Model", drive.Model},
+				field{"Serial Number", drive.SerialNumber},
+				field{"Size", formatBytes(drive.SizeBytes)},
+				field{"Status", drive.Status},
+				field{"Last Error Code", drive.LastErrorCode},
+				field{"Capabilities", drive.CapabilityDescriptions},
+			)
+		}
+	})
 
-	// Append footer
-	output.WriteString("\n\nReport generated by GoDiag. Learn more at https://github.com/LewdLillyVT/godiag")
+	writeSection(output, sectionVolumes, r.Errors, func() {
+		if len(r.Volumes) == 0 {
+			writeNone(output, "volumes")
+			return
+		}
+		for _, volume := range r.Volumes {
+			writeFields(output,
+				field{"Volume", volume.Name},
+				field{"Label", volume.Label},
+				field{"File System", volume.FileSystem},
+				field{"Size", formatBytes(volume.SizeBytes)},
+				field{"Free Space", fmt.Sprintf("%s (%d%%)", formatBytes(volume.FreeBytes), volume.FreePercent)},
+			)
+		}
+	})
+}
 
-	// Save to file
-	return os.WriteFile(outputPath, output.Bytes(), 0644)
+// GenerateHealthAndUsageReport gathers detailed health information of drives.
+func GenerateHealthAndUsageReport(ctx context.Context, outputDir string) error {
+	return healthCollector.Run(ctx, outputDir)
 }
@@ -0,0 +1,118 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// CollectHealthAndUsageReport gathers detailed health information of drives, including SMART status.
+func CollectHealthAndUsageReport(ctx context.Context) (*HealthReport, error) {
+	report := &HealthReport{}
+
+	// Every block device with a backing device is a physical drive; loop, RAM and
+	// device-mapper devices have none.
+	devices, err := filepath.Glob("/sys/block/*/device")
+	if err != nil {
+		report.Errors.Add(sectionDrives, err)
+		return report, nil
+	}
+
+	smartctl := true
+	for _, device := range devices {
+		dir := filepath.Dir(device)
+		name := filepath.Base(dir)
+		drive := DriveInfo{
+			Model:        name,
+			SerialNumber: readSysFile(device, "serial"),
+			SizeBytes:    readSysInt(dir, "size") * 512, // Always counted in 512-byte sectors
+		}
+		// SCSI and SATA drives split the name into vendor and model; virtio only has a PCI vendor ID
+		if model := readSysFile(device, "model"); model != "" {
+			drive.Model = strings.TrimSpace(readSysFile(device, "vendor") + " " + model)
+		}
+
+		var capabilities []string
+		if readSysFile(dir, "queue", "rotational") == "1" {
+			capabilities = append(capabilities, "Rotational")
+		} else {
+			capabilities = append(capabilities, "Solid State")
+		}
+		if readSysFile(dir, "removable") == "1" {
+			capabilities = append(capabilities, "Removable")
+		}
+		drive.CapabilityDescriptions = strings.Join(capabilities, ", ")
+
+		// smartctl exits non-zero for many warnings that still come with a verdict, so the
+		// output is read whatever the exit status. It usually needs root.
+		if smartctl {
+			output, err := runner.CombinedOutput(ctx, "smartctl", "-H", "/dev/"+name)
+			if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+				smartctl = false
+			} else {
+				drive.Status = parseSmartctlHealth(output)
+			}
+		}
+		report.Drives = append(report.Drives, drive)
+	}
+
+	if volumes, err := collectVolumes(); err != nil {
+		report.Errors.Add(sectionVolumes, err)
+	} else {
+		report.Volumes = volumes
+	}
+
+	return report, nil
+}
+
+// collectVolumes lists the file systems mounted from block devices with their free space. Bind
+// mounts and subvolumes of a device already listed are skipped.
+func collectVolumes() ([]VolumeInfo, error) {
+	mounts, err := os.ReadFile("/proc/self/mounts")
+	if err != nil {
+		return nil, err
+	}
+
+	var volumes []VolumeInfo
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(mounts), "\n") {
+		// "device mount-point type options dump pass", with spaces in paths escaped as \040
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[0], "/dev/") || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		mountPoint := strings.ReplaceAll(fields[1], "\\040", " ")
+
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(mountPoint, &stat); err != nil {
+			continue
+		}
+		volumes = append(volumes, VolumeInfo{
+			Name:       mountPoint,
+			FileSystem: fields[2],
+			SizeBytes:  int64(stat.Blocks) * int64(stat.Bsize),
+			FreeBytes:  int64(stat.Bavail) * int64(stat.Bsize), // Space available to unprivileged users
+		})
+	}
+	setFreePercent(volumes)
+	return volumes, nil
+}
+
+// parseSmartctlHealth turns the verdict printed by `smartctl -H` into the status Win32_DiskDrive
+// reports: "OK", or "Pred Fail" when SMART predicts a failure. It returns an empty string when
+// there is no verdict, for example without root.
+func parseSmartctlHealth(data []byte) string {
+	text := normalizeOutput(data)
+	switch {
+	case strings.Contains(text, "self-assessment test result: PASSED"), strings.Contains(text, "SMART Health Status: OK"):
+		return "OK"
+	case strings.Contains(text, "self-assessment test result: FAILED"):
+		return "Pred Fail"
+	}
+	return ""
+}
@@ -0,0 +1,20 @@
+package modules
+
+import "testing"
+
+func TestParseSmartctlHealth(t *testing.T) {
+	tests := []struct {
+		output, want string
+	}{
+		{"=== START OF READ SMART DATA SECTION ===\nSMART overall-health self-assessment test result: PASSED\n", "OK"},
+		{"=== START OF READ SMART DATA SECTION ===\r\nSMART Health Status: OK\r\n", "OK"},
+		{"SMART overall-health self-assessment test result: FAILED!\nDrive failure expected in less than 24 hours. SAVE ALL DATA.\n", "Pred Fail"},
+		{"Smartctl open device: /dev/sda failed: Permission denied\n", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := parseSmartctlHealth([]byte(tt.output)); got != tt.want {
+			t.Errorf("parseSmartctlHealth(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package modules
+
+import "context"
+
+// collectWindowsHealthAndUsageReport gathers detailed health information of drives, including SMART status.
+func collectWindowsHealthAndUsageReport(ctx context.Context) (*HealthReport, error) {
+	report := &HealthReport{}
+
+	// Retrieve detailed drive information including model, serial number, size, status and SMART data
+	queryWMI(ctx, &report.Errors, sectionDrives, &report.Drives, "Win32_DiskDrive", "")
+
+	// Free space on the local fixed disks
+	queryWMI(ctx, &report.Errors, sectionVolumes, &report.Volumes, "Win32_LogicalDisk", "DriveType=3")
+	setFreePercent(report.Volumes)
+
+	return report, nil
+}
@@ -2,81 +2,168 @@ package modules
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"strings"
 )
 
-// GenerateNetworkReport gathers various network-related information and saves it to a text file.
-func GenerateNetworkReport(outputDir string) error {
-	var output bytes.Buffer
-	outputPath := filepath.Join(outputDir, "Network_Diagnostics_Report.txt")
-
-	// --- 1. IP Configuration (ipconfig /all) ---
-	output.WriteString("--- IP Configuration (ipconfig /all) ---\n\n")
-	ipConfig, err := exec.Command("ipconfig", "/all").Output()
-	if err != nil {
-		output.WriteString("Error gathering IP configuration: " + err.Error() + "\n\n")
-	} else {
-		output.Write(ipConfig)
-	}
-	output.WriteString("\n\n")
-
-	// --- 2. Active Network Connections (netstat -ano) ---
-	output.WriteString("--- Active Network Connections (netstat -ano) ---\n\n")
-	netstat, err := exec.Command("netstat", "-ano").Output()
-	if err != nil {
-		output.WriteString("Error gathering active network connections: " + err.Error() + "\n\n")
-	} else {
-		output.Write(netstat)
-	}
-	output.WriteString("\n\n")
-
-	// --- 3. Route Print (route print) ---
-	output.WriteString("--- IP Routing Table (route print) ---\n\n")
-	routePrint, err := exec.Command("route", "print").Output()
-	if err != nil {
-		output.WriteString("Error gathering routing table: " + err.Error() + "\n\n")
-	} else {
-		output.Write(routePrint)
-	}
-	output.WriteString("\n\n")
-
-	// --- 4. DNS Cache (ipconfig /displaydns) ---
-	output.WriteString("--- DNS Resolver Cache (ipconfig /displaydns) ---\n\n")
-	dnsCache, err := exec.Command("ipconfig", "/displaydns").Output()
-	if err != nil {
-		output.WriteString("Error gathering DNS cache: " + err.Error() + "\n\n")
-	} else {
-		output.Write(dnsCache)
-	}
-	output.WriteString("\n\n")
-
-	// --- 5. Basic Connectivity Test (ping google.com) ---
-	output.WriteString("--- Basic Connectivity Test (ping google.com) ---\n\n")
-	pingTest, err := exec.Command("ping", "-n", "4", "google.com").Output() // 4 pings
-	if err != nil {
-		output.WriteString("Error performing connectivity test to google.com: " + err.Error() + "\n\n")
-	} else {
-		output.Write(pingTest)
-	}
-	output.WriteString("\n\n")
-
-	// --- Footer ---
-	output.WriteString("\n\nReport generated by GoDiag. Learn more at https://github.com/LewdLillyVT/godiag")
-
-	// Save to file
-	return os.WriteFile(outputPath, output.Bytes(), 0644)
+var networkCollector = &reportCollector{
+	collectorInfo: collectorInfo{
+		id:          "network",
+		name:        "Generate Network Diagnostics Report",
+		outputFiles: []string{"Network_Diagnostics_Report.txt"},
+	},
+	collect: collectFunc(CollectNetworkReport),
+}
+
+func init() {
+	Register(networkCollector)
+}
+
+// pingTarget is the host the basic connectivity test pings.
+const pingTarget = "google.com"
+
+// NetworkAdapter is a network adapter as described by `ipconfig /all` or `ip addr`.
+type NetworkAdapter struct {
+	Name            string   `json:"name"`
+	Description     string   `json:"description,omitempty"`
+	PhysicalAddress string   `json:"physical_address,omitempty"`
+	DHCPEnabled     string   `json:"dhcp_enabled,omitempty"`
+	MediaState      string   `json:"media_state,omitempty"`
+	IPv4Addresses   []string `json:"ipv4_addresses,omitempty"`
+	IPv6Addresses   []string `json:"ipv6_addresses,omitempty"`
+	SubnetMasks     []string `json:"subnet_masks,omitempty"`
+	DefaultGateways []string `json:"default_gateways,omitempty"`
+	DHCPServer      string   `json:"dhcp_server,omitempty"`
+	DNSServers      []string `json:"dns_servers,omitempty"`
+}
+
+// Connection is an active network connection or listening socket from `netstat -ano` or /proc/net.
+type Connection struct {
+	Protocol       string `json:"protocol"`
+	LocalAddress   string `json:"local_address"`
+	ForeignAddress string `json:"foreign_address"`
+	State          string `json:"state,omitempty"` // Empty for UDP
+	PID            int64  `json:"pid"`
+}
+
+// Route is an entry in the routing table.
+type Route struct {
+	Destination string `json:"destination"`
+	Netmask     string `json:"netmask"`
+	Gateway     string `json:"gateway"`
+	Interface   string `json:"interface"`
+	Metric      int64  `json:"metric"`
+}
+
+// DNSRecord is an entry in the DNS resolver cache.
+type DNSRecord struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"` // Numeric record type, e.g. "1" for A
+	TTL     int64  `json:"ttl"`
+	Section string `json:"section,omitempty"`
+	Data    string `json:"data,omitempty"`
 }
 
-// FlushDNSCache flushes the DNS resolver cache.
-func FlushDNSCache() error {
-	cmd := exec.Command("ipconfig", "/flushdns")
-	output, err := cmd.CombinedOutput() // Capture output for potential error messages
-	if err != nil {
-		return fmt.Errorf("failed to flush DNS cache: %v - %s", err, string(output))
-	}
-	// Optionally, you can return a success message from here or let the calling function handle dialogs
-	return nil
+// PingResult summarises a basic connectivity test.
+type PingResult struct {
+	Target    string `json:"target"`
+	Sent      int64  `json:"sent"`
+	Received  int64  `json:"received"`
+	Lost      int64  `json:"lost"`
+	MinimumMS int64  `json:"minimum_ms"`
+	MaximumMS int64  `json:"maximum_ms"`
+	AverageMS int64  `json:"average_ms"`
+}
+
+// NetworkReport is the structured result of the network diagnostics report.
+type NetworkReport struct {
+	HostName    string           `json:"host_name,omitempty"`
+	Adapters    []NetworkAdapter `json:"adapters"`
+	Connections []Connection     `json:"connections"`
+	Routes      []Route          `json:"routes"`
+	DNSCache    []DNSRecord      `json:"dns_cache"`
+	Ping        *PingResult      `json:"ping,omitempty"`
+	Errors      SectionErrors    `json:"errors,omitempty"`
+}
+
+// WriteText renders the report in GoDiag's plain-text layout.
+func (r *NetworkReport) WriteText(output *bytes.Buffer) {
+	writeSection(output, sectionIPConfig, r.Errors, func() {
+		if r.HostName != "" {
+			output.WriteString(fmt.Sprintf("Host Name: %s\n\n", r.HostName))
+		}
+		if len(r.Adapters) == 0 {
+			writeNone(output, "network adapters")
+		}
+		for _, adapter := range r.Adapters {
+			writeFields(output,
+				field{"Adapter", adapter.Name},
+				field{"Description", adapter.Description},
+				field{"Physical Address", adapter.PhysicalAddress},
+				field{"Media State", adapter.MediaState},
+				field{"DHCP Enabled", adapter.DHCPEnabled},
+				field{"DHCP Server", adapter.DHCPServer},
+				field{"IPv4 Addresses", strings.Join(adapter.IPv4Addresses, "\n")},
+				field{"IPv6 Addresses", strings.Join(adapter.IPv6Addresses, "\n")},
+				field{"Subnet Masks", strings.Join(adapter.SubnetMasks, "\n")},
+				field{"Default Gateways", strings.Join(adapter.DefaultGateways, "\n")},
+				field{"DNS Servers", strings.Join(adapter.DNSServers, "\n")},
+			)
+		}
+	})
+
+	writeSection(output, sectionConnections, r.Errors, func() {
+		if len(r.Connections) == 0 {
+			writeNone(output, "connections")
+			return
+		}
+		output.WriteString(fmt.Sprintf("  %-6s %-46s %-46s %-12s %s\n", "Proto", "Local Address", "Foreign Address", "State", "PID"))
+		for _, c := range r.Connections {
+			output.WriteString(fmt.Sprintf("  %-6s %-46s %-46s %-12s %d\n", c.Protocol, c.LocalAddress, c.ForeignAddress, c.State, c.PID))
+		}
+	})
+
+	writeSection(output, sectionRoutes, r.Errors, func() {
+		if len(r.Routes) == 0 {
+			writeNone(output, "routes")
+			return
+		}
+		output.WriteString(fmt.Sprintf("  %-18s %-16s %-16s %-16s %s\n", "Destination", "Netmask", "Gateway", "Interface", "Metric"))
+		for _, route := range r.Routes {
+			output.WriteString(fmt.Sprintf("  %-18s %-16s %-16s %-16s %d\n", route.Destination, route.Netmask, route.Gateway, route.Interface, route.Metric))
+		}
+	})
+
+	writeSection(output, sectionDNSCache, r.Errors, func() {
+		if len(r.DNSCache) == 0 {
+			writeNone(output, "cached DNS records")
+		}
+		for _, record := range r.DNSCache {
+			writeFields(output,
+				field{"Record Name", record.Name},
+				field{"Record Type", record.Type},
+				field{"Time To Live", fmt.Sprint(record.TTL)},
+				field{"Section", record.Section},
+				field{"Data", record.Data},
+			)
+		}
+	})
+
+	writeSection(output, sectionPing, r.Errors, func() {
+		if r.Ping == nil {
+			writeNone(output, "ping results")
+			return
+		}
+		writeFields(output,
+			field{"Target", r.Ping.Target},
+			field{"Packets", fmt.Sprintf("Sent = %d, Received = %d, Lost = %d", r.Ping.Sent, r.Ping.Received, r.Ping.Lost)},
+			field{"Round Trip", fmt.Sprintf("Minimum = %dms, Maximum = %dms, Average = %dms", r.Ping.MinimumMS, r.Ping.MaximumMS, r.Ping.AverageMS)},
+		)
+	})
+}
+
+// GenerateNetworkReport gathers various network-related information and saves it to a text file.
+func GenerateNetworkReport(ctx context.Context, outputDir string) error {
+	return networkCollector.Run(ctx, outputDir)
 }
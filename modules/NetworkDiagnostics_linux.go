@@ -0,0 +1,316 @@
+package modules
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The section titles name the commands each platform runs. They are variables so that tests can
+// render the Windows collectors' reports here.
+var (
+	sectionIPConfig    = "IP Configuration (ip addr)"
+	sectionConnections = "Active Network Connections (/proc/net)"
+	sectionRoutes      = "IP Routing Table (ip route)"
+	sectionDNSCache    = "DNS Resolver Cache"
+	sectionPing        = "Basic Connectivity Test (ping google.com)"
+)
+
+// CollectNetworkReport gathers various network-related information.
+func CollectNetworkReport(ctx context.Context) (*NetworkReport, error) {
+	report := &NetworkReport{}
+	report.HostName, _ = os.Hostname()
+
+	// --- 1. Routes first, as the default gateways belong to the adapters ---
+	routes, routeErr := runner.Output(ctx, "ip", "-j", "route", "show")
+	if routeErr == nil {
+		report.Routes, routeErr = parseIPRoutes(routes)
+	}
+
+	// --- 2. IP Configuration (ip addr) ---
+	if addrs, err := runner.Output(ctx, "ip", "-j", "addr", "show"); err != nil {
+		report.Errors.Add(sectionIPConfig, err)
+	} else if report.Adapters, err = parseIPAddr(addrs); err != nil {
+		report.Errors.Add(sectionIPConfig, err)
+	} else {
+		dnsServers := readNameservers("/etc/resolv.conf")
+		for i := range report.Adapters {
+			adapter := &report.Adapters[i]
+			for _, route := range report.Routes {
+				if route.Destination == "0.0.0.0" && route.Interface == adapter.Name && route.Gateway != "On-link" {
+					adapter.DefaultGateways = append(adapter.DefaultGateways, route.Gateway)
+				}
+			}
+			// resolv.conf is system-wide, so list it under every adapter that can reach a server
+			if len(adapter.DefaultGateways) > 0 {
+				adapter.DNSServers = dnsServers
+			}
+		}
+	}
+
+	// --- 3. Active Network Connections (/proc/net) ---
+	if connections, err := readConnections(); err != nil {
+		report.Errors.Add(sectionConnections, err)
+	} else {
+		report.Connections = connections
+	}
+
+	// --- 4. Route table ---
+	if routeErr != nil {
+		report.Errors.Add(sectionRoutes, routeErr)
+	}
+
+	// --- 5. DNS Cache ---
+	// Neither systemd-resolved nor nscd can list the entries they cache
+	report.Errors.Add(sectionDNSCache, errors.New("the DNS resolver cache cannot be listed on Linux"))
+
+	// --- 6. Basic Connectivity Test (ping google.com) ---
+	if pingTest, err := runner.Output(ctx, "ping", "-c", "4", pingTarget); err != nil { // 4 pings
+		report.Errors.Add(sectionPing, err)
+	} else {
+		report.Ping = parseLinuxPing(pingTarget, pingTest)
+	}
+
+	return report, nil
+}
+
+// ipAddrEntry is an interface as printed by `ip -j addr show`.
+type ipAddrEntry struct {
+	IfName    string `json:"ifname"`
+	OperState string `json:"operstate"`
+	LinkType  string `json:"link_type"`
+	Address   string `json:"address"`
+	AddrInfo  []struct {
+		Family    string `json:"family"`
+		Local     string `json:"local"`
+		PrefixLen int    `json:"prefixlen"`
+		Dynamic   bool   `json:"dynamic"`
+	} `json:"addr_info"`
+}
+
+// parseIPAddr converts the interfaces listed by `ip -j addr show` to adapters.
+func parseIPAddr(data []byte) ([]NetworkAdapter, error) {
+	var entries []ipAddrEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse ip addr output: %w", err)
+	}
+
+	adapters := make([]NetworkAdapter, 0, len(entries))
+	for _, entry := range entries {
+		adapter := NetworkAdapter{
+			Name:            entry.IfName,
+			Description:     entry.LinkType,
+			PhysicalAddress: entry.Address,
+			DHCPEnabled:     "No",
+		}
+		if driver, err := os.Readlink(filepath.Join("/sys/class/net", entry.IfName, "device", "driver")); err == nil {
+			adapter.Description = filepath.Base(driver)
+		}
+		if entry.OperState == "DOWN" {
+			adapter.MediaState = "Media disconnected"
+		}
+		for _, addr := range entry.AddrInfo {
+			if addr.Dynamic {
+				adapter.DHCPEnabled = "Yes"
+			}
+			switch addr.Family {
+			case "inet":
+				adapter.IPv4Addresses = append(adapter.IPv4Addresses, addr.Local)
+				adapter.SubnetMasks = append(adapter.SubnetMasks, net.IP(net.CIDRMask(addr.PrefixLen, 32)).String())
+			case "inet6":
+				adapter.IPv6Addresses = append(adapter.IPv6Addresses, fmt.Sprintf("%s/%d", addr.Local, addr.PrefixLen))
+			}
+		}
+		adapters = append(adapters, adapter)
+	}
+	return adapters, nil
+}
+
+// parseIPRoutes converts the main IPv4 routing table printed by `ip -j route show` to the
+// layout of `route print`.
+func parseIPRoutes(data []byte) ([]Route, error) {
+	var entries []struct {
+		Dst     string `json:"dst"`
+		Gateway string `json:"gateway"`
+		Dev     string `json:"dev"`
+		Metric  int64  `json:"metric"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse ip route output: %w", err)
+	}
+
+	routes := make([]Route, 0, len(entries))
+	for _, entry := range entries {
+		route := Route{Gateway: entry.Gateway, Interface: entry.Dev, Metric: entry.Metric}
+		if route.Gateway == "" {
+			route.Gateway = "On-link"
+		}
+		switch {
+		case entry.Dst == "default":
+			route.Destination, route.Netmask = "0.0.0.0", "0.0.0.0"
+		case strings.Contains(entry.Dst, "/"):
+			_, network, err := net.ParseCIDR(entry.Dst)
+			if err != nil {
+				continue
+			}
+			route.Destination, route.Netmask = network.IP.String(), net.IP(network.Mask).String()
+		default:
+			route.Destination, route.Netmask = entry.Dst, "255.255.255.255"
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// readNameservers lists the nameserver entries of a resolv.conf file.
+func readNameservers(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var servers []string
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
+
+// tcpStates maps the hexadecimal socket states in /proc/net/tcp to the names netstat uses on Windows.
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECEIVED",
+	"04": "FIN_WAIT_1",
+	"05": "FIN_WAIT_2",
+	"06": "TIME_WAIT",
+	"07": "CLOSED",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTENING",
+	"0B": "CLOSING",
+}
+
+// readConnections lists TCP and UDP sockets from /proc/net, attributing them to processes where
+// the socket's owner can be seen (all of them when running as root).
+func readConnections() ([]Connection, error) {
+	owners := socketOwners()
+
+	var connections []Connection
+	for _, table := range []struct{ file, protocol string }{
+		{"tcp", "TCP"}, {"tcp6", "TCP"}, {"udp", "UDP"}, {"udp6", "UDP"},
+	} {
+		data, err := os.ReadFile(filepath.Join("/proc/net", table.file))
+		if err != nil {
+			if os.IsNotExist(err) { // No IPv6 support
+				continue
+			}
+			return nil, err
+		}
+		connections = append(connections, parseProcNet(data, table.protocol, owners)...)
+	}
+	return connections, nil
+}
+
+// parseProcNet parses a /proc/net/tcp or /proc/net/udp table:
+// "sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...".
+func parseProcNet(data []byte, protocol string, owners map[string]int64) []Connection {
+	var connections []Connection
+	for _, line := range strings.Split(normalizeOutput(data), "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+		connection := Connection{
+			Protocol:       protocol,
+			LocalAddress:   decodeProcNetAddress(fields[1]),
+			ForeignAddress: decodeProcNetAddress(fields[2]),
+			PID:            owners[fields[9]],
+		}
+		if protocol == "TCP" {
+			connection.State = tcpStates[fields[3]]
+		} else if strings.HasSuffix(connection.ForeignAddress, ":0") {
+			connection.ForeignAddress = "*:*"
+		}
+		connections = append(connections, connection)
+	}
+	return connections
+}
+
+// decodeProcNetAddress converts a /proc/net address such as "0100007F:0035" (an IPv4 or IPv6
+// address stored as little-endian 32-bit words, then the port) to "127.0.0.1:53".
+func decodeProcNetAddress(value string) string {
+	addrHex, portHex, found := strings.Cut(value, ":")
+	if !found {
+		return value
+	}
+	raw, err := hex.DecodeString(addrHex)
+	if err != nil || len(raw)%4 != 0 {
+		return value
+	}
+	for i := 0; i < len(raw); i += 4 {
+		raw[i], raw[i+1], raw[i+2], raw[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	port, _ := strconv.ParseUint(portHex, 16, 16)
+	return net.JoinHostPort(net.IP(raw).String(), strconv.FormatUint(port, 10))
+}
+
+// socketOwners maps socket inodes to the processes holding them open, from the /proc/<pid>/fd
+// links that can be read.
+func socketOwners() map[string]int64 {
+	owners := make(map[string]int64)
+	links, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, link := range links {
+		target, err := os.Readlink(link)
+		if err != nil || !strings.HasPrefix(target, "socket:[") {
+			continue
+		}
+		inode := strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")
+		owners[inode] = parseInt(filepath.Base(filepath.Dir(filepath.Dir(link))))
+	}
+	return owners
+}
+
+var (
+	linuxPingPacketsPattern = regexp.MustCompile(`(\d+) packets transmitted, (\d+) (?:packets )?received`)
+	linuxPingTimesPattern   = regexp.MustCompile(`= ([\d.]+)/([\d.]+)/([\d.]+)/[\d.]+ ms`)
+)
+
+// parseLinuxPing extracts the packet and round-trip statistics printed at the end of `ping -c`.
+func parseLinuxPing(target string, data []byte) *PingResult {
+	result := &PingResult{Target: target}
+	text := normalizeOutput(data)
+	if m := linuxPingPacketsPattern.FindStringSubmatch(text); m != nil {
+		result.Sent, result.Received = parseInt(m[1]), parseInt(m[2])
+		result.Lost = result.Sent - result.Received
+	}
+	if m := linuxPingTimesPattern.FindStringSubmatch(text); m != nil {
+		result.MinimumMS, result.AverageMS, result.MaximumMS = roundMS(m[1]), roundMS(m[2]), roundMS(m[3])
+	}
+	return result
+}
+
+// roundMS rounds a fractional millisecond value as printed by ping.
+func roundMS(value string) int64 {
+	ms, _ := strconv.ParseFloat(value, 64)
+	return int64(math.Round(ms))
+}
+
+// FlushDNSCache flushes the systemd-resolved DNS cache.
+func FlushDNSCache(ctx context.Context) error {
+	output, err := runner.CombinedOutput(ctx, "resolvectl", "flush-caches")
+	if err != nil {
+		return fmt.Errorf("failed to flush DNS cache: %v - %s", err, string(output))
+	}
+	return nil
+}
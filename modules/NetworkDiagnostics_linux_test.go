@@ -0,0 +1,118 @@
+package modules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIPAddr(t *testing.T) {
+	got, err := parseIPAddr(readLinuxFixture(t, "ip_addr.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// None of the interfaces has a device on the test machine, so they are described by link type
+	want := []NetworkAdapter{
+		{
+			Name: "lo", Description: "loopback", PhysicalAddress: "00:00:00:00:00:00", DHCPEnabled: "No",
+			IPv4Addresses: []string{"127.0.0.1"}, SubnetMasks: []string{"255.0.0.0"}, IPv6Addresses: []string{"::1/128"},
+		},
+		{
+			Name: "enx8c16453a7be2", Description: "ether", PhysicalAddress: "8c:16:45:3a:7b:e2", DHCPEnabled: "Yes",
+			IPv4Addresses: []string{"192.168.1.23"}, SubnetMasks: []string{"255.255.255.0"},
+			IPv6Addresses: []string{"fe80::8e16:45ff:fe3a:7be2/64"},
+		},
+		{
+			Name: "wlp2s0", Description: "ether", PhysicalAddress: "a4:c3:f0:12:34:56", DHCPEnabled: "No",
+			MediaState: "Media disconnected",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseIPAddr() = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseIPRoutes(t *testing.T) {
+	got, err := parseIPRoutes(readLinuxFixture(t, "ip_route.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The route with an unreadable destination is skipped
+	want := []Route{
+		{Destination: "0.0.0.0", Netmask: "0.0.0.0", Gateway: "192.168.1.1", Interface: "enx8c16453a7be2", Metric: 100},
+		{Destination: "169.254.0.0", Netmask: "255.255.0.0", Gateway: "On-link", Interface: "enx8c16453a7be2", Metric: 1000},
+		{Destination: "192.168.1.0", Netmask: "255.255.255.0", Gateway: "On-link", Interface: "enx8c16453a7be2", Metric: 100},
+		{Destination: "10.8.0.1", Netmask: "255.255.255.255", Gateway: "On-link", Interface: "tun0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseIPRoutes() = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseIPMalformed(t *testing.T) {
+	data := []byte("Object \"-j\" is unknown, try \"ip help\".")
+	if _, err := parseIPAddr(data); err == nil {
+		t.Error("parseIPAddr() succeeded")
+	}
+	if _, err := parseIPRoutes(data); err == nil {
+		t.Error("parseIPRoutes() succeeded")
+	}
+}
+
+func TestParseProcNet(t *testing.T) {
+	owners := map[string]int64{"98765": 4242, "45678": 611}
+	tests := []struct {
+		file, protocol string
+		want           []Connection
+	}{
+		// The truncated line is skipped
+		{"proc_net_tcp.txt", "TCP", []Connection{
+			{Protocol: "TCP", LocalAddress: "127.0.0.53:53", ForeignAddress: "0.0.0.0:0", State: "LISTENING"},
+			{Protocol: "TCP", LocalAddress: "192.168.1.23:47074", ForeignAddress: "142.213.58.46:443", State: "ESTABLISHED", PID: 4242},
+			{Protocol: "TCP", LocalAddress: "192.168.1.23:47076", ForeignAddress: "142.213.58.46:443", State: "TIME_WAIT"},
+		}},
+		{"proc_net_udp6.txt", "UDP", []Connection{
+			{Protocol: "UDP", LocalAddress: "[::]:5353", ForeignAddress: "*:*"},
+			{Protocol: "UDP", LocalAddress: "192.168.1.23:68", ForeignAddress: "*:*", PID: 611},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := parseProcNet(readLinuxFixture(t, tt.file), tt.protocol, owners); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseProcNet() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+	if got := parseProcNet(nil, "TCP", owners); got != nil {
+		t.Errorf("parseProcNet() of an empty table = %+v", got)
+	}
+}
+
+func TestDecodeProcNetAddress(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"0100007F:0035", "127.0.0.1:53"},
+		{"00000000000000000000000001000000:01BB", "[::1]:443"},
+		{"0100007F", "0100007F"},
+		{"NOTHEX00:0035", "NOTHEX00:0035"},
+		{"01007F:0035", "01007F:0035"},
+	}
+	for _, tt := range tests {
+		if got := decodeProcNetAddress(tt.value); got != tt.want {
+			t.Errorf("decodeProcNetAddress(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseLinuxPing(t *testing.T) {
+	got := parseLinuxPing("google.com", readLinuxFixture(t, "ping.txt"))
+	want := &PingResult{Target: "google.com", Sent: 4, Received: 3, Lost: 1, MinimumMS: 12, AverageMS: 13, MaximumMS: 15}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLinuxPing() = %+v, want %+v", got, want)
+	}
+
+	got = parseLinuxPing("google.com", []byte("ping: google.com: Temporary failure in name resolution\n"))
+	if want := (&PingResult{Target: "google.com"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLinuxPing() of a failed ping = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,218 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	windowsSectionIPConfig    = "IP Configuration (ipconfig /all)"
+	windowsSectionConnections = "Active Network Connections (netstat -ano)"
+	windowsSectionRoutes      = "IP Routing Table (route print)"
+	windowsSectionDNSCache    = "DNS Resolver Cache (ipconfig /displaydns)"
+	windowsSectionPing        = "Basic Connectivity Test (ping google.com)"
+)
+
+// collectWindowsNetworkReport gathers various network-related information.
+func collectWindowsNetworkReport(ctx context.Context) (*NetworkReport, error) {
+	report := &NetworkReport{}
+
+	// --- 1. IP Configuration (ipconfig /all) ---
+	if ipConfig, err := runner.Output(ctx, "ipconfig", "/all"); err != nil {
+		report.Errors.Add(windowsSectionIPConfig, err)
+	} else {
+		report.HostName, report.Adapters = parseIPConfig(ipConfig)
+	}
+
+	// --- 2. Active Network Connections (netstat -ano) ---
+	if netstat, err := runner.Output(ctx, "netstat", "-ano"); err != nil {
+		report.Errors.Add(windowsSectionConnections, err)
+	} else {
+		report.Connections = parseNetstat(netstat)
+	}
+
+	// --- 3. Route Print (route print) ---
+	if routePrint, err := runner.Output(ctx, "route", "print"); err != nil {
+		report.Errors.Add(windowsSectionRoutes, err)
+	} else {
+		report.Routes = parseRoutes(routePrint)
+	}
+
+	// --- 4. DNS Cache (ipconfig /displaydns) ---
+	if dnsCache, err := runner.Output(ctx, "ipconfig", "/displaydns"); err != nil {
+		report.Errors.Add(windowsSectionDNSCache, err)
+	} else {
+		report.DNSCache = parseDNSCache(dnsCache)
+	}
+
+	// --- 5. Basic Connectivity Test (ping google.com) ---
+	if pingTest, err := runner.Output(ctx, "ping", "-n", "4", pingTarget); err != nil { // 4 pings
+		report.Errors.Add(windowsSectionPing, err)
+	} else {
+		report.Ping = parsePing(pingTarget, pingTest)
+	}
+
+	return report, nil
+}
+
+// parseIPConfig parses `ipconfig /all` into the host name and one entry per adapter.
+func parseIPConfig(data []byte) (string, []NetworkAdapter) {
+	var hostName string
+	var adapters []NetworkAdapter
+	var current *NetworkAdapter
+	var lastName string
+
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// Unindented lines are headings: "Windows IP Configuration" or "<type> adapter <name>:"
+		if line[0] != ' ' && line[0] != '\t' {
+			current = nil
+			if i := strings.Index(line, " adapter "); i >= 0 {
+				adapters = append(adapters, NetworkAdapter{Name: strings.TrimSuffix(strings.TrimSpace(line[i+len(" adapter "):]), ":")})
+				current = &adapters[len(adapters)-1]
+			}
+			continue
+		}
+
+		name, value, ok := parseDottedLine(line)
+		if !ok {
+			// A further value for the previous field, e.g. a second DNS server
+			name, value = lastName, strings.TrimSpace(line)
+		}
+		lastName = name
+		value = strings.TrimSuffix(strings.TrimSuffix(value, "(Preferred)"), "(Deprecated)")
+
+		if current == nil {
+			if name == "Host Name" {
+				hostName = value
+			}
+			continue
+		}
+		if value == "" {
+			continue
+		}
+		switch {
+		case name == "Description":
+			current.Description = value
+		case name == "Physical Address":
+			current.PhysicalAddress = value
+		case name == "DHCP Enabled":
+			current.DHCPEnabled = value
+		case name == "Media State":
+			current.MediaState = value
+		case name == "DHCP Server":
+			current.DHCPServer = value
+		case name == "Subnet Mask":
+			current.SubnetMasks = append(current.SubnetMasks, value)
+		case name == "Default Gateway":
+			current.DefaultGateways = append(current.DefaultGateways, value)
+		case name == "DNS Servers":
+			current.DNSServers = append(current.DNSServers, value)
+		case strings.HasSuffix(name, "IPv4 Address"):
+			current.IPv4Addresses = append(current.IPv4Addresses, value)
+		case strings.HasSuffix(name, "IPv6 Address"):
+			current.IPv6Addresses = append(current.IPv6Addresses, value)
+		}
+	}
+	return hostName, adapters
+}
+
+// parseNetstat parses the connection table printed by `netstat -ano`.
+func parseNetstat(data []byte) []Connection {
+	var connections []Connection
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 5 && fields[0] == "TCP":
+			connections = append(connections, Connection{Protocol: fields[0], LocalAddress: fields[1], ForeignAddress: fields[2], State: fields[3], PID: parseInt(fields[4])})
+		case len(fields) == 4 && fields[0] == "UDP":
+			connections = append(connections, Connection{Protocol: fields[0], LocalAddress: fields[1], ForeignAddress: fields[2], PID: parseInt(fields[3])})
+		}
+	}
+	return connections
+}
+
+// parseRoutes parses the IPv4 "Active Routes" table printed by `route print`.
+func parseRoutes(data []byte) []Route {
+	var routes []Route
+	inTable := false
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "Network Destination"):
+			inTable = true
+		case strings.HasPrefix(trimmed, "="):
+			if inTable {
+				return routes
+			}
+		case inTable:
+			if fields := strings.Fields(trimmed); len(fields) == 5 {
+				routes = append(routes, Route{Destination: fields[0], Netmask: fields[1], Gateway: fields[2], Interface: fields[3], Metric: parseInt(fields[4])})
+			}
+		}
+	}
+	return routes
+}
+
+// parseDNSCache parses the records listed by `ipconfig /displaydns`.
+func parseDNSCache(data []byte) []DNSRecord {
+	var records []DNSRecord
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		name, value, ok := parseDottedLine(line)
+		if !ok {
+			continue
+		}
+		if name == "Record Name" {
+			records = append(records, DNSRecord{Name: value})
+			continue
+		}
+		if len(records) == 0 {
+			continue
+		}
+		record := &records[len(records)-1]
+		switch {
+		case name == "Record Type":
+			record.Type = value
+		case name == "Time To Live":
+			record.TTL = parseInt(value)
+		case name == "Section":
+			record.Section = value
+		case strings.HasSuffix(name, "Record"): // "A (Host) Record", "CNAME Record", ...
+			record.Data = value
+		}
+	}
+	return records
+}
+
+var (
+	pingPacketsPattern = regexp.MustCompile(`Sent = (\d+), Received = (\d+), Lost = (\d+)`)
+	pingTimesPattern   = regexp.MustCompile(`Minimum = (\d+)ms, Maximum = (\d+)ms, Average = (\d+)ms`)
+)
+
+// parsePing extracts the packet and round-trip statistics printed at the end of `ping`.
+func parsePing(target string, data []byte) *PingResult {
+	result := &PingResult{Target: target}
+	text := normalizeOutput(data)
+	if m := pingPacketsPattern.FindStringSubmatch(text); m != nil {
+		result.Sent, result.Received, result.Lost = parseInt(m[1]), parseInt(m[2]), parseInt(m[3])
+	}
+	if m := pingTimesPattern.FindStringSubmatch(text); m != nil {
+		result.MinimumMS, result.MaximumMS, result.AverageMS = parseInt(m[1]), parseInt(m[2]), parseInt(m[3])
+	}
+	return result
+}
+
+// flushWindowsDNSCache flushes the DNS resolver cache.
+func flushWindowsDNSCache(ctx context.Context) error {
+	output, err := runner.CombinedOutput(ctx, "ipconfig", "/flushdns") // Capture output for potential error messages
+	if err != nil {
+		return fmt.Errorf("failed to flush DNS cache: %v - %s", err, string(output))
+	}
+	// Optionally, you can return a success message from here or let the calling function handle dialogs
+	return nil
+}
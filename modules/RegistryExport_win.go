@@ -0,0 +1,109 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var registryCollector = &reportCollector{
+	collectorInfo: collectorInfo{
+		id:          "registry",
+		name:        "Export Common Registry Keys",
+		outputFiles: []string{"Registry_Export_Summary.txt", "RegistryExports"},
+		timeout:     10 * time.Minute,
+	},
+	collect: func(ctx context.Context, outputDir string) (Report, error) {
+		report, err := CollectRegistryExport(ctx, outputDir)
+		if err != nil {
+			return nil, err
+		}
+		return report, nil
+	},
+}
+
+// RegistryExportResult records the outcome of exporting a single registry key.
+type RegistryExportResult struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	File    string `json:"file,omitempty"` // .reg file name inside the export directory, set on success
+	Error   string `json:"error,omitempty"`
+	Details string `json:"details,omitempty"` // Output of `reg export` when it failed
+}
+
+// RegistryExportReport is the structured result of the registry export.
+type RegistryExportReport struct {
+	Directory string                 `json:"directory"`
+	Profiles  []string               `json:"profiles,omitempty"`
+	UserKeys  int                    `json:"user_keys,omitempty"` // Keys added in the settings on top of the profiles
+	Exports   []RegistryExportResult `json:"exports"`
+}
+
+// CollectRegistryExport exports the keys of the selected registry profiles (see
+// SetRegistryExport) to .reg files in a RegistryExports subfolder of outputDir and reports the
+// outcome of each export.
+func CollectRegistryExport(ctx context.Context, outputDir string) (*RegistryExportReport, error) {
+	keysToExport, err := RegistryKeysToExport()
+	if err != nil {
+		return nil, err
+	}
+
+	// Define the subfolder for registry exports
+	registryExportSubDir := filepath.Join(outputDir, "RegistryExports")
+
+	// Create the subfolder if it doesn't exist
+	if err := os.MkdirAll(registryExportSubDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create registry export subdirectory '%s': %w", registryExportSubDir, err)
+	}
+
+	profiles, userKeys := RegistryExportSelection()
+	report := &RegistryExportReport{Directory: registryExportSubDir, Profiles: profiles, UserKeys: len(userKeys)}
+	for _, key := range keysToExport {
+		result := RegistryExportResult{Name: key.Name, Path: key.Path}
+		exportFilePath := filepath.Join(registryExportSubDir, fmt.Sprintf("RegExport_%s.reg", key.Name))
+		// Use /y to overwrite existing files without prompt
+		// Capture combined output (stdout and stderr) for debugging purposes
+		cmdOutput, err := runner.CombinedOutput(ctx, "reg", "export", key.Path, exportFilePath, "/y")
+		if err != nil {
+			result.Error = err.Error()
+			result.Details = string(bytes.TrimSpace(cmdOutput))
+		} else {
+			result.File = filepath.Base(exportFilePath)
+		}
+		report.Exports = append(report.Exports, result)
+	}
+
+	return report, nil
+}
+
+// WriteText renders the report in GoDiag's plain-text layout.
+func (r *RegistryExportReport) WriteText(output *bytes.Buffer) {
+	output.WriteString("--- Registry Export Report ---\n\n")
+	output.WriteString(fmt.Sprintf("Registry keys have been attempted for export to:\n%s\n\n", r.Directory))
+	profiles := strings.Join(r.Profiles, ", ")
+	if profiles == "" {
+		profiles = "(none)"
+	}
+	if r.UserKeys > 0 {
+		profiles += fmt.Sprintf(" and %d keys from the settings", r.UserKeys)
+	}
+	output.WriteString(fmt.Sprintf("Profiles: %s\n\n", profiles))
+
+	for _, export := range r.Exports {
+		if export.Error != "" {
+			output.WriteString(fmt.Sprintf("ERROR: Failed to export '%s' (%s).\n", export.Name, export.Path))
+			output.WriteString(fmt.Sprintf("  Details: %s\n  Error: %s\n\n", export.Details, export.Error))
+		} else {
+			output.WriteString(fmt.Sprintf("SUCCESS: Exported '%s' to %s\n", export.Name, export.File)) // Show just filename in summary
+		}
+	}
+}
+
+// GenerateRegistryExport exports the selected registry keys to .reg files in a dedicated subfolder.
+func GenerateRegistryExport(ctx context.Context, outputDir string) error {
+	return registryCollector.Run(ctx, outputDir)
+}
@@ -2,36 +2,68 @@ package modules
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
 )
 
-// GenerateRunningProcessesReport collects information about all currently running processes
-// and saves it to a text file.
-func GenerateRunningProcessesReport(outputDir string) error {
-	var output bytes.Buffer
-	outputPath := filepath.Join(outputDir, "Running_Processes_Report.txt")
+var processCollector = &reportCollector{
+	collectorInfo: collectorInfo{
+		id:          "processes",
+		name:        "Generate Running Processes Report",
+		outputFiles: []string{"Running_Processes_Report.txt"},
+	},
+	collect: collectFunc(CollectRunningProcessesReport),
+}
+
+func init() {
+	Register(processCollector)
+}
+
+// ProcessEntry is a running process. On Windows the running processes report fills it from
+// `tasklist /v` and the software report fills the command line and working set from WMI instead;
+// on Linux both read /proc.
+type ProcessEntry struct {
+	ImageName       string `json:"image_name" wmi:"Name"`
+	PID             int64  `json:"pid" wmi:"ProcessId"`
+	SessionName     string `json:"session_name,omitempty"`
+	SessionNumber   int64  `json:"session_number,omitempty"`
+	MemoryKB        int64  `json:"memory_kb,omitempty"`
+	Status          string `json:"status,omitempty"`
+	UserName        string `json:"user_name,omitempty"`
+	CPUTime         string `json:"cpu_time,omitempty"`
+	WindowTitle     string `json:"window_title,omitempty"`
+	CommandLine     string `json:"command_line,omitempty" wmi:"CommandLine"`
+	WorkingSetBytes int64  `json:"working_set_bytes,omitempty" wmi:"WorkingSetSize"`
+}
 
+// ProcessReport is the structured result of the running processes report.
+type ProcessReport struct {
+	Processes []ProcessEntry `json:"processes"`
+}
+
+// WriteText renders the report in GoDiag's plain-text layout.
+func (r *ProcessReport) WriteText(output *bytes.Buffer) {
 	output.WriteString("--- Running Processes Report ---\n\n")
 	output.WriteString("This report lists all processes currently running on the system.\n\n")
+	output.WriteString(fmt.Sprintf("%d processes running.\n\n", len(r.Processes)))
 
-	// Use the 'tasklist' command to get a list of running processes.
-	// '/v' for verbose output (e.g., session name, PID, memory usage, window title),
-	// '/fo list' for a detailed, list-formatted output.
-	cmd := exec.Command("tasklist", "/v", "/fo", "list")
-	cmdOutput, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("error running tasklist command: %v\nOutput: %s", err, string(cmdOutput))
+	for _, process := range r.Processes {
+		writeFields(output,
+			field{"Image Name", process.ImageName},
+			field{"PID", fmt.Sprint(process.PID)},
+			field{"Session Name", process.SessionName},
+			field{"Session#", fmt.Sprint(process.SessionNumber)},
+			field{"Mem Usage", formatUnit(process.MemoryKB, "K")},
+			field{"Status", process.Status},
+			field{"User Name", process.UserName},
+			field{"CPU Time", process.CPUTime},
+			field{"Window Title", process.WindowTitle},
+		)
 	}
+}
 
-	output.Write(cmdOutput)
-	output.WriteString("\n")
-
-	// --- Footer ---
-	output.WriteString("\n\nReport generated by GoDiag. Learn more at https://github.com/LewdLillyVT/godiag")
-
-	// Save the collected information to the designated output file
-	return os.WriteFile(outputPath, output.Bytes(), 0644)
+// GenerateRunningProcessesReport collects information about all currently running processes
+// and saves it to a text file.
+func GenerateRunningProcessesReport(ctx context.Context, outputDir string) error {
+	return processCollector.Run(ctx, outputDir)
 }
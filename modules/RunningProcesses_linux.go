@@ -0,0 +1,117 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// clockTicks is the USER_HZ unit /proc/<pid>/stat counts CPU time in. It is 100 on every
+// architecture Linux supports.
+const clockTicks = 100
+
+// processStates names the state letters in /proc/<pid>/stat.
+var processStates = map[string]string{
+	"R": "Running",
+	"S": "Sleeping",
+	"D": "Disk Sleep",
+	"T": "Stopped",
+	"t": "Tracing Stop",
+	"Z": "Zombie",
+	"X": "Dead",
+	"I": "Idle",
+}
+
+// CollectRunningProcessesReport collects information about all currently running processes.
+func CollectRunningProcessesReport(ctx context.Context) (*ProcessReport, error) {
+	processes, err := readProcesses()
+	if err != nil {
+		return nil, fmt.Errorf("error reading /proc: %w", err)
+	}
+	return &ProcessReport{Processes: processes}, nil
+}
+
+// readProcesses lists the processes in /proc, ordered by PID. Processes that exit while being
+// read are left out.
+func readProcesses() ([]ProcessEntry, error) {
+	dirs, err := filepath.Glob("/proc/[0-9]*")
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no processes found; is /proc mounted?")
+	}
+
+	var processes []ProcessEntry
+	for _, dir := range dirs {
+		if process, ok := readProcess(dir); ok {
+			processes = append(processes, process)
+		}
+	}
+	sort.Slice(processes, func(i, j int) bool { return processes[i].PID < processes[j].PID })
+	return processes, nil
+}
+
+// readProcess reads a single /proc/<pid> directory.
+func readProcess(dir string) (ProcessEntry, bool) {
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return ProcessEntry{}, false
+	}
+
+	// "pid (comm) state ppid pgrp session tty_nr tpgid flags minflt cminflt majflt cmajflt utime stime ..."
+	// The command name may itself contain spaces and parentheses, so split after the last ')'.
+	text := string(stat)
+	open, end := strings.IndexByte(text, '('), strings.LastIndexByte(text, ')')
+	if open < 0 || end < open {
+		return ProcessEntry{}, false
+	}
+	fields := strings.Fields(text[end+1:])
+	if len(fields) < 13 {
+		return ProcessEntry{}, false
+	}
+
+	process := ProcessEntry{
+		ImageName: text[open+1 : end],
+		PID:       parseInt(text[:open]),
+		Status:    processStates[fields[0]],
+		CPUTime:   formatCPUTime((parseInt(fields[11]) + parseInt(fields[12])) / clockTicks),
+	}
+
+	status, _ := os.ReadFile(filepath.Join(dir, "status"))
+	for _, line := range strings.Split(string(status), "\n") {
+		name, value, _ := strings.Cut(line, ":")
+		switch name {
+		case "Uid":
+			if uid := strings.Fields(value); len(uid) > 0 {
+				process.UserName = lookupUserName(uid[0])
+			}
+		case "VmRSS":
+			process.MemoryKB = parseInt(strings.TrimSuffix(strings.TrimSpace(value), " kB"))
+			process.WorkingSetBytes = process.MemoryKB * 1024
+		}
+	}
+
+	// cmdline separates arguments with NUL bytes and is empty for kernel threads
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+		process.CommandLine = strings.Join(args, " ")
+		// comm is cut to 15 characters; the executable name is not
+		if base := filepath.Base(args[0]); len(process.ImageName) == 15 && strings.HasPrefix(base, process.ImageName) {
+			process.ImageName = base
+		}
+	}
+	// The login session, the closest match to a Windows session number; 2^32-1 for services
+	if session := readSysInt(dir, "sessionid"); session < 1<<32-1 {
+		process.SessionNumber = session
+	}
+	return process, true
+}
+
+// formatCPUTime formats CPU seconds as H:MM:SS, as tasklist does.
+func formatCPUTime(seconds int64) string {
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
@@ -0,0 +1,40 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// collectWindowsRunningProcessesReport collects information about all currently running processes.
+func collectWindowsRunningProcessesReport(ctx context.Context) (*ProcessReport, error) {
+	// Use the 'tasklist' command to get a list of running processes.
+	// '/v' for verbose output (e.g., session name, PID, memory usage, window title),
+	// '/fo csv' so the columns (Image Name, PID, Session Name, Session#, Mem Usage, Status,
+	// User Name, CPU Time, Window Title) can be read by position whatever the system language.
+	cmdOutput, err := runner.CombinedOutput(ctx, "tasklist", "/v", "/fo", "csv")
+	if err != nil {
+		return nil, fmt.Errorf("error running tasklist command: %v\nOutput: %s", err, string(cmdOutput))
+	}
+
+	rows, err := parseCSVRecords(cmdOutput)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing tasklist output: %w", err)
+	}
+
+	report := &ProcessReport{}
+	for _, row := range rows {
+		report.Processes = append(report.Processes, ProcessEntry{
+			ImageName:     column(row, 0),
+			PID:           parseInt(column(row, 1)),
+			SessionName:   column(row, 2),
+			SessionNumber: parseInt(column(row, 3)),
+			MemoryKB:      parseInt(strings.TrimSuffix(column(row, 4), " K")),
+			Status:        column(row, 5),
+			UserName:      column(row, 6),
+			CPUTime:       column(row, 7),
+			WindowTitle:   column(row, 8),
+		})
+	}
+	return report, nil
+}
@@ -2,63 +2,112 @@ package modules
 
 import (
 	"bytes"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"context"
+	"fmt"
+	"time"
 )
 
-// GenerateSoftwareReport gathers various software and application-related information and saves it to a text file.
-func GenerateSoftwareReport(outputDir string) error {
-	var output bytes.Buffer
-	outputPath := filepath.Join(outputDir, "Software_Diagnostics_Report.txt")
+var softwareCollector = &reportCollector{
+	collectorInfo: collectorInfo{
+		id:          "software",
+		name:        "Generate Software & Application Report",
+		outputFiles: []string{"Software_Diagnostics_Report.txt"},
+		timeout:     5 * time.Minute,
+	},
+	collect: collectFunc(CollectSoftwareReport),
+}
+
+func init() {
+	Register(softwareCollector)
+}
 
-	// --- 1. Installed Programs (wmic product get Name,Version,InstallDate) ---
-	output.WriteString("--- Installed Programs ---\n\n")
-	// Using powershell to get installed programs from Add/Remove Programs list (more comprehensive than wmic product)
-	// Get-ItemProperty HKLM:\Software\Microsoft\Windows\CurrentVersion\Uninstall\* | Select-Object DisplayName, DisplayVersion, InstallDate, Publisher
-	installedProgramsCmd := `Get-ItemProperty HKLM:\Software\Microsoft\Windows\CurrentVersion\Uninstall\*, HKLM:\Software\Wow6432Node\Microsoft\Windows\CurrentVersion\Uninstall\* | Select-Object DisplayName, DisplayVersion, InstallDate, Publisher | Format-Table -AutoSize`
-	installedPrograms, err := exec.Command("powershell", "-Command", installedProgramsCmd).Output()
-	if err != nil {
-		output.WriteString("Error gathering installed programs: " + err.Error() + "\n\n")
-	} else {
-		output.Write(installedPrograms)
-	}
-	output.WriteString("\n\n")
+// InstalledProgram is an entry in Add/Remove Programs.
+type InstalledProgram struct {
+	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
+	InstallDate string `json:"install_date,omitempty"` // YYYY-MM-DD when the installer recorded one
+	Publisher   string `json:"publisher,omitempty"`
+}
 
-	// --- 2. Running Processes (wmic process get Name,ProcessId,WorkingSetSize,CommandLine) ---
-	output.WriteString("--- Running Processes ---\n\n")
-	// Note: WorkingSetSize is in bytes, you might want to convert to MB/GB in a more advanced UI
-	runningProcesses, err := exec.Command("wmic", "process", "get", "Name,ProcessId,WorkingSetSize,CommandLine", "/format:list").Output()
-	if err != nil {
-		output.WriteString("Error gathering running processes: " + err.Error() + "\n\n")
-	} else {
-		output.Write(runningProcesses)
-	}
-	output.WriteString("\n\n")
+// ServiceEntry is a Windows service or, on Linux, a systemd service unit.
+type ServiceEntry struct {
+	Name        string `json:"name" wmi:"Name"`
+	DisplayName string `json:"display_name,omitempty" wmi:"DisplayName"`
+	State       string `json:"state,omitempty" wmi:"State"`
+	StartMode   string `json:"start_mode,omitempty" wmi:"StartMode"`
+	PathName    string `json:"path_name,omitempty" wmi:"PathName"`
+}
 
-	// --- 3. Windows Services (wmic service get Name,DisplayName,State,StartMode,PathName) ---
-	output.WriteString("--- Windows Services ---\n\n")
-	windowsServices, err := exec.Command("wmic", "service", "get", "Name,DisplayName,State,StartMode,PathName", "/format:list").Output()
-	if err != nil {
-		output.WriteString("Error gathering Windows services: " + err.Error() + "\n\n")
-	} else {
-		output.Write(windowsServices)
-	}
-	output.WriteString("\n\n")
+// SoftwareReport is the structured result of the software and application report.
+type SoftwareReport struct {
+	Programs     []InstalledProgram `json:"programs"`
+	Processes    []ProcessEntry     `json:"processes"`
+	Services     []ServiceEntry     `json:"services"`
+	StartupItems []StartupItem      `json:"startup_items"`
+	Errors       SectionErrors      `json:"errors,omitempty"`
+}
+
+const (
+	sectionPrograms        = "Installed Programs"
+	sectionSoftwareProcess = "Running Processes"
+	sectionSoftwareStartup = "Startup Programs"
+)
 
-	// --- 4. Startup Programs (wmic startup get Caption,Command,Location,User) ---
-	output.WriteString("--- Startup Programs ---\n\n")
-	startupPrograms, err := exec.Command("wmic", "startup", "get", "Caption,Command,Location,User", "/format:list").Output()
-	if err != nil {
-		output.WriteString("Error gathering startup programs: " + err.Error() + "\n\n")
-	} else {
-		output.Write(startupPrograms)
-	}
-	output.WriteString("\n\n")
+// WriteText renders the report in GoDiag's plain-text layout.
+func (r *SoftwareReport) WriteText(output *bytes.Buffer) {
+	writeSection(output, sectionPrograms, r.Errors, func() {
+		if len(r.Programs) == 0 {
+			writeNone(output, "installed programs")
+		}
+		for _, program := range r.Programs {
+			writeFields(output,
+				field{"Name", program.Name},
+				field{"Version", program.Version},
+				field{"Install Date", program.InstallDate},
+				field{"Publisher", program.Publisher},
+			)
+		}
+	})
 
-	// --- Footer ---
-	output.WriteString("\n\nReport generated by GoDiag. Learn more at https://github.com/LewdLillyVT/godiag")
+	writeSection(output, sectionSoftwareProcess, r.Errors, func() {
+		for _, process := range r.Processes {
+			writeFields(output,
+				field{"Name", process.ImageName},
+				field{"Process ID", fmt.Sprint(process.PID)},
+				field{"Working Set", formatBytes(process.WorkingSetBytes)},
+				field{"Command Line", process.CommandLine},
+			)
+		}
+	})
 
-	// Save to file
-	return os.WriteFile(outputPath, output.Bytes(), 0644)
-}
\ No newline at end of file
+	writeSection(output, sectionServices, r.Errors, func() {
+		for _, service := range r.Services {
+			writeFields(output,
+				field{"Name", service.Name},
+				field{"Display Name", service.DisplayName},
+				field{"State", service.State},
+				field{"Start Mode", service.StartMode},
+				field{"Path", service.PathName},
+			)
+		}
+	})
+
+	writeSection(output, sectionSoftwareStartup, r.Errors, func() {
+		if len(r.StartupItems) == 0 {
+			writeNone(output, "startup programs")
+		}
+		for _, item := range r.StartupItems {
+			writeFields(output,
+				field{"Caption", item.Name},
+				field{"Command", item.Command},
+				field{"Location", item.Location},
+				field{"User", item.User},
+			)
+		}
+	})
+}
+
+// GenerateSoftwareReport gathers various software and application-related information and saves it to a text file.
+func GenerateSoftwareReport(ctx context.Context, outputDir string) error {
+	return softwareCollector.Run(ctx, outputDir)
+}
@@ -0,0 +1,130 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var sectionServices = "Services (systemd)" // A variable for the same reason as sectionIPConfig
+
+// CollectSoftwareReport gathers various software and application-related information.
+func CollectSoftwareReport(ctx context.Context) (*SoftwareReport, error) {
+	report := &SoftwareReport{}
+
+	// --- 1. Installed Packages ---
+	if programs, err := collectPackages(ctx); err != nil {
+		report.Errors.Add(sectionPrograms, err)
+	} else {
+		report.Programs = programs
+	}
+
+	// --- 2. Running Processes ---
+	if processes, err := readProcesses(); err != nil {
+		report.Errors.Add(sectionSoftwareProcess, err)
+	} else {
+		report.Processes = processes
+	}
+
+	// --- 3. systemd Services ---
+	if services, err := collectServices(ctx); err != nil {
+		report.Errors.Add(sectionServices, err)
+	} else {
+		report.Services = services
+	}
+
+	// --- 4. Startup Programs ---
+	if startup, err := CollectStartupProgramsReport(ctx); err != nil {
+		report.Errors.Add(sectionSoftwareStartup, err)
+	} else {
+		report.StartupItems = startup.Items
+	}
+
+	return report, nil
+}
+
+// collectPackages lists the packages known to dpkg or, on RPM-based distributions, rpm.
+func collectPackages(ctx context.Context) ([]InstalledProgram, error) {
+	data, err := runner.Output(ctx, "dpkg-query", "-W", "-f", `${db:Status-Abbrev}\t${Package}\t${Version}\t${Maintainer}\n`)
+	if err == nil {
+		var programs []InstalledProgram
+		for _, line := range strings.Split(normalizeOutput(data), "\n") {
+			fields := strings.Split(line, "\t")
+			// Removed packages whose configuration files remain are listed as "rc"
+			if len(fields) < 4 || !strings.HasPrefix(fields[0], "ii") {
+				continue
+			}
+			programs = append(programs, InstalledProgram{Name: fields[1], Version: fields[2], Publisher: fields[3]})
+		}
+		return programs, nil
+	}
+	if !errors.Is(err, exec.ErrNotFound) && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	data, err = runner.Output(ctx, "rpm", "-qa", "--qf", `%{NAME}\t%{VERSION}-%{RELEASE}\t%{INSTALLTIME}\t%{VENDOR}\n`)
+	if err != nil {
+		return nil, err
+	}
+	var programs []InstalledProgram
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 4 {
+			continue
+		}
+		program := InstalledProgram{Name: fields[0], Version: fields[1], Publisher: fields[3]}
+		if installed := parseInt(fields[2]); installed > 0 {
+			program.InstallDate = time.Unix(installed, 0).Format("2006-01-02")
+		}
+		if program.Publisher == "(none)" {
+			program.Publisher = ""
+		}
+		programs = append(programs, program)
+	}
+	return programs, nil
+}
+
+// collectServices lists systemd service units with their state and whether they start at boot.
+func collectServices(ctx context.Context) ([]ServiceEntry, error) {
+	// "UNIT LOAD ACTIVE SUB DESCRIPTION..." for every loaded service
+	units, err := runner.Output(ctx, "systemctl", "list-units", "--type=service", "--all", "--no-legend", "--plain", "--no-pager")
+	if err != nil {
+		return nil, err
+	}
+
+	// "UNIT-FILE STATE [PRESET]", where STATE is enabled, disabled, static, masked, ...
+	startModes := make(map[string]string)
+	if files, err := runner.Output(ctx, "systemctl", "list-unit-files", "--type=service", "--no-legend", "--no-pager"); err == nil {
+		for _, line := range strings.Split(normalizeOutput(files), "\n") {
+			if fields := strings.Fields(line); len(fields) >= 2 {
+				startModes[fields[0]] = fields[1]
+			}
+		}
+	}
+
+	var services []ServiceEntry
+	for _, line := range strings.Split(normalizeOutput(units), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		name := fields[0]
+		startMode := startModes[name]
+		if startMode == "" {
+			// Instances such as getty@tty1.service are enabled through their template
+			if prefix, _, found := strings.Cut(name, "@"); found {
+				startMode = startModes[prefix+"@.service"]
+			}
+		}
+		services = append(services, ServiceEntry{
+			Name:        name,
+			DisplayName: strings.Join(fields[4:], " "),
+			State:       fields[3],
+			StartMode:   startMode,
+		})
+	}
+	return services, nil
+}
@@ -0,0 +1,87 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// missingCommandRunner answers from a replay directory as if the named command were not installed.
+type missingCommandRunner struct {
+	CommandRunner
+	missing string
+}
+
+func (r missingCommandRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if name == r.missing {
+		return nil, &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	return r.CommandRunner.Output(ctx, name, args...)
+}
+
+func TestCollectPackages(t *testing.T) {
+	defer SetCommandRunner(nil)
+	replay := NewReplayRunner(filepath.Join("testdata", "linux", "replay"))
+
+	// Only packages dpkg lists as installed ("ii") are reported
+	SetCommandRunner(replay)
+	got, err := collectPackages(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []InstalledProgram{
+		{Name: "bash", Version: "5.1-6ubuntu1.1", Publisher: "Ubuntu Developers <ubuntu-devel-discuss@lists.ubuntu.com>"},
+		{Name: "code", Version: "1.87.0-1709078641", Publisher: "Microsoft Corporation <vscode-linux@microsoft.com>"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectPackages() with dpkg = %+v\nwant %+v", got, want)
+	}
+
+	// Install dates are shown in the local time zone
+	defer func(local *time.Location) { time.Local = local }(time.Local)
+	time.Local = time.UTC
+
+	SetCommandRunner(missingCommandRunner{replay, "dpkg-query"})
+	got, err = collectPackages(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []InstalledProgram{
+		{Name: "bash", Version: "5.2.26-3.fc40", InstallDate: "2024-04-10", Publisher: "Fedora Project"},
+		{Name: "gpg-pubkey", Version: "18b8e74c-62f2920f", InstallDate: "2024-04-10"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectPackages() with rpm = %+v\nwant %+v", got, want)
+	}
+
+	// rpm is only tried when dpkg is missing, not when it fails
+	SetCommandRunner(stubRunner{err: errors.New("exit status 2")})
+	if _, err := collectPackages(context.Background()); err == nil || err.Error() != "exit status 2" {
+		t.Errorf("collectPackages() = %v, want dpkg's error", err)
+	}
+}
+
+func TestCollectServices(t *testing.T) {
+	defer SetCommandRunner(nil)
+	SetCommandRunner(NewReplayRunner(filepath.Join("testdata", "linux", "replay")))
+
+	got, err := collectServices(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ServiceEntry{
+		{Name: "cron.service", DisplayName: "Regular background program processing daemon", State: "running", StartMode: "enabled"},
+		// Enabled through its template, getty@.service
+		{Name: "getty@tty1.service", DisplayName: "Getty on tty1", State: "running", StartMode: "enabled"},
+		// A unit that is referenced but has no unit file
+		{Name: "nfs-server.service", DisplayName: "nfs-server.service", State: "dead"},
+		{Name: "ssh.service", DisplayName: "OpenBSD Secure Shell server", State: "failed", StartMode: "enabled"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectServices() = %+v\nwant %+v", got, want)
+	}
+}
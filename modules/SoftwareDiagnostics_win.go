@@ -0,0 +1,74 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const windowsSectionServices = "Windows Services"
+
+// collectWindowsSoftwareReport gathers various software and application-related information.
+func collectWindowsSoftwareReport(ctx context.Context) (*SoftwareReport, error) {
+	report := &SoftwareReport{}
+
+	// --- 1. Installed Programs ---
+	// Using powershell to get installed programs from Add/Remove Programs list (more comprehensive than wmic product)
+	installedProgramsCmd := `Get-ItemProperty HKLM:\Software\Microsoft\Windows\CurrentVersion\Uninstall\*, HKLM:\Software\Wow6432Node\Microsoft\Windows\CurrentVersion\Uninstall\* | Where-Object DisplayName | Select-Object DisplayName, DisplayVersion, InstallDate, Publisher | ConvertTo-Json -Compress`
+	installedPrograms, err := runner.Output(ctx, "powershell", "-Command", installedProgramsCmd)
+	if err == nil {
+		report.Programs, err = parseInstalledPrograms(installedPrograms)
+	}
+	if err != nil {
+		report.Errors.Add(sectionPrograms, err)
+	}
+
+	// --- 2. Running Processes ---
+	// Note: WorkingSetSize is in bytes
+	queryWMI(ctx, &report.Errors, sectionSoftwareProcess, &report.Processes, "Win32_Process", "")
+
+	// --- 3. Windows Services ---
+	queryWMI(ctx, &report.Errors, windowsSectionServices, &report.Services, "Win32_Service", "")
+
+	// --- 4. Startup Programs ---
+	queryWMI(ctx, &report.Errors, sectionSoftwareStartup, &report.StartupItems, "Win32_StartupCommand", "")
+
+	return report, nil
+}
+
+// parseInstalledPrograms decodes the JSON PowerShell prints for the uninstall keys. ConvertTo-Json
+// emits a bare object rather than an array when there is only one entry.
+func parseInstalledPrograms(data []byte) ([]InstalledProgram, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if data[0] == '{' {
+		data = append(append([]byte("["), data...), ']')
+	}
+
+	var entries []map[string]interface{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse installed programs: %w", err)
+	}
+
+	programs := make([]InstalledProgram, 0, len(entries))
+	for _, entry := range entries {
+		programs = append(programs, InstalledProgram{
+			Name:        jsonString(entry["DisplayName"]),
+			Version:     jsonString(entry["DisplayVersion"]),
+			InstallDate: formatWMIDate(jsonString(entry["InstallDate"])),
+			Publisher:   jsonString(entry["Publisher"]),
+		})
+	}
+	return programs, nil
+}
+
+// jsonString formats a decoded JSON value as a string, treating null as empty.
+func jsonString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
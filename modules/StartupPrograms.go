@@ -2,97 +2,119 @@ package modules
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"strings"
 )
 
-// GenerateStartupProgramsReport collects information about programs configured to run automatically at system startup
-// from common registry keys and startup folders, and saves it to a text file.
-func GenerateStartupProgramsReport(outputDir string) error {
-	var output bytes.Buffer
-	// This will create a new file specifically for startup programs report.
-	outputPath := filepath.Join(outputDir, "Startup_Programs_Report.txt")
+var startupCollector = &reportCollector{
+	collectorInfo: collectorInfo{
+		id:          "startup",
+		name:        "Generate Startup Programs Report",
+		outputFiles: []string{"Startup_Programs_Report.txt"},
+	},
+	collect: collectFunc(CollectStartupProgramsReport),
+}
 
-	output.WriteString("--- Startup Programs Report ---\n\n")
-	output.WriteString("This report provides insights into programs configured to run automatically at system startup.\n\n")
+func init() {
+	Register(startupCollector)
+}
+
+// StartupItem is a program configured to run automatically at system startup.
+type StartupItem struct {
+	Name     string `json:"name" wmi:"Caption"`
+	Command  string `json:"command,omitempty" wmi:"Command"` // Empty for startup folder entries
+	Location string `json:"location" wmi:"Location"`         // Registry key, folder or unit directory the entry was found in
+	User     string `json:"user,omitempty" wmi:"User"`       // Only reported by WMI
+	Modified string `json:"modified,omitempty"`              // Startup folder entries only
+}
 
-	// --- 1. Startup Programs from Registry (Run & RunOnce Keys) ---
-	output.WriteString("--- Startup Programs from Registry (Run & RunOnce Keys) ---\n\n")
+// StartupLocation is a registry key or folder that was checked for startup entries.
+type StartupLocation struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`            // "registry" or "folder" on Windows, "systemd" or "autostart" on Linux
+	Exists bool   `json:"exists"`          // False when the key or folder does not exist
+	Error  string `json:"error,omitempty"` // Set when the location could not be read
+}
 
-	registryKeys := []string{
-		"HKLM\\SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\Run",
-		"HKLM\\SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\RunOnce",
-		"HKCU\\SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\Run",
-		"HKCU\\SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\RunOnce",
-		// Also check WoW6432Node for 32-bit applications on 64-bit systems
-		"HKLM\\SOFTWARE\\WOW6432Node\\Microsoft\\Windows\\CurrentVersion\\Run",
-		"HKLM\\SOFTWARE\\WOW6432Node\\Microsoft\\Windows\\CurrentVersion\\RunOnce",
-	}
+// StartupReport is the structured result of the startup programs report.
+type StartupReport struct {
+	Locations []StartupLocation `json:"locations"`
+	Items     []StartupItem     `json:"items"`
+}
 
-	for _, key := range registryKeys {
-		output.WriteString(fmt.Sprintf("Registry Key: %s\n", key))
-		// Using 'reg query' command to list entries under the key
-		cmd := exec.Command("reg", "query", key)
-		cmdOutput, err := cmd.CombinedOutput()
-		if err != nil {
-			// Check for specific error message if the key does not exist
-			if strings.Contains(strings.ToLower(string(cmdOutput)), "error: the system was unable to find the specified registry key or value") {
-				output.WriteString("  No entries found or key does not exist.\n\n")
-			} else {
-				// Log other errors
-				output.WriteString(fmt.Sprintf("  Error querying registry key %s: %v\n  Output: %s\n\n", key, err, string(cmdOutput)))
-			}
-		} else {
-			// Write the output of the reg query command
-			output.Write(cmdOutput)
-			output.WriteString("\n") // Add a newline for better separation between keys
-		}
-	}
-	output.WriteString("\n")
+// startupSections lists each kind of startup location in the order the text report shows them.
+var startupSections = []struct {
+	kind, title, label, missing string
+}{
+	{"registry", "Startup Programs from Registry (Run & RunOnce Keys)", "Registry Key", "  No entries found or key does not exist.\n"},
+	{"folder", "Startup Programs from Startup Folders", "Folder", "  Folder does not exist or is empty.\n"},
+	{"systemd", "Services Enabled at Boot (systemd)", "Unit Directory", "  Directory does not exist.\n"},
+	{"autostart", "Desktop Autostart Entries (XDG)", "Folder", "  Folder does not exist or is empty.\n"},
+}
 
-	// --- 2. Startup Programs from Startup Folders ---
-	output.WriteString("--- Startup Programs from Startup Folders ---\n\n")
+// WriteText renders the report in GoDiag's plain-text layout.
+func (r *StartupReport) WriteText(output *bytes.Buffer) {
+	output.WriteString("--- Startup Programs Report ---\n\n")
+	output.WriteString("This report provides insights into programs configured to run automatically at system startup.\n\n")
 
-	// Get APPDATA and PROGRAMDATA environment variables to find the correct paths
-	appData := os.Getenv("APPDATA")
-	programData := os.Getenv("PROGRAMDATA")
+	for _, section := range startupSections {
+		if !r.hasLocations(section.kind) {
+			continue
+		}
+		output.WriteString(fmt.Sprintf("--- %s ---\n\n", section.title))
+		r.writeLocations(output, section.kind, section.label, section.missing)
+		output.WriteString("\n")
+	}
+}
 
-	startupFolders := []string{
-		filepath.Join(appData, "Microsoft\\Windows\\Start Menu\\Programs\\Startup"),     // Current user's startup folder
-		filepath.Join(programData, "Microsoft\\Windows\\Start Menu\\Programs\\Startup"), // All users' startup folder
+// hasLocations reports whether any location of the given kind was checked.
+func (r *StartupReport) hasLocations(kind string) bool {
+	for _, location := range r.Locations {
+		if location.Kind == kind {
+			return true
+		}
 	}
+	return false
+}
 
-	for _, folder := range startupFolders {
-		output.WriteString(fmt.Sprintf("Folder: %s\n", folder))
-		files, err := ioutil.ReadDir(folder) // Read contents of the directory
-		if err != nil {
-			if os.IsNotExist(err) {
-				output.WriteString("  Folder does not exist or is empty.\n\n")
-			} else {
-				output.WriteString(fmt.Sprintf("  Error reading folder %s: %v\n\n", folder, err))
-			}
-			continue // Move to the next folder
+func (r *StartupReport) writeLocations(output *bytes.Buffer, kind, label, missing string) {
+	for _, location := range r.Locations {
+		if location.Kind != kind {
+			continue
 		}
+		output.WriteString(fmt.Sprintf("%s: %s\n", label, location.Path))
 
-		if len(files) == 0 {
-			output.WriteString("  No items found in this folder.\n\n")
-		} else {
-			for _, file := range files {
-				// List file name and last modification time
-				output.WriteString(fmt.Sprintf("  - %s (Last Modified: %s)\n", file.Name(), file.ModTime().Format("2006-01-02 15:04:05")))
+		switch {
+		case location.Error != "":
+			output.WriteString(fmt.Sprintf("  Error reading %s: %s\n", location.Path, location.Error))
+		case !location.Exists:
+			output.WriteString(missing)
+		default:
+			found := false
+			for _, item := range r.Items {
+				if item.Location != location.Path {
+					continue
+				}
+				found = true
+				switch {
+				case item.Command != "":
+					output.WriteString(fmt.Sprintf("  - %s: %s\n", item.Name, item.Command))
+				case item.Modified == "":
+					output.WriteString(fmt.Sprintf("  - %s\n", item.Name))
+				default:
+					output.WriteString(fmt.Sprintf("  - %s (Last Modified: %s)\n", item.Name, item.Modified))
+				}
+			}
+			if !found {
+				output.WriteString("  No items found.\n")
 			}
-			output.WriteString("\n")
 		}
+		output.WriteString("\n")
 	}
-	output.WriteString("\n")
-
-	// --- Footer ---
-	output.WriteString("\n\nReport generated by GoDiag. Learn more at https://github.com/LewdLillyVT/godiag")
+}
 
-	// Save the collected information to the designated output file
-	return os.WriteFile(outputPath, output.Bytes(), 0644)
+// GenerateStartupProgramsReport collects information about programs configured to run automatically at system startup
+// from common registry keys and startup folders, and saves it to a text file.
+func GenerateStartupProgramsReport(ctx context.Context, outputDir string) error {
+	return startupCollector.Run(ctx, outputDir)
 }
@@ -0,0 +1,111 @@
+package modules
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CollectStartupProgramsReport collects information about programs configured to run automatically
+// at system startup from systemd unit directories and XDG autostart folders.
+func CollectStartupProgramsReport(ctx context.Context) (*StartupReport, error) {
+	report := &StartupReport{}
+
+	// --- 1. Units enabled with systemctl ---
+	// Enabling a unit links it into the .wants directory of the target that pulls it in,
+	// e.g. /etc/systemd/system/multi-user.target.wants.
+	unitDirs := []string{"/etc/systemd/system", filepath.Join(xdgConfigHome(), "systemd", "user")}
+	for _, unitDir := range unitDirs {
+		wants, _ := filepath.Glob(filepath.Join(unitDir, "*.wants"))
+		if len(wants) == 0 {
+			location := StartupLocation{Path: unitDir, Kind: "systemd"}
+			if _, err := os.Stat(unitDir); err == nil {
+				location.Exists = true
+			}
+			report.Locations = append(report.Locations, location)
+			continue
+		}
+		sort.Strings(wants)
+		for _, dir := range wants {
+			location := StartupLocation{Path: dir, Kind: "systemd", Exists: true}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				location.Error = err.Error()
+			}
+			for _, entry := range entries {
+				report.Items = append(report.Items, StartupItem{
+					Name:     entry.Name(),
+					Command:  unitExecStart(filepath.Join(dir, entry.Name())),
+					Location: dir,
+				})
+			}
+			report.Locations = append(report.Locations, location)
+		}
+	}
+
+	// --- 2. Desktop session autostart entries ---
+	autostartDirs := []string{filepath.Join(xdgConfigHome(), "autostart")}
+	for _, dir := range xdgConfigDirs() {
+		autostartDirs = append(autostartDirs, filepath.Join(dir, "autostart"))
+	}
+	for _, dir := range autostartDirs {
+		location := StartupLocation{Path: dir, Kind: "autostart", Exists: true}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				location.Exists = false
+			} else {
+				location.Error = err.Error()
+			}
+		}
+		for _, entry := range entries {
+			if !strings.HasSuffix(entry.Name(), ".desktop") {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				continue
+			}
+			desktop := parseDesktopEntry(data)
+			// Hidden=true is how a user turns off a system-wide entry
+			if strings.EqualFold(desktop["Hidden"], "true") {
+				continue
+			}
+			name := desktop["Name"]
+			if name == "" {
+				name = strings.TrimSuffix(entry.Name(), ".desktop")
+			}
+			report.Items = append(report.Items, StartupItem{Name: name, Command: desktop["Exec"], Location: dir})
+		}
+		report.Locations = append(report.Locations, location)
+	}
+
+	return report, nil
+}
+
+// parseDesktopEntry reads the keys of the [Desktop Entry] group of a .desktop file, ignoring the
+// [Desktop Action ...] groups that may follow it.
+func parseDesktopEntry(data []byte) map[string]string {
+	text := normalizeOutput(data)
+	if i := strings.Index(text, "\n[Desktop Action"); i >= 0 {
+		text = text[:i]
+	}
+	return parseKeyValueFile([]byte(text))
+}
+
+// unitExecStart returns the first ExecStart= command of a unit file, or an empty string for
+// units that run nothing themselves, such as targets and sockets.
+func unitExecStart(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		if command, found := strings.CutPrefix(strings.TrimSpace(line), "ExecStart="); found && command != "" {
+			return strings.TrimLeft(command, "-@:+!") // Drop the prefixes that only change how it is run
+		}
+	}
+	return ""
+}
@@ -0,0 +1,20 @@
+package modules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDesktopEntry(t *testing.T) {
+	data := "[Desktop Entry]\nType=Application\nName=Nextcloud\nExec=/usr/bin/nextcloud --background\n" +
+		"X-GNOME-Autostart-enabled=true\n\n[Desktop Action Quit]\nName=Quit\nExec=/usr/bin/nextcloud --quit\n"
+	want := map[string]string{
+		"Type":                      "Application",
+		"Name":                      "Nextcloud",
+		"Exec":                      "/usr/bin/nextcloud --background",
+		"X-GNOME-Autostart-enabled": "true",
+	}
+	if got := parseDesktopEntry([]byte(data)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDesktopEntry() = %q\nwant %q", got, want)
+	}
+}
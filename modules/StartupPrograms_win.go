@@ -0,0 +1,84 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// regValuePattern matches a value line in `reg query` output: "    Name    REG_SZ    Data".
+var regValuePattern = regexp.MustCompile(`^\s{4}(.*?)\s{4}(REG_[A-Z_]+)(?:\s{4}(.*))?$`)
+
+// collectWindowsStartupProgramsReport collects information about programs configured to run automatically
+// at system startup from common registry keys and startup folders.
+func collectWindowsStartupProgramsReport(ctx context.Context) (*StartupReport, error) {
+	report := &StartupReport{}
+
+	// --- 1. Startup Programs from Registry (Run & RunOnce Keys) ---
+	registryKeys := []string{
+		"HKLM\\SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\Run",
+		"HKLM\\SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\RunOnce",
+		"HKCU\\SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\Run",
+		"HKCU\\SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\RunOnce",
+		// Also check WoW6432Node for 32-bit applications on 64-bit systems
+		"HKLM\\SOFTWARE\\WOW6432Node\\Microsoft\\Windows\\CurrentVersion\\Run",
+		"HKLM\\SOFTWARE\\WOW6432Node\\Microsoft\\Windows\\CurrentVersion\\RunOnce",
+	}
+
+	for _, key := range registryKeys {
+		location := StartupLocation{Path: key, Kind: "registry", Exists: true}
+		// Using 'reg query' command to list entries under the key
+		cmdOutput, err := runner.CombinedOutput(ctx, "reg", "query", key)
+		if err != nil {
+			// Check for specific error message if the key does not exist
+			if strings.Contains(strings.ToLower(string(cmdOutput)), "error: the system was unable to find the specified registry key or value") {
+				location.Exists = false
+			} else {
+				location.Error = fmt.Sprintf("%v: %s", err, strings.TrimSpace(string(cmdOutput)))
+			}
+		} else {
+			for _, line := range strings.Split(normalizeOutput(cmdOutput), "\n") {
+				if m := regValuePattern.FindStringSubmatch(line); m != nil {
+					report.Items = append(report.Items, StartupItem{Name: m[1], Command: m[3], Location: key})
+				}
+			}
+		}
+		report.Locations = append(report.Locations, location)
+	}
+
+	// --- 2. Startup Programs from Startup Folders ---
+	// Get APPDATA and PROGRAMDATA environment variables to find the correct paths
+	appData := os.Getenv("APPDATA")
+	programData := os.Getenv("PROGRAMDATA")
+
+	startupFolders := []string{
+		filepath.Join(appData, "Microsoft\\Windows\\Start Menu\\Programs\\Startup"),     // Current user's startup folder
+		filepath.Join(programData, "Microsoft\\Windows\\Start Menu\\Programs\\Startup"), // All users' startup folder
+	}
+
+	for _, folder := range startupFolders {
+		location := StartupLocation{Path: folder, Kind: "folder", Exists: true}
+		files, err := ioutil.ReadDir(folder) // Read contents of the directory
+		if err != nil {
+			if os.IsNotExist(err) {
+				location.Exists = false
+			} else {
+				location.Error = err.Error()
+			}
+		}
+		for _, file := range files {
+			report.Items = append(report.Items, StartupItem{
+				Name:     file.Name(),
+				Location: folder,
+				Modified: file.ModTime().Format("2006-01-02 15:04:05"),
+			})
+		}
+		report.Locations = append(report.Locations, location)
+	}
+
+	return report, nil
+}
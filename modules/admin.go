@@ -2,6 +2,7 @@ package modules
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,13 +11,13 @@ import (
 )
 
 // ensureAdminPrivileges restarts the application with administrative privileges if not already elevated.
-func ensureAdminPrivileges() error {
+func ensureAdminPrivileges(ctx context.Context) error {
 	if runtime.GOOS != "windows" {
 		return nil // Elevation is only required for Windows
 	}
 
 	// Check if the current process is already running with administrative privileges
-	isAdmin, err := isRunningAsAdmin()
+	isAdmin, err := isRunningAsAdmin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to check admin privileges: %v", err)
 	}
@@ -52,9 +53,8 @@ func ensureAdminPrivileges() error {
 }
 
 // isRunningAsAdmin checks if the current process is running with administrative privileges.
-func isRunningAsAdmin() (bool, error) {
-	cmd := exec.Command("powershell", "-Command", "([Security.Principal.WindowsPrincipal] [Security.Principal.WindowsIdentity]::GetCurrent()).IsInRole([Security.Principal.WindowsBuiltInRole]::Administrator)")
-	output, err := cmd.Output()
+func isRunningAsAdmin(ctx context.Context) (bool, error) {
+	output, err := runner.Output(ctx, "powershell", "-Command", "([Security.Principal.WindowsPrincipal] [Security.Principal.WindowsIdentity]::GetCurrent()).IsInRole([Security.Principal.WindowsBuiltInRole]::Administrator)")
 	if err != nil {
 		return false, err
 	}
@@ -62,3 +62,13 @@ func isRunningAsAdmin() (bool, error) {
 	// Check if the output is "True"
 	return bytes.Equal(bytes.TrimSpace(output), []byte("True")), nil
 }
+
+// IsElevated reports whether GoDiag is running with administrative privileges.
+// Outside Windows no elevation is required, so it always returns true there.
+func IsElevated() bool {
+	if runtime.GOOS != "windows" {
+		return true
+	}
+	isAdmin, err := isRunningAsAdmin(context.Background())
+	return err == nil && isAdmin
+}
@@ -0,0 +1,202 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestFileName is the name of the run manifest written by RunCollectors.
+const ManifestFileName = "Run_Manifest.json"
+
+// CollectorStatus is the outcome of a single collector in a batch run.
+type CollectorStatus string
+
+const (
+	StatusSuccess  CollectorStatus = "success"
+	StatusFailed   CollectorStatus = "failed"
+	StatusSkipped  CollectorStatus = "skipped"
+	StatusTimedOut CollectorStatus = "timed_out"
+)
+
+// CollectorResult records how a single collector fared in a batch run.
+type CollectorResult struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Status     CollectorStatus `json:"status"`
+	Started    time.Time       `json:"started"`
+	DurationMS int64           `json:"duration_ms"`
+	Files      []string        `json:"files"` // Output files that exist after the run, relative to the output directory
+	Error      string          `json:"error,omitempty"`
+
+	// Report is the structured result of a ReportCollector, kept so other renderers can use it
+	// without re-running the collector. It is nil for collectors that only write files.
+	Report Report `json:"-"`
+}
+
+// RunManifest summarises a batch run of several collectors.
+type RunManifest struct {
+	Started   time.Time         `json:"started"`
+	Finished  time.Time         `json:"finished"`
+	OutputDir string            `json:"output_dir"`
+	Cancelled bool              `json:"cancelled,omitempty"` // The run was stopped before every collector had run
+	Results   []CollectorResult `json:"results"`
+
+	// Findings are the problems the finding rules turned up, most severe first. They are
+	// written to Findings.txt and Findings.json rather than the manifest.
+	Findings []Finding `json:"-"`
+}
+
+// Count returns how many collectors finished with the given status.
+func (m *RunManifest) Count(status CollectorStatus) int {
+	count := 0
+	for _, result := range m.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// ProgressFunc is called by RunCollectors before each collector starts (with a nil result)
+// and again once it has finished. index is zero-based.
+type ProgressFunc func(index, total int, c Collector, result *CollectorResult)
+
+// RunCollector runs a single collector into outputDir, stopping it once its timeout (see
+// CollectorTimeout) has elapsed or ctx is cancelled, and reports how it went. Collectors turned
+// off by SetDisabledCollectors are skipped.
+func RunCollector(ctx context.Context, outputDir string, c Collector) CollectorResult {
+	if CollectorDisabled(c.ID()) {
+		return skippedResult(outputDir, c, "disabled by policy")
+	}
+	result := CollectorResult{ID: c.ID(), Name: c.Name(), Started: time.Now()}
+
+	runCtx, cancel := context.WithTimeout(ctx, CollectorTimeout(c))
+	defer cancel()
+
+	var err error
+	if rc, ok := c.(ReportCollector); ok {
+		if result.Report, err = rc.Collect(runCtx, outputDir); err == nil {
+			err = rc.WriteReport(runCtx, result.Report, outputDir)
+		}
+	} else {
+		err = c.Run(runCtx, outputDir)
+	}
+	switch {
+	case ctx.Err() == nil && runCtx.Err() == context.DeadlineExceeded:
+		// The collector may have written a partial report and returned nil; it still timed out
+		result.Status = StatusTimedOut
+		result.Error = fmt.Sprintf("timed out after %s", CollectorTimeout(c))
+	case ctx.Err() != nil:
+		result.Status = StatusSkipped
+		result.Error = ctx.Err().Error()
+	case err != nil:
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	default:
+		result.Status = StatusSuccess
+	}
+
+	result.DurationMS = time.Since(result.Started).Milliseconds()
+	result.Files = existingOutputFiles(outputDir, collectorFiles(c))
+	return result
+}
+
+// RunCollectors runs the given collectors one after another into outputDir, carrying on past
+// failures and timeouts, and writes a Run_Manifest.json describing the outcome of each along with
+// GoDiag_Report.html, which combines every report into one page, Findings.txt/.json, which rank
+// the problems the finding rules spotted in the reports, and godiag_report.json, which holds
+// every collector's report for scripts and for comparing runs.
+// Collectors that need administrative privileges are skipped when GoDiag is not elevated, and
+// any collectors left when ctx is cancelled are skipped as well. The returned error only reports
+// a failure to write the manifest or combined reports; collector failures are recorded in the
+// manifest itself.
+func RunCollectors(ctx context.Context, outputDir string, collectors []Collector, progress ProgressFunc) (*RunManifest, error) {
+	manifest := &RunManifest{
+		Started:   time.Now(),
+		OutputDir: outputDir,
+		Results:   make([]CollectorResult, 0, len(collectors)),
+	}
+
+	elevated := IsElevated()
+	for i, c := range collectors {
+		if progress != nil {
+			progress(i, len(collectors), c, nil)
+		}
+
+		var result CollectorResult
+		switch {
+		case ctx.Err() != nil:
+			result = skippedResult(outputDir, c, ctx.Err().Error())
+		case c.RequiresAdmin() && !elevated:
+			result = skippedResult(outputDir, c, "requires administrative privileges")
+		default:
+			result = RunCollector(ctx, outputDir, c)
+		}
+
+		manifest.Results = append(manifest.Results, result)
+		if progress != nil {
+			progress(i, len(collectors), c, &manifest.Results[len(manifest.Results)-1])
+		}
+	}
+	manifest.Finished = time.Now()
+	manifest.Cancelled = ctx.Err() != nil
+	manifest.Findings = EvaluateFindings(manifest.Results)
+
+	if err := writeManifest(outputDir, manifest); err != nil {
+		return manifest, err
+	}
+	if err := writeFindings(outputDir, manifest.Findings); err != nil {
+		return manifest, err
+	}
+	if err := writeHTMLReport(outputDir, manifest); err != nil {
+		return manifest, err
+	}
+	return manifest, writeCombinedReport(outputDir, manifest)
+}
+
+// skippedResult records a collector that was not run at all.
+func skippedResult(outputDir string, c Collector, reason string) CollectorResult {
+	return CollectorResult{
+		ID:      c.ID(),
+		Name:    c.Name(),
+		Status:  StatusSkipped,
+		Started: time.Now(),
+		Files:   existingOutputFiles(outputDir, collectorFiles(c)),
+		Error:   reason,
+	}
+}
+
+// collectorFiles lists the files c may write, including its .json file when the JSON export is on.
+func collectorFiles(c Collector) []string {
+	files := c.OutputFiles()
+	if _, ok := c.(ReportCollector); ok && JSONExportEnabled() {
+		files = append(files[:len(files):len(files)], JSONFileName(c))
+	}
+	return files
+}
+
+// existingOutputFiles filters files down to those present in outputDir.
+func existingOutputFiles(outputDir string, files []string) []string {
+	existing := []string{}
+	for _, file := range files {
+		if _, err := os.Stat(filepath.Join(outputDir, file)); err == nil {
+			existing = append(existing, file)
+		}
+	}
+	return existing
+}
+
+func writeManifest(outputDir string, manifest *RunManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, ManifestFileName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write run manifest: %w", err)
+	}
+	return nil
+}
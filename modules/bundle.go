@@ -0,0 +1,328 @@
+package modules
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BundleManifestFileName is the name of the manifest stored at the root of every zip bundle.
+const BundleManifestFileName = "Bundle_Manifest.json"
+
+// BundleFile describes one file stored in a zip bundle.
+type BundleFile struct {
+	Path     string    `json:"path"` // Slash-separated path inside the zip
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	SHA256   string    `json:"sha256"`
+}
+
+// BundleManifest lists every file in a zip bundle together with its SHA-256 checksum, so support
+// can tell whether anything was changed or lost on the way.
+type BundleManifest struct {
+	SchemaVersion int          `json:"schema_version"`
+	GoDiagVersion string       `json:"godiag_version,omitempty"`
+	HostName      string       `json:"host_name"`
+	Created       time.Time    `json:"created"`
+	Files         []BundleFile `json:"files"`
+
+	// Redaction is set when identifying values were replaced before the files were packed.
+	// Checksums are of the redacted files as stored in the bundle.
+	Redaction *BundleRedaction `json:"redaction,omitempty"`
+}
+
+// BundleRedaction records how a bundle was redacted, without the values that were hidden.
+type BundleRedaction struct {
+	Mode       RedactionMode       `json:"mode"`
+	Categories []RedactionCategory `json:"categories"`
+	Values     int                 `json:"values"`  // Distinct values replaced
+	Omitted    []string            `json:"omitted"` // Files left out because they cannot be redacted
+}
+
+// BundleFileName returns the name of the zip bundle for hostName created at t, such as
+// GoDiag_DESKTOP-ABC_2024-05-01_103000.zip.
+func BundleFileName(hostName string, t time.Time) string {
+	host := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, hostName)
+	if host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("GoDiag_%s_%s.zip", host, t.Format("2006-01-02_150405"))
+}
+
+// isBundle reports whether name looks like a zip bundle written by CreateBundle.
+func isBundle(name string) bool {
+	return strings.HasPrefix(name, "GoDiag_") && strings.EqualFold(filepath.Ext(name), ".zip")
+}
+
+// CreateBundle packs everything in outputDir, including subfolders such as RegistryExports, into
+// a timestamped zip in outputDir and returns its path. Earlier bundles are left out. The zip ends
+// with a Bundle_Manifest.json listing the SHA-256 checksum of every file.
+//
+// When redaction is not nil, the files are redacted as they are packed (the originals in outputDir
+// are left alone) and files that cannot be redacted are left out; PreviewRedaction shows what
+// that will hide. While a policy requires redaction (see SetRequiredRedaction), the required
+// categories are redacted whatever redaction says.
+func CreateBundle(ctx context.Context, outputDir string, redaction *RedactionConfig) (string, error) {
+	redaction = enforceRedaction(redaction)
+	files, err := bundleFiles(outputDir)
+	if err != nil {
+		return "", err
+	}
+
+	var redactor *Redactor
+	if redaction != nil {
+		if err := redaction.Validate(); err != nil {
+			return "", err
+		}
+		if redactor, err = learnRedactions(ctx, outputDir, files, *redaction); err != nil {
+			return "", err
+		}
+	}
+
+	hostName, _ := os.Hostname()
+	created := time.Now()
+	bundlePath := filepath.Join(outputDir, BundleFileName(hostName, created))
+
+	manifest := BundleManifest{
+		SchemaVersion: ReportSchemaVersion,
+		GoDiagVersion: AppVersion(),
+		HostName:      hostName,
+		Created:       created,
+		Files:         []BundleFile{},
+	}
+	if redactor != nil {
+		manifest.Redaction = &BundleRedaction{Mode: redaction.Mode, Categories: redaction.Categories, Omitted: []string{}}
+	}
+
+	// Write to a temporary name so a half-written bundle is never mistaken for a finished one
+	tempPath := bundlePath + ".partial"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer os.Remove(tempPath) // No-op once the bundle has been renamed into place
+
+	archive := zip.NewWriter(file)
+	for _, name := range files {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+
+		var bundled BundleFile
+		var included bool
+		if redactor != nil {
+			bundled, included, err = addRedactedBundleFile(archive, redactor, filepath.Join(outputDir, filepath.FromSlash(name)), name)
+			if err == nil && !included {
+				manifest.Redaction.Omitted = append(manifest.Redaction.Omitted, name)
+				continue
+			}
+		} else {
+			bundled, err = addBundleFile(archive, filepath.Join(outputDir, filepath.FromSlash(name)), name)
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to add %s to bundle: %w", name, err)
+			break
+		}
+		manifest.Files = append(manifest.Files, bundled)
+	}
+	if err == nil {
+		if redactor != nil {
+			manifest.Redaction.Values = len(redactor.Redactions())
+		}
+		err = addBundleManifest(archive, &manifest)
+	}
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tempPath, bundlePath); err != nil {
+		return "", fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	return bundlePath, nil
+}
+
+// PreviewRedaction reports what CreateBundle would hide when bundling outputDir with config,
+// without writing anything.
+func PreviewRedaction(ctx context.Context, outputDir string, config RedactionConfig) (*RedactionPreview, error) {
+	config = *enforceRedaction(&config)
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	files, err := bundleFiles(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := learnRedactions(ctx, outputDir, files, config)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &RedactionPreview{Config: config, Omitted: []string{}}
+	for _, name := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		text, _, ok := decodeText(data)
+		if !ok {
+			preview.Omitted = append(preview.Omitted, name)
+			continue
+		}
+		redactor.Redact(name, text)
+	}
+	preview.Redactions = redactor.Redactions()
+	return preview, nil
+}
+
+// bundleFiles lists the files in outputDir that go into a bundle, as slash-separated relative paths.
+func bundleFiles(outputDir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(outputDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if entry.IsDir() || isBundle(name) || strings.HasSuffix(name, ".zip.partial") {
+			return nil
+		}
+		rel, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", outputDir, err)
+	}
+	return files, nil
+}
+
+// learnRedactions creates a Redactor for config and teaches it the labelled values in every file.
+func learnRedactions(ctx context.Context, outputDir string, files []string, config RedactionConfig) (*Redactor, error) {
+	redactor := NewRedactor(config)
+	for _, name := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if text, _, ok := decodeText(data); ok {
+			redactor.Learn(text)
+		}
+	}
+	return redactor, nil
+}
+
+// addRedactedBundleFile redacts the file at path and stores it in archive as name. included is
+// false, and nothing is stored, for binary files that cannot be redacted.
+func addRedactedBundleFile(archive *zip.Writer, redactor *Redactor, path, name string) (bundled BundleFile, included bool, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return BundleFile{}, false, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return BundleFile{}, false, err
+	}
+	text, encode, ok := decodeText(data)
+	if !ok {
+		return BundleFile{}, false, nil
+	}
+	data = encode(redactor.Redact(name, text))
+
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: info.ModTime()})
+	if err != nil {
+		return BundleFile{}, false, err
+	}
+	if _, err := entry.Write(data); err != nil {
+		return BundleFile{}, false, err
+	}
+
+	hash := sha256.Sum256(data)
+	return BundleFile{
+		Path:     name,
+		Size:     int64(len(data)),
+		Modified: info.ModTime(),
+		SHA256:   hex.EncodeToString(hash[:]),
+	}, true, nil
+}
+
+// addBundleFile copies the file at path into archive as name, hashing it on the way.
+func addBundleFile(archive *zip.Writer, path, name string) (BundleFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return BundleFile{}, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return BundleFile{}, err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return BundleFile{}, err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	entry, err := archive.CreateHeader(header)
+	if err != nil {
+		return BundleFile{}, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(entry, hash), file)
+	if err != nil {
+		return BundleFile{}, err
+	}
+
+	return BundleFile{
+		Path:     name,
+		Size:     size,
+		Modified: info.ModTime(),
+		SHA256:   hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+func addBundleManifest(archive *zip.Writer, manifest *BundleManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle manifest: %w", err)
+	}
+	entry, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     BundleManifestFileName,
+		Method:   zip.Deflate,
+		Modified: manifest.Created,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(data)
+	return err
+}
@@ -0,0 +1,225 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultCollectorTimeout is how long a collector may run before it is stopped, unless the
+// collector or the user asks for something else.
+const DefaultCollectorTimeout = 3 * time.Minute
+
+// Collector is a single diagnostic that writes one or more report files into an output directory.
+// Every diagnostic in this package registers itself with Register so the GUI, the CLI and the
+// batch runner all work from the same list.
+type Collector interface {
+	// ID is a short, stable identifier such as "network", used on the command line.
+	ID() string
+	// Name is the human-readable label shown in the GUI.
+	Name() string
+	// OutputFiles lists the files (or folders) the collector writes, relative to the output directory.
+	OutputFiles() []string
+	// RequiresAdmin reports whether the collector needs administrative privileges.
+	RequiresAdmin() bool
+	// Run collects the diagnostic and writes its output files into outputDir.
+	Run(ctx context.Context, outputDir string) error
+}
+
+// ReportCollector is implemented by collectors that produce a structured Report. Run is
+// equivalent to Collect followed by WriteReport; splitting the two lets callers keep the
+// structured data around for other renderers.
+type ReportCollector interface {
+	Collector
+	// Collect gathers the report. Collectors that export raw artifacts (such as .reg files)
+	// write them into outputDir; everything else is returned in the Report.
+	Collect(ctx context.Context, outputDir string) (Report, error)
+	// WriteReport renders report into the collector's output files in outputDir, plus its .json
+	// file when the JSON export is turned on. ctx is only consulted to note in the output when
+	// collection was cut short.
+	WriteReport(ctx context.Context, report Report, outputDir string) error
+}
+
+// collectorInfo holds the descriptive fields shared by the package's collector types.
+type collectorInfo struct {
+	id            string
+	name          string
+	outputFiles   []string
+	requiresAdmin bool
+	timeout       time.Duration // Zero means DefaultCollectorTimeout
+}
+
+func (c *collectorInfo) ID() string            { return c.id }
+func (c *collectorInfo) Name() string          { return c.name }
+func (c *collectorInfo) OutputFiles() []string { return c.outputFiles }
+func (c *collectorInfo) RequiresAdmin() bool   { return c.requiresAdmin }
+
+// DefaultTimeout lets slow collectors such as msinfo32 ask for more time than DefaultCollectorTimeout.
+func (c *collectorInfo) DefaultTimeout() time.Duration {
+	if c.timeout == 0 {
+		return DefaultCollectorTimeout
+	}
+	return c.timeout
+}
+
+// reportCollector is a ReportCollector whose text report is written to the first of its output files.
+type reportCollector struct {
+	collectorInfo
+	collect func(ctx context.Context, outputDir string) (Report, error)
+}
+
+func (c *reportCollector) Collect(ctx context.Context, outputDir string) (Report, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.collect(ctx, outputDir)
+}
+
+func (c *reportCollector) WriteReport(ctx context.Context, report Report, outputDir string) error {
+	if err := writeTextReport(ctx, filepath.Join(outputDir, c.outputFiles[0]), report); err != nil {
+		return err
+	}
+	if JSONExportEnabled() {
+		if err := writeJSONReport(ctx, outputDir, c, report); err != nil {
+			return fmt.Errorf("failed to write JSON report: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *reportCollector) Run(ctx context.Context, outputDir string) error {
+	report, err := c.Collect(ctx, outputDir)
+	if err != nil {
+		return err
+	}
+	return c.WriteReport(ctx, report, outputDir)
+}
+
+// toolCollector wraps an external tool, such as msinfo32 or dxdiag, that writes its own output file.
+type toolCollector struct {
+	collectorInfo
+	run func(ctx context.Context, outputDir string) error
+}
+
+func (c *toolCollector) Run(ctx context.Context, outputDir string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.run(ctx, outputDir)
+}
+
+// collectFunc adapts a typed Collect* function, which needs no output directory, to reportCollector.
+func collectFunc[T Report](collect func(ctx context.Context) (T, error)) func(context.Context, string) (Report, error) {
+	return func(ctx context.Context, _ string) (Report, error) {
+		report, err := collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return report, nil
+	}
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Collector{}
+)
+
+// Register adds a collector to the registry. It is meant to be called from init functions
+// and panics if another collector already uses the same ID.
+func Register(c Collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[c.ID()]; exists {
+		panic(fmt.Sprintf("modules: collector %q registered twice", c.ID()))
+	}
+	registry[c.ID()] = c
+}
+
+// Collectors returns every registered collector, sorted by ID, including disabled ones.
+func Collectors() []Collector {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	collectors := make([]Collector, 0, len(registry))
+	for _, c := range registry {
+		collectors = append(collectors, c)
+	}
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].ID() < collectors[j].ID()
+	})
+	return collectors
+}
+
+// LookupCollector returns the registered collector with the given ID.
+func LookupCollector(id string) (Collector, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	c, ok := registry[id]
+	return c, ok
+}
+
+// timeoutOverrides holds user-configured timeouts, keyed by collector ID.
+var (
+	timeoutMu        sync.RWMutex
+	timeoutOverrides = map[string]time.Duration{}
+)
+
+// SetCollectorTimeout overrides how long the collector with the given ID may run.
+// A zero or negative duration removes the override.
+func SetCollectorTimeout(id string, timeout time.Duration) {
+	timeoutMu.Lock()
+	defer timeoutMu.Unlock()
+
+	if timeout <= 0 {
+		delete(timeoutOverrides, id)
+		return
+	}
+	timeoutOverrides[id] = timeout
+}
+
+// CollectorTimeout returns how long c may run: the user's override if one is set, otherwise the
+// collector's own default, otherwise DefaultCollectorTimeout.
+func CollectorTimeout(c Collector) time.Duration {
+	timeoutMu.RLock()
+	timeout, ok := timeoutOverrides[c.ID()]
+	timeoutMu.RUnlock()
+	if ok {
+		return timeout
+	}
+
+	if d, ok := c.(interface{ DefaultTimeout() time.Duration }); ok {
+		return d.DefaultTimeout()
+	}
+	return DefaultCollectorTimeout
+}
+
+// disabledCollectors holds the IDs of collectors an administrator has turned off; see
+// SetDisabledCollectors.
+var (
+	disabledMu         sync.RWMutex
+	disabledCollectors = map[string]bool{}
+)
+
+// SetDisabledCollectors turns off the collectors with the given IDs, as a machine policy
+// requires. RunCollector skips them. Unknown IDs are ignored, since one policy may cover
+// platforms with different collectors.
+func SetDisabledCollectors(ids []string) {
+	disabledMu.Lock()
+	defer disabledMu.Unlock()
+	disabledCollectors = make(map[string]bool, len(ids))
+	for _, id := range ids {
+		disabledCollectors[id] = true
+	}
+}
+
+// CollectorDisabled reports whether the collector with the given ID has been turned off.
+func CollectorDisabled(id string) bool {
+	disabledMu.RLock()
+	defer disabledMu.RUnlock()
+	return disabledCollectors[id]
+}
@@ -0,0 +1,81 @@
+package modules
+
+import "context"
+
+// The Windows collectors are implemented in the *_win.go files. Those carry no build tag: they
+// only go through the CommandRunner and QueryWMI, so the replay tests run them on every platform.
+// This file registers and exposes them on Windows.
+
+func init() {
+	Register(registryCollector)
+	Register(securityCollector)
+	Register(etlCollector)
+	Register(dxdiagCollector)
+	Register(msinfo32Collector)
+}
+
+// The section titles name the commands each platform runs.
+var (
+	sectionIPConfig    = windowsSectionIPConfig
+	sectionConnections = windowsSectionConnections
+	sectionRoutes      = windowsSectionRoutes
+	sectionDNSCache    = windowsSectionDNSCache
+	sectionPing        = windowsSectionPing
+	sectionServices    = windowsSectionServices
+)
+
+// CollectBIOSReport gathers BIOS/UEFI version information.
+func CollectBIOSReport(ctx context.Context) (*BIOSReport, error) {
+	return collectWindowsBIOSReport(ctx)
+}
+
+// CollectQuickSysInfo gathers basic system information like CPU, GPU and RAM.
+func CollectQuickSysInfo(ctx context.Context) (*SystemInfo, error) {
+	return collectWindowsQuickSysInfo(ctx)
+}
+
+// CollectDriverReport gathers information about installed drivers.
+func CollectDriverReport(ctx context.Context) (*DriverReport, error) {
+	return collectWindowsDriverReport(ctx)
+}
+
+// CollectNetworkReport gathers various network-related information.
+func CollectNetworkReport(ctx context.Context) (*NetworkReport, error) {
+	return collectWindowsNetworkReport(ctx)
+}
+
+// CollectRunningProcessesReport collects information about all currently running processes.
+func CollectRunningProcessesReport(ctx context.Context) (*ProcessReport, error) {
+	return collectWindowsRunningProcessesReport(ctx)
+}
+
+// CollectHealthAndUsageReport gathers detailed health information of drives, including SMART status.
+func CollectHealthAndUsageReport(ctx context.Context) (*HealthReport, error) {
+	return collectWindowsHealthAndUsageReport(ctx)
+}
+
+// CollectHardwareReport gathers various hardware and peripheral-related information.
+func CollectHardwareReport(ctx context.Context) (*HardwareReport, error) {
+	return collectWindowsHardwareReport(ctx)
+}
+
+// CollectSoftwareReport gathers various software and application-related information.
+func CollectSoftwareReport(ctx context.Context) (*SoftwareReport, error) {
+	return collectWindowsSoftwareReport(ctx)
+}
+
+// CollectStartupProgramsReport collects information about programs configured to run automatically
+// at system startup from common registry keys and startup folders.
+func CollectStartupProgramsReport(ctx context.Context) (*StartupReport, error) {
+	return collectWindowsStartupProgramsReport(ctx)
+}
+
+// CollectEventLogs runs the configured event queries.
+func CollectEventLogs(ctx context.Context) (*EventLogReport, error) {
+	return collectWindowsEventLogs(ctx)
+}
+
+// FlushDNSCache flushes the DNS resolver cache.
+func FlushDNSCache(ctx context.Context) error {
+	return flushWindowsDNSCache(ctx)
+}
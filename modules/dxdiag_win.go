@@ -0,0 +1,23 @@
+package modules
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+)
+
+var dxdiagCollector = &toolCollector{
+	collectorInfo: collectorInfo{
+		id:          "dxdiag",
+		name:        "Generate dxdiag.txt",
+		outputFiles: []string{"dxdiag.txt"},
+		timeout:     5 * time.Minute,
+	},
+	run: GenerateDxdiag,
+}
+
+func GenerateDxdiag(ctx context.Context, outputDir string) error {
+	outputPath := filepath.Join(outputDir, "dxdiag.txt")
+	_, err := runner.Output(ctx, "dxdiag", "/t", outputPath)
+	return err
+}
@@ -0,0 +1,360 @@
+package modules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventLevel is the severity of an event log entry.
+type EventLevel string
+
+const (
+	LevelCritical    EventLevel = "critical"
+	LevelError       EventLevel = "error"
+	LevelWarning     EventLevel = "warning"
+	LevelInformation EventLevel = "information"
+	LevelVerbose     EventLevel = "verbose"
+)
+
+// eventLevels lists every level from most to least severe, the order they are presented in.
+var eventLevels = []EventLevel{LevelCritical, LevelError, LevelWarning, LevelInformation, LevelVerbose}
+
+var eventLevelLabels = map[EventLevel]string{
+	LevelCritical:    "Critical",
+	LevelError:       "Error",
+	LevelWarning:     "Warning",
+	LevelInformation: "Information",
+	LevelVerbose:     "Verbose",
+}
+
+// eventLevelValues are the System/Level values Windows stores for each severity. Events logged
+// with the LogAlways level carry 0 and are shown as Information by Event Viewer.
+var eventLevelValues = map[EventLevel][]int{
+	LevelCritical:    {1},
+	LevelError:       {2},
+	LevelWarning:     {3},
+	LevelInformation: {0, 4},
+	LevelVerbose:     {5},
+}
+
+// EventLevels returns every event level, from most to least severe.
+func EventLevels() []EventLevel {
+	return append([]EventLevel(nil), eventLevels...)
+}
+
+// Label returns the human-readable name of the level.
+func (l EventLevel) Label() string {
+	if label, ok := eventLevelLabels[l]; ok {
+		return label
+	}
+	return string(l)
+}
+
+// ParseEventLevels parses a comma-separated list of levels, or "all".
+func ParseEventLevels(spec string) ([]EventLevel, error) {
+	if strings.TrimSpace(spec) == "all" {
+		return EventLevels(), nil
+	}
+
+	var levels []EventLevel
+	for _, name := range strings.Split(spec, ",") {
+		level := EventLevel(strings.ToLower(strings.TrimSpace(name)))
+		if level == "" {
+			continue
+		}
+		if _, ok := eventLevelLabels[level]; !ok {
+			return nil, fmt.Errorf("unknown event level %q", level)
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// DefaultMaxEvents is how many events a query returns per channel when it does not say.
+const DefaultMaxEvents = 10
+
+// maxEventsLimit keeps a mistyped count from dumping an entire log.
+const maxEventsLimit = 10000
+
+// maxEventID is the largest ID an event can have; Windows stores it in 16 bits.
+const maxEventID = 65535
+
+// EventQuery selects entries from one or more event log channels.
+type EventQuery struct {
+	Title     string       `json:"title,omitempty"`      // Heading in the report; described from the filters when empty
+	Channels  []string     `json:"channels"`             // e.g. System, Application, Setup or Microsoft-Windows-*/Operational
+	Levels    []EventLevel `json:"levels,omitempty"`     // Empty for every level
+	Since     string       `json:"since,omitempty"`      // Only events newer than this, e.g. "24h" or "7d"; empty for any age
+	Providers []string     `json:"providers,omitempty"`  // Event sources to keep; empty for every provider
+	EventIDs  []int        `json:"event_ids,omitempty"`  // Event IDs to keep, e.g. 41 or 6008; empty for every ID
+	MaxEvents int          `json:"max_events,omitempty"` // Newest events to return per channel; DefaultMaxEvents when zero
+}
+
+// Validate checks that the query names at least one channel and that every filter is usable.
+func (q EventQuery) Validate() error {
+	if len(q.Channels) == 0 {
+		return fmt.Errorf("event query %q has no channels", q.DisplayTitle())
+	}
+	for _, channel := range q.Channels {
+		if strings.TrimSpace(channel) == "" || strings.ContainsAny(channel, "\r\n") {
+			return fmt.Errorf("invalid event log channel %q", channel)
+		}
+	}
+	for _, level := range q.Levels {
+		if _, ok := eventLevelLabels[level]; !ok {
+			return fmt.Errorf("unknown event level %q", level)
+		}
+	}
+	for _, provider := range q.Providers {
+		if strings.TrimSpace(provider) == "" || (strings.Contains(provider, "'") && strings.Contains(provider, `"`)) {
+			return fmt.Errorf("invalid event provider %q", provider)
+		}
+	}
+	for _, id := range q.EventIDs {
+		if id < 0 || id > maxEventID {
+			return fmt.Errorf("event ID %d is out of range (0-%d)", id, maxEventID)
+		}
+	}
+	if _, err := ParseEventWindow(q.Since); err != nil {
+		return err
+	}
+	if q.MaxEvents < 0 || q.MaxEvents > maxEventsLimit {
+		return fmt.Errorf("event count %d is out of range (1-%d)", q.MaxEvents, maxEventsLimit)
+	}
+	return nil
+}
+
+// Limit returns the number of events to read per channel.
+func (q EventQuery) Limit() int {
+	if q.MaxEvents <= 0 {
+		return DefaultMaxEvents
+	}
+	return q.MaxEvents
+}
+
+// DisplayTitle returns the query's title, or one describing its filters, such as
+// "Last 50 Critical/Error Events (last 24h)".
+func (q EventQuery) DisplayTitle() string {
+	if q.Title != "" {
+		return q.Title
+	}
+	labels := make([]string, 0, len(q.Levels))
+	for _, level := range q.Levels {
+		labels = append(labels, level.Label())
+	}
+	title := fmt.Sprintf("Last %d Events", q.Limit())
+	if len(labels) > 0 {
+		title = fmt.Sprintf("Last %d %s Events", q.Limit(), strings.Join(labels, "/"))
+	}
+	if len(q.Providers) > 0 {
+		title += " from " + strings.Join(q.Providers, ", ")
+	}
+	if len(q.EventIDs) > 0 {
+		ids := make([]string, 0, len(q.EventIDs))
+		for _, id := range q.EventIDs {
+			ids = append(ids, strconv.Itoa(id))
+		}
+		title += " with ID " + strings.Join(ids, ", ")
+	}
+	if q.Since != "" {
+		title += " (last " + q.Since + ")"
+	}
+	return title
+}
+
+// XPath returns the query as the XPath filter `wevtutil qe /q:` expects, e.g.
+// *[System[(Level=1 or Level=2) and TimeCreated[timediff(@SystemTime) <= 86400000]]].
+// A query without filters selects every event: "*".
+func (q EventQuery) XPath() (string, error) {
+	if err := q.Validate(); err != nil {
+		return "", err
+	}
+
+	var conditions []string
+	if len(q.Levels) > 0 {
+		var terms []string
+		seen := make(map[int]bool)
+		for _, level := range q.Levels {
+			for _, value := range eventLevelValues[level] {
+				if !seen[value] {
+					seen[value] = true
+					terms = append(terms, "Level="+strconv.Itoa(value))
+				}
+			}
+		}
+		conditions = append(conditions, "("+strings.Join(terms, " or ")+")")
+	}
+	if len(q.Providers) > 0 {
+		terms := make([]string, 0, len(q.Providers))
+		for _, provider := range q.Providers {
+			terms = append(terms, "@Name="+xpathLiteral(strings.TrimSpace(provider)))
+		}
+		conditions = append(conditions, "Provider["+strings.Join(terms, " or ")+"]")
+	}
+	if len(q.EventIDs) > 0 {
+		terms := make([]string, 0, len(q.EventIDs))
+		for _, id := range q.EventIDs {
+			terms = append(terms, "EventID="+strconv.Itoa(id))
+		}
+		conditions = append(conditions, "("+strings.Join(terms, " or ")+")")
+	}
+	if window, _ := ParseEventWindow(q.Since); window > 0 {
+		conditions = append(conditions, fmt.Sprintf("TimeCreated[timediff(@SystemTime) <= %d]", window.Milliseconds()))
+	}
+
+	if len(conditions) == 0 {
+		return "*", nil
+	}
+	return "*[System[" + strings.Join(conditions, " and ") + "]]", nil
+}
+
+// xpathLiteral quotes s as an XPath string literal. XPath 1.0 has no escapes, so the quote
+// character is whichever one s does not contain.
+func xpathLiteral(s string) string {
+	if strings.Contains(s, "'") {
+		return `"` + s + `"`
+	}
+	return "'" + s + "'"
+}
+
+// ParseEventIDs parses a comma-separated list of event IDs.
+func ParseEventIDs(spec string) ([]int, error) {
+	var ids []int
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil || id < 0 || id > maxEventID {
+			return nil, fmt.Errorf("invalid event ID %q", field)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ParseEventWindow parses how far back a query reaches: a Go duration such as "90m" or "24h",
+// or a number of days such as "7d". An empty string means no limit and yields zero.
+func ParseEventWindow(spec string) (time.Duration, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return 0, nil
+	}
+	var window time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(spec, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		window, err = time.ParseDuration(spec)
+	}
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf("invalid time window %q (expected e.g. 30m, 24h or 7d)", spec)
+	}
+	return window, nil
+}
+
+// EventPreset is a named set of event queries offered in the GUI and by `collect --events`.
+type EventPreset struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Queries     []EventQuery `json:"queries"`
+}
+
+// Names of the event presets with special meaning.
+const (
+	EventPresetDefault = "default" // What GoDiag has always collected
+	EventPresetCustom  = "custom"  // A single query the user builds in the settings
+)
+
+var eventPresets = []EventPreset{
+	{
+		Name:        EventPresetDefault,
+		Description: "Latest warnings, errors and critical events from the System log",
+		Queries: []EventQuery{
+			{Title: "Last 10 Warning Events", Channels: []string{"System"}, Levels: []EventLevel{LevelWarning}},
+			{Title: "Last 10 Error Events", Channels: []string{"System"}, Levels: []EventLevel{LevelError}},
+			{Title: "Last 10 Critical Events", Channels: []string{"System"}, Levels: []EventLevel{LevelCritical}},
+		},
+	},
+	{
+		Name:        "last24h",
+		Description: "Errors and critical events of the last 24 hours from the System and Application logs",
+		Queries: []EventQuery{
+			{Title: "Errors and Critical Events in the Last 24 Hours", Channels: []string{"System", "Application"},
+				Levels: []EventLevel{LevelCritical, LevelError}, Since: "24h", MaxEvents: 50},
+		},
+	},
+	{
+		Name:        "crashes",
+		Description: "Unexpected shutdowns, bug checks and application crashes of the last 7 days",
+		Queries: []EventQuery{
+			{Title: "Unexpected Shutdowns and Bug Checks", Channels: []string{"System"},
+				Providers: []string{"Microsoft-Windows-Kernel-Power", "Microsoft-Windows-WER-SystemErrorReporting", "EventLog"},
+				Levels:    []EventLevel{LevelCritical, LevelError}, Since: "7d", MaxEvents: 25},
+			{Title: "Application Crashes and Hangs", Channels: []string{"Application"},
+				Providers: []string{"Application Error", "Application Hang", "Windows Error Reporting"}, Since: "7d", MaxEvents: 25},
+		},
+	},
+	{
+		Name:        "hardware",
+		Description: "Hardware, disk and file system errors of the last 30 days",
+		Queries: []EventQuery{
+			{Title: "Hardware and Storage Events", Channels: []string{"System"},
+				Providers: []string{"Microsoft-Windows-WHEA-Logger", "disk", "Ntfs", "stornvme", "storahci", "volmgr"},
+				Levels:    []EventLevel{LevelCritical, LevelError, LevelWarning}, Since: "30d", MaxEvents: 50},
+		},
+	},
+	{
+		Name:        "updates",
+		Description: "Windows Setup and Windows Update problems of the last 30 days",
+		Queries: []EventQuery{
+			{Title: "Setup and Update Events", Channels: []string{"Setup", "Microsoft-Windows-WindowsUpdateClient/Operational"},
+				Levels: []EventLevel{LevelCritical, LevelError, LevelWarning}, Since: "30d", MaxEvents: 25},
+		},
+	},
+}
+
+// EventPresets returns the built-in event presets, default first.
+func EventPresets() []EventPreset {
+	return append([]EventPreset(nil), eventPresets...)
+}
+
+// LookupEventPreset returns the built-in preset with the given name.
+func LookupEventPreset(name string) (EventPreset, bool) {
+	for _, preset := range eventPresets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	return EventPreset{}, false
+}
+
+var (
+	eventQueriesMu sync.RWMutex
+	eventQueries   []EventQuery
+)
+
+// SetEventQueries sets the queries the event log collector runs. Passing nil restores the
+// default preset.
+func SetEventQueries(queries []EventQuery) {
+	eventQueriesMu.Lock()
+	defer eventQueriesMu.Unlock()
+	eventQueries = append([]EventQuery(nil), queries...)
+}
+
+// EventQueries returns the queries the event log collector runs.
+func EventQueries() []EventQuery {
+	eventQueriesMu.RLock()
+	defer eventQueriesMu.RUnlock()
+	if len(eventQueries) == 0 {
+		preset, _ := LookupEventPreset(EventPresetDefault)
+		return append([]EventQuery(nil), preset.Queries...)
+	}
+	return append([]EventQuery(nil), eventQueries...)
+}
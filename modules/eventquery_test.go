@@ -0,0 +1,225 @@
+package modules
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventQueryXPath(t *testing.T) {
+	system := []string{"System"}
+	tests := []struct {
+		name  string
+		query EventQuery
+		want  string
+	}{
+		{"no filters", EventQuery{Channels: system}, "*"},
+		{"one level", EventQuery{Channels: system, Levels: []EventLevel{LevelError}}, "*[System[(Level=2)]]"},
+		{"levels", EventQuery{Channels: system, Levels: []EventLevel{LevelCritical, LevelError, LevelWarning}},
+			"*[System[(Level=1 or Level=2 or Level=3)]]"},
+		// Information covers the LogAlways level too, and repeated levels add nothing
+		{"information", EventQuery{Channels: system, Levels: []EventLevel{LevelInformation, LevelVerbose, LevelInformation}},
+			"*[System[(Level=0 or Level=4 or Level=5)]]"},
+		{"event IDs", EventQuery{Channels: system, EventIDs: []int{41, 6008}}, "*[System[(EventID=41 or EventID=6008)]]"},
+		{"event ID 0", EventQuery{Channels: system, EventIDs: []int{0}}, "*[System[(EventID=0)]]"},
+		{"providers", EventQuery{Channels: system, Providers: []string{"Microsoft-Windows-Kernel-Power", " disk "}},
+			"*[System[Provider[@Name='Microsoft-Windows-Kernel-Power' or @Name='disk']]]"},
+		{"hours", EventQuery{Channels: system, Since: "24h"}, "*[System[TimeCreated[timediff(@SystemTime) <= 86400000]]]"},
+		{"minutes", EventQuery{Channels: system, Since: "90m"}, "*[System[TimeCreated[timediff(@SystemTime) <= 5400000]]]"},
+		{"days", EventQuery{Channels: system, Since: "7d"}, "*[System[TimeCreated[timediff(@SystemTime) <= 604800000]]]"},
+		{"apostrophe", EventQuery{Channels: system, Providers: []string{"Contoso's Agent"}},
+			`*[System[Provider[@Name="Contoso's Agent"]]]`},
+		{"double quote", EventQuery{Channels: system, Providers: []string{`The "Agent"`}},
+			`*[System[Provider[@Name='The "Agent"']]]`},
+		{"markup", EventQuery{Channels: system, Providers: []string{"A&B <Service>"}},
+			"*[System[Provider[@Name='A&B <Service>']]]"},
+		{"every filter", EventQuery{Channels: []string{"System", "Application"}, Levels: []EventLevel{LevelCritical},
+			Providers: []string{"EventLog"}, EventIDs: []int{6008}, Since: "30d", MaxEvents: 5},
+			"*[System[(Level=1) and Provider[@Name='EventLog'] and (EventID=6008) and TimeCreated[timediff(@SystemTime) <= 2592000000]]]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.query.XPath()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("XPath() = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventQueryValidate(t *testing.T) {
+	system := []string{"System"}
+	tests := []struct {
+		name  string
+		query EventQuery
+		err   string
+	}{
+		{"no channels", EventQuery{}, "has no channels"},
+		{"blank channel", EventQuery{Channels: []string{"System", " "}}, "invalid event log channel"},
+		{"channel with newline", EventQuery{Channels: []string{"System\r\n/q:*"}}, "invalid event log channel"},
+		{"unknown level", EventQuery{Channels: system, Levels: []EventLevel{"fatal"}}, `unknown event level "fatal"`},
+		{"blank provider", EventQuery{Channels: system, Providers: []string{""}}, "invalid event provider"},
+		// XPath 1.0 cannot quote a string holding both kinds of quote
+		{"both quotes", EventQuery{Channels: system, Providers: []string{`It's "quoted"`}}, "invalid event provider"},
+		{"negative event ID", EventQuery{Channels: system, EventIDs: []int{-1}}, "event ID -1 is out of range"},
+		{"large event ID", EventQuery{Channels: system, EventIDs: []int{65536}}, "event ID 65536 is out of range"},
+		{"bad window", EventQuery{Channels: system, Since: "yesterday"}, "invalid time window"},
+		{"negative window", EventQuery{Channels: system, Since: "-1h"}, "invalid time window"},
+		{"negative count", EventQuery{Channels: system, MaxEvents: -1}, "out of range"},
+		{"large count", EventQuery{Channels: system, MaxEvents: maxEventsLimit + 1}, "out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.err)
+			}
+			if _, err := tt.query.XPath(); err == nil {
+				t.Error("XPath() of an invalid query succeeded")
+			}
+		})
+	}
+}
+
+func TestEventPresetXPaths(t *testing.T) {
+	want := map[string][]string{
+		EventPresetDefault: {
+			"*[System[(Level=3)]]",
+			"*[System[(Level=2)]]",
+			"*[System[(Level=1)]]",
+		},
+		"last24h": {
+			"*[System[(Level=1 or Level=2) and TimeCreated[timediff(@SystemTime) <= 86400000]]]",
+		},
+		"crashes": {
+			"*[System[(Level=1 or Level=2) and Provider[@Name='Microsoft-Windows-Kernel-Power' or @Name='Microsoft-Windows-WER-SystemErrorReporting' or @Name='EventLog'] and TimeCreated[timediff(@SystemTime) <= 604800000]]]",
+			"*[System[Provider[@Name='Application Error' or @Name='Application Hang' or @Name='Windows Error Reporting'] and TimeCreated[timediff(@SystemTime) <= 604800000]]]",
+		},
+		"hardware": {
+			"*[System[(Level=1 or Level=2 or Level=3) and Provider[@Name='Microsoft-Windows-WHEA-Logger' or @Name='disk' or @Name='Ntfs' or @Name='stornvme' or @Name='storahci' or @Name='volmgr'] and TimeCreated[timediff(@SystemTime) <= 2592000000]]]",
+		},
+		"updates": {
+			"*[System[(Level=1 or Level=2 or Level=3) and TimeCreated[timediff(@SystemTime) <= 2592000000]]]",
+		},
+	}
+	presets := EventPresets()
+	if len(presets) != len(want) || presets[0].Name != EventPresetDefault {
+		t.Fatalf("got %d presets starting with %q, want %d starting with %q", len(presets), presets[0].Name, len(want), EventPresetDefault)
+	}
+	for _, preset := range presets {
+		t.Run(preset.Name, func(t *testing.T) {
+			var got []string
+			for _, query := range preset.Queries {
+				xpath, err := query.XPath()
+				if err != nil {
+					t.Fatalf("%s: %v", query.DisplayTitle(), err)
+				}
+				got = append(got, xpath)
+			}
+			if !reflect.DeepEqual(got, want[preset.Name]) {
+				t.Errorf("XPaths = %q\nwant %q", got, want[preset.Name])
+			}
+			if found, ok := LookupEventPreset(preset.Name); !ok || !reflect.DeepEqual(found, preset) {
+				t.Errorf("LookupEventPreset(%q) = %+v, %t", preset.Name, found, ok)
+			}
+		})
+	}
+	if _, ok := LookupEventPreset(EventPresetCustom); ok {
+		t.Errorf("%q is a built-in preset", EventPresetCustom)
+	}
+}
+
+func TestEventQueryDisplayTitle(t *testing.T) {
+	tests := []struct {
+		query EventQuery
+		want  string
+	}{
+		{EventQuery{Title: "Boot Problems", Levels: []EventLevel{LevelError}}, "Boot Problems"},
+		{EventQuery{}, "Last 10 Events"},
+		{EventQuery{Levels: []EventLevel{LevelCritical, LevelError}, Since: "24h", MaxEvents: 50}, "Last 50 Critical/Error Events (last 24h)"},
+		{EventQuery{Providers: []string{"EventLog"}, EventIDs: []int{6005, 6006}}, "Last 10 Events from EventLog with ID 6005, 6006"},
+	}
+	for _, tt := range tests {
+		if got := tt.query.DisplayTitle(); got != tt.want {
+			t.Errorf("DisplayTitle() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestParseEventLevels(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []EventLevel
+	}{
+		{"all", EventLevels()},
+		{"Critical, error,,", []EventLevel{LevelCritical, LevelError}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := ParseEventLevels(tt.spec)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseEventLevels(%q) = %q, %v; want %q", tt.spec, got, err, tt.want)
+		}
+	}
+	if _, err := ParseEventLevels("error,severe"); err == nil {
+		t.Error(`ParseEventLevels("error,severe") succeeded`)
+	}
+}
+
+func TestParseEventIDs(t *testing.T) {
+	got, err := ParseEventIDs(" 41, 6008,,1001 ")
+	if err != nil || !reflect.DeepEqual(got, []int{41, 6008, 1001}) {
+		t.Errorf("ParseEventIDs() = %v, %v", got, err)
+	}
+	for _, spec := range []string{"41,x", "-1", "70000", "4.1"} {
+		if _, err := ParseEventIDs(spec); err == nil {
+			t.Errorf("ParseEventIDs(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestParseEventWindow(t *testing.T) {
+	tests := []struct {
+		spec string
+		want time.Duration
+	}{
+		{"", 0},
+		{" 30m ", 30 * time.Minute},
+		{"24h", 24 * time.Hour},
+		{"7d", 7 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := ParseEventWindow(tt.spec)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseEventWindow(%q) = %v, %v; want %v", tt.spec, got, err, tt.want)
+		}
+	}
+	for _, spec := range []string{"0d", "0s", "d", "1w", "-2h"} {
+		if _, err := ParseEventWindow(spec); err == nil {
+			t.Errorf("ParseEventWindow(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestSetEventQueries(t *testing.T) {
+	defer SetEventQueries(nil)
+	preset, _ := LookupEventPreset(EventPresetDefault)
+	if got := EventQueries(); !reflect.DeepEqual(got, preset.Queries) {
+		t.Errorf("EventQueries() = %+v, want the default preset", got)
+	}
+
+	custom := []EventQuery{{Channels: []string{"Application"}, EventIDs: []int{1000}}}
+	SetEventQueries(custom)
+	custom[0].Channels = []string{"Changed"}
+	if got := EventQueries(); len(got) != 1 || got[0].Channels[0] != "Application" {
+		t.Errorf("EventQueries() = %+v after SetEventQueries", got)
+	}
+	SetEventQueries(nil)
+	if got := EventQueries(); !reflect.DeepEqual(got, preset.Queries) {
+		t.Errorf("EventQueries() = %+v after SetEventQueries(nil)", got)
+	}
+}
@@ -0,0 +1,164 @@
+package modules
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// eventTimeLayout is how event times are shown in reports, in the local time zone.
+const eventTimeLayout = "2006-01-02T15:04:05.000"
+
+// xmlEvent is the subset of the Windows event schema GoDiag reads. RenderingInfo is only present
+// in `/f:RenderedXml` output.
+type xmlEvent struct {
+	System struct {
+		Provider struct {
+			Name            string `xml:"Name,attr"`
+			EventSourceName string `xml:"EventSourceName,attr"`
+		} `xml:"Provider"`
+		EventID     int64 `xml:"EventID"`
+		Level       *int  `xml:"Level"`
+		TimeCreated struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		} `xml:"TimeCreated"`
+		EventRecordID int64  `xml:"EventRecordID"`
+		Channel       string `xml:"Channel"`
+		Computer      string `xml:"Computer"`
+		Security      struct {
+			UserID string `xml:"UserID,attr"`
+		} `xml:"Security"`
+	} `xml:"System"`
+	EventData struct {
+		Data []struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"Data"`
+	} `xml:"EventData"`
+	UserData struct {
+		Inner []byte `xml:",innerxml"`
+	} `xml:"UserData"`
+	RenderingInfo struct {
+		Message string `xml:"Message"`
+		Level   string `xml:"Level"`
+	} `xml:"RenderingInfo"`
+}
+
+// ParseEventXML parses the output of `wevtutil qe ... /f:xml` or `/f:RenderedXml`: a sequence
+// of <Event> elements without a root element. Event Viewer's "Save as XML" files, which wrap the
+// events in <Events>, are accepted too.
+func ParseEventXML(data []byte) ([]Event, error) {
+	decoder := xml.NewDecoder(strings.NewReader(normalizeOutput(data)))
+	// normalizeOutput has already decoded UTF-16 files, whose declaration still says UTF-16
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) { return input, nil }
+	var events []Event
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return events, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse event XML: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Event" {
+			continue
+		}
+		var raw xmlEvent
+		if err := decoder.DecodeElement(&raw, &start); err != nil {
+			return nil, fmt.Errorf("failed to parse event XML: %w", err)
+		}
+		events = append(events, raw.event())
+	}
+}
+
+// event converts a decoded <Event> element to an Event.
+func (x xmlEvent) event() Event {
+	event := Event{
+		LogName:  x.System.Channel,
+		Provider: x.System.Provider.Name,
+		EventID:  x.System.EventID,
+		RecordID: x.System.EventRecordID,
+		Computer: x.System.Computer,
+		User:     x.System.Security.UserID,
+		Message:  strings.TrimSpace(strings.ReplaceAll(x.RenderingInfo.Message, "\r\n", "\n")), // Line breaks are written as &#13;&#10;
+		Level:    strings.TrimSpace(x.RenderingInfo.Level),
+	}
+	// Classic sources are registered with their own name, which is the one Event Viewer shows
+	if x.System.Provider.EventSourceName != "" {
+		event.Provider = x.System.Provider.EventSourceName
+	}
+	if event.Level == "" && x.System.Level != nil {
+		event.Level = eventLevelName(*x.System.Level)
+	}
+	if t, err := time.Parse(time.RFC3339Nano, x.System.TimeCreated.SystemTime); err == nil {
+		event.Time = t.Local().Format(eventTimeLayout)
+	}
+
+	for _, data := range x.EventData.Data {
+		event.Data = append(event.Data, EventData{Name: data.Name, Value: strings.TrimSpace(data.Value)})
+	}
+	event.Data = append(event.Data, parseUserData(x.UserData.Inner)...)
+
+	// Without rendering information the insertion strings are the closest thing to a message
+	if event.Message == "" {
+		var values []string
+		for _, data := range event.Data {
+			if data.Value == "" {
+				continue
+			}
+			if data.Name != "" {
+				values = append(values, data.Name+": "+data.Value)
+			} else {
+				values = append(values, data.Value)
+			}
+		}
+		event.Message = strings.Join(values, "\n")
+	}
+	return event
+}
+
+// parseUserData flattens the provider-defined XML inside <UserData> into one entry per element
+// that holds text, named after that element.
+func parseUserData(inner []byte) []EventData {
+	decoder := xml.NewDecoder(strings.NewReader(string(inner)))
+	var data []EventData
+	var names []string
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return data
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			names = append(names, t.Name.Local)
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if value := strings.TrimSpace(text.String()); value != "" && len(names) > 0 {
+				data = append(data, EventData{Name: names[len(names)-1], Value: value})
+			}
+			text.Reset()
+			if len(names) > 0 {
+				names = names[:len(names)-1]
+			}
+		}
+	}
+}
+
+// eventLevelName returns the name Event Viewer shows for a System/Level value.
+func eventLevelName(value int) string {
+	for _, level := range eventLevels {
+		for _, v := range eventLevelValues[level] {
+			if v == value {
+				return level.Label()
+			}
+		}
+	}
+	return fmt.Sprintf("Level %d", value)
+}
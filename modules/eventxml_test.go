@@ -0,0 +1,186 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseEventXML(t *testing.T) {
+	// Event times are shown in the local time zone
+	defer func(local *time.Location) { time.Local = local }(time.Local)
+	time.Local = time.UTC
+
+	kernelPower := Event{
+		LogName: "System", Provider: "Microsoft-Windows-Kernel-Power", EventID: 41, RecordID: 51234,
+		Level: "Critical", Time: "2024-03-04T08:15:42.641", Computer: "DESKTOP-7Q2K9LM", User: "S-1-5-18",
+		Message: "The system has rebooted without cleanly shutting down first. This error could be caused if the system stopped responding, crashed, or lost power unexpectedly.",
+		Data: []EventData{
+			{Name: "BugcheckCode", Value: "0"}, {Name: "BugcheckParameter1", Value: "0x0"},
+			{Name: "SleepInProgress", Value: "0"}, {Name: "PowerButtonTimestamp", Value: "0"},
+		},
+	}
+	unexpectedShutdown := Event{
+		LogName: "System", Provider: "EventLog", EventID: 6008, RecordID: 51229,
+		Level: "Error", Time: "2024-03-04T08:15:37.000", Computer: "DESKTOP-7Q2K9LM",
+		Message: "The previous system shutdown at 8:02:11 AM on 3/4/2024 was unexpected.",
+		Data:    []EventData{{Value: "8:02:11 AM"}, {Value: "3/4/2024"}, {Value: ""}, {Value: ""}, {Value: "20471"}},
+	}
+	appCrashes := []Event{
+		{
+			LogName: "Application", Provider: "Application Error", EventID: 1000, RecordID: 88120,
+			Level: "Error", Time: "2024-03-02T16:22:10.441", Computer: "DESKTOP-7Q2K9LM",
+			Message: "Faulting application name: explorer.exe, version: 10.0.22621.3235\nException code: 0xc0000005",
+			Data:    []EventData{{Value: "explorer.exe"}, {Value: "10.0.22621.3235"}, {Value: "c0000005"}},
+		},
+		{
+			LogName: "Application", Provider: "Application Hang", EventID: 1002, RecordID: 88127,
+			Level: "Error", Time: "2024-03-02T16:25:47.001", Computer: "DESKTOP-7Q2K9LM",
+			Message: "The program OUTLOOK.EXE version 16.0.17328.20142 stopped interacting with Windows and was closed.",
+			Data:    []EventData{{Value: "OUTLOOK.EXE"}, {Value: "16.0.17328.20142"}},
+		},
+	}
+
+	tests := []struct {
+		file string
+		want []Event
+	}{
+		// `wevtutil qe System /f:RenderedXml`: several events one after another, without a root element
+		{"system_rendered.xml", []Event{
+			kernelPower,
+			unexpectedShutdown,
+			{
+				LogName: "System", Provider: "Service Control Manager", EventID: 7000, RecordID: 51102,
+				Level: "Error", Time: "2024-03-03T19:40:05.118", Computer: "DESKTOP-7Q2K9LM",
+				Message: "The Contoso Update Service service failed to start due to the following error: \nThe system cannot find the file specified.",
+				Data: []EventData{
+					{Name: "param1", Value: "Contoso Update Service"}, {Name: "param2", Value: "%%2"}, {Name: "param3", Value: "ContosoUpdate"},
+				},
+			},
+		}},
+		// `/f:xml` has no RenderingInfo: the level comes from System/Level and the message from
+		// the insertion strings
+		{"system_plain.xml", []Event{
+			{
+				LogName: "System", Provider: "Microsoft-Windows-Kernel-Power", EventID: 41, RecordID: 51234,
+				Level: "Critical", Time: "2024-03-04T08:15:42.641", Computer: "DESKTOP-7Q2K9LM", User: "S-1-5-18",
+				Message: "BugcheckCode: 0\nBugcheckParameter1: 0x0\nSleepInProgress: 0",
+				Data: []EventData{
+					{Name: "BugcheckCode", Value: "0"}, {Name: "BugcheckParameter1", Value: "0x0"},
+					{Name: "SleepInProgress", Value: "0"}, {Name: "PowerButtonTimestamp", Value: ""},
+				},
+			},
+			{
+				LogName: "System", Provider: "EventLog", EventID: 6008, RecordID: 51229,
+				Level: "Error", Time: "2024-03-04T08:15:37.000", Computer: "DESKTOP-7Q2K9LM",
+				Message: "8:02:11 AM\n3/4/2024\n20471",
+				Data:    unexpectedShutdown.Data,
+			},
+			// LogAlways events carry level 0, which Event Viewer shows as Information
+			{
+				LogName: "System", Provider: "Microsoft-Windows-Time-Service", EventID: 158, RecordID: 51240,
+				Level: "Information", Time: "2024-03-04T08:16:01.500", Computer: "DESKTOP-7Q2K9LM", User: "S-1-5-19",
+				Message: "TimeProvider: VMICTimeProvider",
+				Data:    []EventData{{Name: "TimeProvider", Value: "VMICTimeProvider"}},
+			},
+		}},
+		// Provider-defined <UserData> is flattened into one value per element holding text
+		{"userdata_rendered.xml", []Event{{
+			LogName: "System", Provider: "Microsoft-Windows-Eventlog", EventID: 104, RecordID: 50001,
+			Level: "Information", Time: "2024-03-01T12:00:03.987", Computer: "DESKTOP-7Q2K9LM",
+			User:    "S-1-5-21-3623811015-3361044348-30300820-1001",
+			Message: "The Application log file was cleared.",
+			Data: []EventData{
+				{Name: "SubjectUserName", Value: "jdoe"}, {Name: "SubjectDomainName", Value: "DESKTOP-7Q2K9LM"},
+				{Name: "Channel", Value: "Application"},
+			},
+		}}},
+		// Event Viewer's "Save as XML" wraps the events in <Events> after an XML declaration
+		{"saved_events.xml", appCrashes},
+		// The same file saved as UTF-16, which the declaration then names
+		{"saved_events_utf16.xml", appCrashes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "eventxml", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseEventXML(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d events, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("event %d = %+v\nwant %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseEventXMLEmpty(t *testing.T) {
+	for _, data := range []string{"", "\r\n", "<Events></Events>"} {
+		events, err := ParseEventXML([]byte(data))
+		if err != nil || events != nil {
+			t.Errorf("ParseEventXML(%q) = %+v, %v; want no events", data, events, err)
+		}
+	}
+}
+
+func TestParseEventXMLMalformed(t *testing.T) {
+	for _, data := range []string{
+		"<Event><System><EventID>41</EventID></System>",
+		"<Event><System><EventID>forty-one</EventID></System></Event>",
+	} {
+		if _, err := ParseEventXML([]byte(data)); err == nil {
+			t.Errorf("ParseEventXML(%q) succeeded", data)
+		}
+	}
+}
+
+func TestSummarizeEvents(t *testing.T) {
+	defer func(local *time.Location) { time.Local = local }(time.Local)
+	time.Local = time.UTC
+
+	data, err := os.ReadFile(filepath.Join("testdata", "eventxml", "system_rendered.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := ParseEventXML(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	earlierCrash := events[0]
+	earlierCrash.RecordID, earlierCrash.Time = 50877, "2024-02-27T21:03:12.004"
+
+	// The critical and error queries both return the shutdown events; they are counted once
+	summary := summarizeEvents([]EventGroup{
+		{Title: "Critical", Events: []Event{events[0], earlierCrash}},
+		{Title: "Errors", Events: events},
+	})
+	if summary == nil || summary.Total != 4 {
+		t.Fatalf("summary = %+v, want 4 events", summary)
+	}
+	top := summary.TopEvents[0]
+	if top.Provider != "Microsoft-Windows-Kernel-Power" || top.EventID != 41 || top.Count != 2 ||
+		top.First != "2024-02-27T21:03:12.004" || top.Last != "2024-03-04T08:15:42.641" {
+		t.Errorf("top event = %+v", top)
+	}
+	var providers []string
+	for _, provider := range summary.Providers {
+		providers = append(providers, provider.Provider)
+	}
+	if want := []string{"Microsoft-Windows-Kernel-Power", "EventLog", "Service Control Manager"}; !reflect.DeepEqual(providers, want) {
+		t.Errorf("providers = %q, want %q", providers, want)
+	}
+
+	if summarizeEvents([]EventGroup{{Title: "Empty"}}) != nil {
+		t.Error("summary of no events is not nil")
+	}
+}
@@ -0,0 +1,151 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReportSchemaVersion is the version of the JSON layout written by the JSON export. It is bumped
+// whenever a field is renamed or removed, or its meaning changes; new fields may be added without a bump.
+const ReportSchemaVersion = 1
+
+// CombinedReportFileName is the name of the JSON file combining every collector's report. It is
+// written by every batch run, whether or not the JSON export is on, so that runs can be compared.
+const CombinedReportFileName = "godiag_report.json"
+
+var (
+	exportMu   sync.RWMutex
+	exportJSON bool
+	appVersion string
+)
+
+// SetJSONExport turns the JSON export on or off. When it is on, every ReportCollector writes a
+// .json file next to its text report.
+func SetJSONExport(enabled bool) {
+	exportMu.Lock()
+	defer exportMu.Unlock()
+	exportJSON = enabled
+}
+
+// JSONExportEnabled reports whether the JSON export is turned on.
+func JSONExportEnabled() bool {
+	exportMu.RLock()
+	defer exportMu.RUnlock()
+	return exportJSON
+}
+
+// SetAppVersion records the GoDiag version to stamp into JSON reports.
+func SetAppVersion(version string) {
+	exportMu.Lock()
+	defer exportMu.Unlock()
+	appVersion = version
+}
+
+// AppVersion returns the GoDiag version set by SetAppVersion.
+func AppVersion() string {
+	exportMu.RLock()
+	defer exportMu.RUnlock()
+	return appVersion
+}
+
+// JSONReport is the layout of the .json file written for a single collector.
+type JSONReport struct {
+	SchemaVersion int       `json:"schema_version"`
+	GoDiagVersion string    `json:"godiag_version,omitempty"`
+	Collector     string    `json:"collector"` // Collector ID, e.g. "network"
+	Name          string    `json:"name"`
+	Generated     time.Time `json:"generated"`
+	Interrupted   string    `json:"interrupted,omitempty"` // "timed_out" or "cancelled" if collection was cut short
+	Data          Report    `json:"data"`
+}
+
+// CombinedReport is the layout of godiag_report.json: the outcome of every collector in a run
+// together with the structured data of those that produced any.
+type CombinedReport struct {
+	SchemaVersion int             `json:"schema_version"`
+	GoDiagVersion string          `json:"godiag_version,omitempty"`
+	HostName      string          `json:"host_name"`
+	Started       time.Time       `json:"started"`
+	Finished      time.Time       `json:"finished"`
+	Sections      []ReportSection `json:"sections"`
+}
+
+// ReportSection is one collector's entry in godiag_report.json. Data is omitted for collectors
+// that only write files (such as msinfo32) and for those that failed before producing a report.
+type ReportSection struct {
+	Collector string          `json:"collector"`
+	Name      string          `json:"name"`
+	Status    CollectorStatus `json:"status"`
+	Error     string          `json:"error,omitempty"`
+	Files     []string        `json:"files"`
+	Data      Report          `json:"data,omitempty"`
+}
+
+// JSONFileName returns the name of the .json file written for c, derived from its text report:
+// Driver_Report.txt becomes Driver_Report.json.
+func JSONFileName(c Collector) string {
+	name := c.OutputFiles()[0]
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".json"
+}
+
+// writeJSONReport writes report as the .json file of collector c, noting when ctx stopped collection early.
+func writeJSONReport(ctx context.Context, outputDir string, c Collector, report Report) error {
+	interrupted := ""
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		interrupted = "timed_out"
+	case context.Canceled:
+		interrupted = "cancelled"
+	}
+	return writeJSONFile(filepath.Join(outputDir, JSONFileName(c)), JSONReport{
+		SchemaVersion: ReportSchemaVersion,
+		GoDiagVersion: AppVersion(),
+		Collector:     c.ID(),
+		Name:          c.Name(),
+		Generated:     time.Now(),
+		Interrupted:   interrupted,
+		Data:          report,
+	})
+}
+
+// writeCombinedReport writes godiag_report.json for a finished batch run.
+func writeCombinedReport(outputDir string, manifest *RunManifest) error {
+	hostName, _ := os.Hostname()
+	combined := CombinedReport{
+		SchemaVersion: ReportSchemaVersion,
+		GoDiagVersion: AppVersion(),
+		HostName:      hostName,
+		Started:       manifest.Started,
+		Finished:      manifest.Finished,
+		Sections:      make([]ReportSection, 0, len(manifest.Results)),
+	}
+	for _, result := range manifest.Results {
+		combined.Sections = append(combined.Sections, ReportSection{
+			Collector: result.ID,
+			Name:      result.Name,
+			Status:    result.Status,
+			Error:     result.Error,
+			Files:     result.Files,
+			Data:      result.Report,
+		})
+	}
+
+	if err := writeJSONFile(filepath.Join(outputDir, CombinedReportFileName), combined); err != nil {
+		return fmt.Errorf("failed to write combined report: %w", err)
+	}
+	return nil
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,468 @@
+package modules
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Files written by RunCollectors listing what the findings rules turned up.
+const (
+	FindingsFileName     = "Findings.txt"
+	FindingsJSONFileName = "Findings.json"
+)
+
+// FindingRulesFileName is the name of the user's rules file, which adds to and overrides the
+// built-in rules.
+const FindingRulesFileName = "findings_rules.json"
+
+//go:embed rules/findings.json
+var builtinFindingRulesJSON []byte
+
+// Severity ranks a finding.
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityWarning  Severity = "warning"
+	SeverityInfo     Severity = "info"
+)
+
+// severityRanks orders severities from most to least serious.
+var severityRanks = map[Severity]int{SeverityCritical: 0, SeverityWarning: 1, SeverityInfo: 2}
+
+// Label returns the severity in upper case, as shown in Findings.txt.
+func (s Severity) Label() string {
+	return strings.ToUpper(string(s))
+}
+
+// FindingRule declares a problem to look for in a collector's report. The report is examined in
+// its JSON form, so fields are named as in the .json reports. Title, Detail and Evidence may
+// refer to fields of the matching entry as {field}, or {field:bytes} to format a byte count;
+// aggregated rules can use {count}.
+type FindingRule struct {
+	ID        string      `json:"id"`
+	Collector string      `json:"collector"`           // ID of the collector whose report is examined, e.g. "health"
+	Items     string      `json:"items,omitempty"`     // Dotted path to a list in the report, e.g. "volumes"; empty for the report itself
+	When      []Condition `json:"when,omitempty"`      // Every condition must hold for an entry to match
+	Aggregate bool        `json:"aggregate,omitempty"` // One finding for all matching entries rather than one each
+	Severity  Severity    `json:"severity"`
+	Title     string      `json:"title"`
+	Detail    string      `json:"detail,omitempty"`
+	Evidence  string      `json:"evidence,omitempty"` // Line listed for each matching entry
+	Advice    string      `json:"advice,omitempty"`
+}
+
+// Condition compares one field of an entry with Value. The operators are eq, ne, lt, le, gt,
+// ge, in and not_in (Value is a list), contains, empty, not_empty, and older_than_days, which
+// compares a YYYY-MM-DD date with today. Strings are compared without regard to case.
+type Condition struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+var conditionOps = map[string]bool{
+	"eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true, "in": true, "not_in": true,
+	"contains": true, "empty": true, "not_empty": true, "older_than_days": true,
+}
+
+// Finding is a problem found by a rule.
+type Finding struct {
+	Rule      string   `json:"rule"`
+	Severity  Severity `json:"severity"`
+	Collector string   `json:"collector"`
+	Title     string   `json:"title"`
+	Detail    string   `json:"detail,omitempty"`
+	Evidence  []string `json:"evidence,omitempty"`
+	Advice    string   `json:"advice,omitempty"`
+}
+
+// findingRulesFile is the layout of findings_rules.json and the built-in rules.
+type findingRulesFile struct {
+	Rules []FindingRule `json:"rules"`
+}
+
+// FindingsFile is the layout of Findings.json.
+type FindingsFile struct {
+	SchemaVersion int       `json:"schema_version"`
+	GoDiagVersion string    `json:"godiag_version,omitempty"`
+	Generated     time.Time `json:"generated"`
+	Findings      []Finding `json:"findings"`
+}
+
+// Validate checks that the rule can be evaluated.
+func (r FindingRule) Validate() error {
+	switch {
+	case r.ID == "":
+		return fmt.Errorf("finding rule %q has no id", r.Title)
+	case r.Collector == "":
+		return fmt.Errorf("finding rule %q has no collector", r.ID)
+	case r.Title == "":
+		return fmt.Errorf("finding rule %q has no title", r.ID)
+	}
+	if _, ok := severityRanks[r.Severity]; !ok {
+		return fmt.Errorf("finding rule %q has unknown severity %q", r.ID, r.Severity)
+	}
+	for _, condition := range r.When {
+		if condition.Field == "" {
+			return fmt.Errorf("finding rule %q has a condition without a field", r.ID)
+		}
+		if !conditionOps[condition.Op] {
+			return fmt.Errorf("finding rule %q has unknown operator %q", r.ID, condition.Op)
+		}
+	}
+	return nil
+}
+
+// ParseFindingRules parses a rules file and validates every rule in it.
+func ParseFindingRules(data []byte) ([]FindingRule, error) {
+	var file findingRulesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse finding rules: %w", err)
+	}
+	for _, rule := range file.Rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return file.Rules, nil
+}
+
+// LoadFindingRulesFile reads a user rules file. A missing file is not an error and yields no rules.
+func LoadFindingRulesFile(path string) ([]FindingRule, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rules, err := ParseFindingRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// builtinFindingRules is parsed once at startup; a broken embedded file is a bug in GoDiag itself.
+var builtinFindingRules = func() []FindingRule {
+	rules, err := ParseFindingRules(builtinFindingRulesJSON)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}()
+
+var (
+	userFindingRulesMu sync.RWMutex
+	userFindingRules   []FindingRule
+)
+
+// SetUserFindingRules sets the rules added by the user. A rule with the ID of a built-in one
+// replaces it.
+func SetUserFindingRules(rules []FindingRule) {
+	userFindingRulesMu.Lock()
+	defer userFindingRulesMu.Unlock()
+	userFindingRules = append([]FindingRule(nil), rules...)
+}
+
+// FindingRules returns the built-in rules, with those the user replaced swapped out, followed
+// by the user's new rules.
+func FindingRules() []FindingRule {
+	userFindingRulesMu.RLock()
+	defer userFindingRulesMu.RUnlock()
+
+	byID := make(map[string]FindingRule)
+	for _, rule := range userFindingRules {
+		byID[rule.ID] = rule
+	}
+	var rules []FindingRule
+	for _, rule := range builtinFindingRules {
+		if replacement, ok := byID[rule.ID]; ok {
+			rule = replacement
+			delete(byID, rule.ID)
+		}
+		rules = append(rules, rule)
+	}
+	for _, rule := range userFindingRules {
+		if _, ok := byID[rule.ID]; ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// EvaluateFindings runs the finding rules over the reports of a run and returns what they
+// found, most severe first.
+func EvaluateFindings(results []CollectorResult) []Finding {
+	reports := make(map[string]interface{})
+	for _, result := range results {
+		if result.Report == nil {
+			continue
+		}
+		// Round-trip through JSON so rules see the same field names as the .json reports
+		data, err := json.Marshal(result.Report)
+		if err != nil {
+			continue
+		}
+		var report interface{}
+		if json.Unmarshal(data, &report) == nil {
+			reports[result.ID] = report
+		}
+	}
+
+	findings := []Finding{}
+	for _, rule := range FindingRules() {
+		if report, ok := reports[rule.Collector]; ok {
+			findings = append(findings, rule.evaluate(report)...)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRanks[findings[i].Severity] < severityRanks[findings[j].Severity]
+	})
+	return findings
+}
+
+// evaluate applies the rule to one report.
+func (r FindingRule) evaluate(report interface{}) []Finding {
+	var entries []interface{}
+	if r.Items == "" {
+		entries = []interface{}{report}
+	} else if list, ok := lookupField(report, r.Items).([]interface{}); ok {
+		entries = list
+	}
+
+	var matches []interface{}
+	for _, entry := range entries {
+		if r.matches(entry) {
+			matches = append(matches, entry)
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	if r.Aggregate {
+		count := map[string]interface{}{"count": len(matches)}
+		finding := r.finding(count)
+		if r.Evidence != "" {
+			for _, entry := range matches {
+				finding.Evidence = append(finding.Evidence, expandFields(r.Evidence, entry))
+			}
+		}
+		return []Finding{finding}
+	}
+	findings := make([]Finding, 0, len(matches))
+	for _, entry := range matches {
+		finding := r.finding(entry)
+		if r.Evidence != "" {
+			finding.Evidence = []string{expandFields(r.Evidence, entry)}
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// finding fills in the rule's texts from entry.
+func (r FindingRule) finding(entry interface{}) Finding {
+	return Finding{
+		Rule:      r.ID,
+		Severity:  r.Severity,
+		Collector: r.Collector,
+		Title:     expandFields(r.Title, entry),
+		Detail:    expandFields(r.Detail, entry),
+		Advice:    r.Advice,
+	}
+}
+
+// matches reports whether every condition of the rule holds for entry.
+func (r FindingRule) matches(entry interface{}) bool {
+	for _, condition := range r.When {
+		if !condition.holds(lookupField(entry, condition.Field)) {
+			return false
+		}
+	}
+	return true
+}
+
+// holds reports whether the condition is true of value, the field's value or nil if it is missing.
+func (c Condition) holds(value interface{}) bool {
+	text := fieldText(value)
+	switch c.Op {
+	case "empty":
+		return text == ""
+	case "not_empty":
+		return text != ""
+	case "eq":
+		return compareValues(value, c.Value) == 0
+	case "ne":
+		return compareValues(value, c.Value) != 0
+	case "lt", "le", "gt", "ge":
+		x, ok1 := fieldNumber(value)
+		y, ok2 := fieldNumber(c.Value)
+		if !ok1 || !ok2 {
+			return false
+		}
+		switch c.Op {
+		case "lt":
+			return x < y
+		case "le":
+			return x <= y
+		case "gt":
+			return x > y
+		}
+		return x >= y
+	case "in", "not_in":
+		found := false
+		if list, ok := c.Value.([]interface{}); ok {
+			for _, candidate := range list {
+				if compareValues(value, candidate) == 0 {
+					found = true
+					break
+				}
+			}
+		}
+		return found == (c.Op == "in")
+	case "contains":
+		return strings.Contains(strings.ToLower(text), strings.ToLower(fieldText(c.Value)))
+	case "older_than_days":
+		days, ok := fieldNumber(c.Value)
+		if len(text) < 10 || !ok {
+			return false
+		}
+		date, err := time.ParseInLocation("2006-01-02", text[:10], time.Local)
+		return err == nil && time.Since(date) > time.Duration(days*24)*time.Hour
+	}
+	return false
+}
+
+// compareValues returns 0 when a and b are equal: numerically when both are numbers, otherwise
+// as strings without regard to case.
+func compareValues(a, b interface{}) int {
+	if x, ok := fieldNumber(a); ok {
+		if y, ok := fieldNumber(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if strings.EqualFold(fieldText(a), fieldText(b)) {
+		return 0
+	}
+	return 1
+}
+
+// lookupField follows a dotted path such as "volumes" or "summary.total" through decoded JSON.
+func lookupField(value interface{}, path string) interface{} {
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+// fieldText formats a decoded JSON value for comparisons and messages.
+func fieldText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// fieldNumber returns a decoded JSON value as a number, accepting numeric strings.
+func fieldNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+var fieldPlaceholder = regexp.MustCompile(`\{([A-Za-z0-9_.]+)(?::(\w+))?\}`)
+
+// expandFields replaces {field} and {field:bytes} placeholders in text with values from entry.
+func expandFields(text string, entry interface{}) string {
+	return fieldPlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+		parts := fieldPlaceholder.FindStringSubmatch(placeholder)
+		value := lookupField(entry, parts[1])
+		if parts[2] == "bytes" {
+			if n, ok := fieldNumber(value); ok {
+				return formatBytes(int64(n))
+			}
+		}
+		return fieldText(value)
+	})
+}
+
+// writeFindings writes Findings.txt and Findings.json for a finished batch run.
+func writeFindings(outputDir string, findings []Finding) error {
+	var output bytes.Buffer
+	writeFindingsText(&output, findings)
+	output.WriteString(reportFooter)
+	if err := os.WriteFile(filepath.Join(outputDir, FindingsFileName), output.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write findings: %w", err)
+	}
+
+	err := writeJSONFile(filepath.Join(outputDir, FindingsJSONFileName), FindingsFile{
+		SchemaVersion: ReportSchemaVersion,
+		GoDiagVersion: AppVersion(),
+		Generated:     time.Now(),
+		Findings:      findings,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write findings: %w", err)
+	}
+	return nil
+}
+
+// writeFindingsText renders findings in GoDiag's plain-text layout.
+func writeFindingsText(output *bytes.Buffer, findings []Finding) {
+	writeSection(output, "Findings", nil, func() {
+		if len(findings) == 0 {
+			output.WriteString("No problems found.\n")
+			return
+		}
+		for _, finding := range findings {
+			output.WriteString(fmt.Sprintf("[%s] %s\n", finding.Severity.Label(), finding.Title))
+			if finding.Detail != "" {
+				output.WriteString(finding.Detail + "\n")
+			}
+			for _, evidence := range finding.Evidence {
+				output.WriteString("  - " + evidence + "\n")
+			}
+			if finding.Advice != "" {
+				output.WriteString("What to do: " + finding.Advice + "\n")
+			}
+			output.WriteString("\n")
+		}
+	})
+}
@@ -0,0 +1,230 @@
+package modules
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuiltinFindingRulesValidate(t *testing.T) {
+	rules := FindingRules()
+	if len(rules) == 0 {
+		t.Fatal("no built-in finding rules")
+	}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			t.Errorf("rule %s: %v", rule.ID, err)
+		}
+	}
+}
+
+func TestDefenderThreatFindings(t *testing.T) {
+	var report interface{}
+	err := json.Unmarshal([]byte(`{"threat_detections": [
+		{"threat_id": "2147735505", "initial_detection_time": "3/2/2024 9:12:44 AM", "resources": "file:_C:\\Users\\jdoe\\Downloads\\setup.exe", "action_success": "False"},
+		{"threat_id": "2147722646", "initial_detection_time": "3/3/2024 4:01:10 PM", "resources": "file:_C:\\Temp\\tool.zip", "action_success": "True"},
+		{"threat_id": "2147680291", "initial_detection_time": "3/4/2024 7:30:02 AM", "resources": "file:_C:\\Temp\\pending.js", "action_success": ""},
+		{"threat_id": "2147519003", "initial_detection_time": "3/4/2024 7:31:15 AM", "resources": "file:_C:\\Temp\\queued.vbs"}
+	]}`), &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		rule     string
+		evidence []string
+	}{
+		// Detections Defender has not reported an outcome for yet are still unresolved
+		{"defender-threat-unresolved", []string{
+			`Threat 2147735505 at 3/2/2024 9:12:44 AM: file:_C:\Users\jdoe\Downloads\setup.exe`,
+			`Threat 2147680291 at 3/4/2024 7:30:02 AM: file:_C:\Temp\pending.js`,
+			`Threat 2147519003 at 3/4/2024 7:31:15 AM: file:_C:\Temp\queued.vbs`,
+		}},
+		{"defender-threat-detected", []string{`Threat 2147722646 at 3/3/2024 4:01:10 PM: file:_C:\Temp\tool.zip`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, ok := findingRule(tt.rule)
+			if !ok {
+				t.Fatalf("rule %s is not built in", tt.rule)
+			}
+			findings := rule.evaluate(report)
+			if len(findings) != 1 {
+				t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+			}
+			if !reflect.DeepEqual(findings[0].Evidence, tt.evidence) {
+				t.Errorf("evidence = %q\nwant %q", findings[0].Evidence, tt.evidence)
+			}
+		})
+	}
+}
+
+func findingRule(id string) (FindingRule, bool) {
+	for _, rule := range FindingRules() {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return FindingRule{}, false
+}
+
+func TestConditionHolds(t *testing.T) {
+	old := time.Now().AddDate(-4, 0, 0).Format("2006-01-02")
+	recent := time.Now().AddDate(-1, 0, 0).Format("2006-01-02")
+	list := []interface{}{"BITS", "sppsvc"}
+
+	tests := []struct {
+		op    string
+		value interface{} // The rule's value
+		field interface{} // The entry's field as decoded from JSON; nil when it is missing
+		want  bool
+	}{
+		{"eq", "Stopped", "stopped", true},
+		{"eq", 5.0, "5", true},
+		{"eq", "True", nil, false},
+		{"ne", "True", "False", true},
+		{"ne", "True", "true", false},
+		{"ne", "True", "", true},
+		{"ne", "True", nil, true},
+		{"lt", 5.0, 4.0, true},
+		{"lt", 5.0, 5.0, false},
+		{"lt", 5.0, "4", true},
+		{"lt", 5.0, nil, false},
+		{"lt", 5.0, "low", false},
+		{"le", 5.0, 5.0, true},
+		{"gt", 0.0, 1.0, true},
+		{"gt", 0.0, 0.0, false},
+		{"ge", 40.0, 40.0, true},
+		{"ge", 40.0, 39.0, false},
+		{"in", list, "bits", true},
+		{"in", list, "Spooler", false},
+		{"in", "BITS", "BITS", false}, // Not a list
+		{"not_in", list, "Spooler", true},
+		{"not_in", list, "sppsvc", false},
+		{"not_in", list, nil, true},
+		{"contains", "fail", "Pred Fail", true},
+		{"contains", "fail", "OK", false},
+		{"empty", nil, "", true},
+		{"empty", nil, nil, true},
+		{"empty", nil, 0.0, false},
+		{"not_empty", nil, "OK", true},
+		{"older_than_days", 1095.0, old, true},
+		{"older_than_days", 1095.0, old + "T00:00:00", true},
+		{"older_than_days", 1095.0, recent, false},
+		{"older_than_days", 1095.0, "03/04/2019", false},
+		{"older_than_days", 1095.0, nil, false},
+		{"older_than_days", "soon", old, false},
+		{"matches", "x", "x", false}, // Unknown operator
+	}
+	for _, tt := range tests {
+		condition := Condition{Field: "field", Op: tt.op, Value: tt.value}
+		if got := condition.holds(tt.field); got != tt.want {
+			t.Errorf("%s %v holds for %#v = %v, want %v", tt.op, tt.value, tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestExpandFields(t *testing.T) {
+	var entry interface{}
+	err := json.Unmarshal([]byte(`{"name": "C:", "free_bytes": 1610612736, "free_percent": 4.5, "summary": {"total": 3}}`), &entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text, want string
+	}{
+		{"{name} has {free_bytes:bytes} free ({free_percent}%)", "C: has 1.5 GiB free (4.5%)"},
+		{"{free_bytes} bytes", "1610612736 bytes"},
+		{"{summary.total} in total", "3 in total"},
+		{"{name:bytes}", "C:"},
+		{"[{missing}]", "[]"},
+		{"{not a placeholder}", "{not a placeholder}"},
+	}
+	for _, tt := range tests {
+		if got := expandFields(tt.text, entry); got != tt.want {
+			t.Errorf("expandFields(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBuiltinFindings(t *testing.T) {
+	const gib = 1 << 30
+	tests := []struct {
+		name   string
+		result CollectorResult
+		want   []string // "rule: title" of each finding, most severe first
+	}{
+		{"disk space", CollectorResult{ID: "health", Report: &HealthReport{Volumes: []VolumeInfo{
+			{Name: "C:", SizeBytes: 100 * gib, FreeBytes: 3 * gib, FreePercent: 3},
+			{Name: "D:", SizeBytes: 100 * gib, FreeBytes: 10 * gib, FreePercent: 10},
+			{Name: "E:", SizeBytes: 100 * gib, FreeBytes: 50 * gib, FreePercent: 50},
+			{Name: "F:"}, // A card reader without a card
+		}}}, []string{
+			"disk-space-critical: Almost no free space on C:",
+			"disk-space-low: Low free space on D:",
+		}},
+		{"SMART status", CollectorResult{ID: "health", Report: &HealthReport{Drives: []DriveInfo{
+			{Model: "Samsung SSD 980", Status: "OK"},
+			{Model: "ST2000DM008", Status: "Pred Fail"},
+			{Model: "USB Flash Disk"}, // No SMART support
+		}}}, []string{
+			`drive-smart-status: Drive ST2000DM008 reports status "Pred Fail"`,
+		}},
+		{"battery wear", CollectorResult{ID: "hardware", Report: &HardwareReport{Batteries: []Battery{
+			{DesignCapacity: 50000, FullChargeCapacity: 27500, WearPercent: 45},
+			{DesignCapacity: 50000, FullChargeCapacity: 37500, WearPercent: 25},
+			{DesignCapacity: 50000, FullChargeCapacity: 47500, WearPercent: 5},
+			{}, // Capacities not reported
+		}}}, []string{
+			"battery-worn-out: Battery has lost 45% of its capacity",
+			"battery-worn: Battery has lost 25% of its capacity",
+		}},
+		{"stopped services", CollectorResult{ID: "software", Report: &SoftwareReport{Services: []ServiceEntry{
+			{Name: "Spooler", DisplayName: "Print Spooler", State: "Stopped", StartMode: "Auto"},
+			{Name: "BITS", DisplayName: "Background Intelligent Transfer Service", State: "Stopped", StartMode: "Auto"},
+			{Name: "Dnscache", DisplayName: "DNS Client", State: "Running", StartMode: "Auto"},
+			{Name: "Fax", DisplayName: "Fax", State: "Stopped", StartMode: "Manual"},
+			{Name: "ssh.service", DisplayName: "OpenBSD Secure Shell server", State: "failed", StartMode: "enabled"},
+		}}}, []string{
+			"services-auto-stopped: Automatic services not running (1)",
+			"services-failed: Failed systemd services (1)",
+		}},
+		{"stale BIOS", CollectorResult{ID: "bios", Report: &BIOSReport{BIOS: []BIOSInfo{
+			{Manufacturer: "Dell Inc.", Version: "1.2.0", ReleaseDate: time.Now().AddDate(-4, 0, 0).Format("2006-01-02")},
+		}}}, []string{
+			"bios-outdated: BIOS/UEFI firmware is more than three years old",
+		}},
+		{"current BIOS", CollectorResult{ID: "bios", Report: &BIOSReport{BIOS: []BIOSInfo{
+			{Manufacturer: "Dell Inc.", Version: "1.24.0", ReleaseDate: time.Now().AddDate(0, -2, 0).Format("2006-01-02")},
+		}}}, nil},
+		{"no report", CollectorResult{ID: "health"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, finding := range EvaluateFindings([]CollectorResult{tt.result}) {
+				got = append(got, finding.Rule+": "+finding.Title)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuiltinFindingDetails(t *testing.T) {
+	findings := EvaluateFindings([]CollectorResult{
+		{ID: "health", Report: &HealthReport{Volumes: []VolumeInfo{{Name: "C:", SizeBytes: 100 << 30, FreeBytes: 3 << 30, FreePercent: 3}}}},
+		{ID: "software", Report: &SoftwareReport{Services: []ServiceEntry{{Name: "Spooler", DisplayName: "Print Spooler", State: "Stopped", StartMode: "Auto"}}}},
+	})
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want 2: %+v", len(findings), findings)
+	}
+	if want := "C: has 3.0 GiB free of 100.0 GiB (3%)."; findings[0].Detail != want {
+		t.Errorf("disk space detail = %q, want %q", findings[0].Detail, want)
+	}
+	if want := []string{"Print Spooler (Spooler)"}; !reflect.DeepEqual(findings[1].Evidence, want) {
+		t.Errorf("stopped services evidence = %q, want %q", findings[1].Evidence, want)
+	}
+}
@@ -0,0 +1,202 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// replayDir holds command output captured with RecordingRunner on a Windows 11 machine.
+const replayDir = "testdata/replay"
+
+// placeholderRunner rewrites the temporary directories a test writes to into fixed placeholders
+// before looking a command up, so captured command lines match whatever directory a test uses.
+type placeholderRunner struct {
+	runner CommandRunner
+	dirs   map[string]string
+}
+
+func (r placeholderRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return r.runner.Output(ctx, name, r.rewrite(args)...)
+}
+
+func (r placeholderRunner) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return r.runner.CombinedOutput(ctx, name, r.rewrite(args)...)
+}
+
+// rewrite replaces the directories in args, writing the paths below them with backslashes.
+func (r placeholderRunner) rewrite(args []string) []string {
+	rewritten := make([]string, len(args))
+	for i, arg := range args {
+		rewritten[i] = withPlaceholders(arg, r.dirs)
+		if rewritten[i] != arg {
+			rewritten[i] = strings.ReplaceAll(rewritten[i], string(filepath.Separator), `\`)
+		}
+	}
+	return rewritten
+}
+
+// withPlaceholders replaces each directory in dirs with its placeholder in s, writing the
+// separator that follows it as a backslash.
+func withPlaceholders(s string, dirs map[string]string) string {
+	for dir, placeholder := range dirs {
+		s = strings.ReplaceAll(s, dir+string(filepath.Separator), placeholder+`\`)
+		s = strings.ReplaceAll(s, dir, placeholder)
+	}
+	return s
+}
+
+// windowsCollectors run the Windows implementation of each report collector, whichever platform
+// the tests run on.
+var windowsCollectors = map[string]func(ctx context.Context, outputDir string) (Report, error){
+	"bios":      collectFunc(collectWindowsBIOSReport),
+	"drivers":   collectFunc(collectWindowsDriverReport),
+	"eventlogs": collectFunc(collectWindowsEventLogs),
+	"hardware":  collectFunc(collectWindowsHardwareReport),
+	"health":    collectFunc(collectWindowsHealthAndUsageReport),
+	"network":   collectFunc(collectWindowsNetworkReport),
+	"processes": collectFunc(collectWindowsRunningProcessesReport),
+	"registry":  registryCollector.collect,
+	"security":  securityCollector.collect,
+	"software":  collectFunc(collectWindowsSoftwareReport),
+	"startup":   collectFunc(collectWindowsStartupProgramsReport),
+	"sysinfo":   collectFunc(collectWindowsQuickSysInfo),
+}
+
+// windowsSections gives the reports the section titles they have on Windows for the rest of the
+// test.
+func windowsSections(t *testing.T) {
+	titles := map[*string]string{
+		&sectionIPConfig:    windowsSectionIPConfig,
+		&sectionConnections: windowsSectionConnections,
+		&sectionRoutes:      windowsSectionRoutes,
+		&sectionDNSCache:    windowsSectionDNSCache,
+		&sectionPing:        windowsSectionPing,
+		&sectionServices:    windowsSectionServices,
+	}
+	for title, windows := range titles {
+		saved := *title
+		t.Cleanup(func() { *title = saved })
+		*title = windows
+	}
+}
+
+// replay makes the package's commands and WMI queries answer from the capture set in replayDir
+// for the rest of the test.
+func replay(t *testing.T, dirs map[string]string) *ReplayRunner {
+	t.Helper()
+	r := NewReplayRunner(replayDir)
+	SetCommandRunner(placeholderRunner{runner: r, dirs: dirs})
+	SetWMIBackend(nil)
+	t.Cleanup(func() {
+		SetCommandRunner(nil)
+		SetWMIBackend(nil)
+	})
+	return r
+}
+
+// checkGolden compares got with testdata/golden/<name>.txt, or rewrites that file with -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".txt")
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("report differs from %s (run go test -update to accept it):\n%s", path, got)
+	}
+}
+
+func TestReportGolden(t *testing.T) {
+	// Event times are shown in the local time zone
+	defer func(local *time.Location) { time.Local = local }(time.Local)
+	time.Local = time.UTC
+	windowsSections(t)
+
+	for _, id := range []string{"bios", "drivers", "eventlogs", "hardware", "health", "network", "processes", "registry", "security", "software", "startup", "sysinfo"} {
+		t.Run(id, func(t *testing.T) {
+			outputDir := t.TempDir()
+			dirs := map[string]string{outputDir: "$OUTPUT"}
+			if id == "startup" {
+				appData, programData := startupFolders(t)
+				dirs[appData], dirs[programData] = "$APPDATA", "$PROGRAMDATA"
+			}
+			replay(t, dirs)
+
+			report, err := windowsCollectors[id](context.Background(), outputDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var output bytes.Buffer
+			report.WriteText(&output)
+			checkGolden(t, id, withPlaceholders(output.String(), dirs))
+		})
+	}
+}
+
+// startupFolders points APPDATA at a temporary directory holding a per-user startup folder with
+// one shortcut in it, and PROGRAMDATA at a directory that does not exist.
+func startupFolders(t *testing.T) (appData, programData string) {
+	t.Helper()
+	appData, programData = t.TempDir(), filepath.Join(t.TempDir(), "ProgramData")
+	t.Setenv("APPDATA", appData)
+	t.Setenv("PROGRAMDATA", programData)
+
+	folder := filepath.Join(appData, "Microsoft\\Windows\\Start Menu\\Programs\\Startup")
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	shortcut := filepath.Join(folder, "Send to OneNote.lnk")
+	if err := os.WriteFile(shortcut, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2024, 3, 2, 9, 15, 0, 0, time.Local)
+	if err := os.Chtimes(shortcut, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+	return appData, programData
+}
+
+// The tools behind these collectors write their own output files, so only the command lines
+// they run are checked.
+func TestToolCollectorCommands(t *testing.T) {
+	tests := []struct {
+		collector *toolCollector
+		command   string
+	}{
+		{dxdiagCollector, `dxdiag /t $OUTPUT\dxdiag.txt`},
+		{etlCollector, `wevtutil epl System $OUTPUT\event_trace_log.evtx`},
+		{msinfo32Collector, `msinfo32 /nfo $OUTPUT\msinfo32.nfo`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.collector.ID(), func(t *testing.T) {
+			outputDir := t.TempDir()
+			r := replay(t, map[string]string{outputDir: "$OUTPUT"})
+
+			if err := tt.collector.Run(context.Background(), outputDir); err != nil {
+				t.Fatal(err)
+			}
+			calls := r.Calls()
+			if len(calls) != 1 {
+				t.Fatalf("ran %d commands, want 1: %q", len(calls), calls)
+			}
+			if got := strings.Join(calls[0], " "); got != tt.command {
+				t.Errorf("ran %q, want %q", got, tt.command)
+			}
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryFileName is the name of the index of past runs kept at the top of the output directory.
+const HistoryFileName = "Run_History.json"
+
+// runDirLayout names the folder each run is written to, so that folders sort by age.
+const runDirLayout = "2006-01-02_150405"
+
+// RunOutcome sums up how a run went as a whole.
+type RunOutcome string
+
+const (
+	OutcomeSuccess   RunOutcome = "success"   // Every collector succeeded
+	OutcomePartial   RunOutcome = "partial"   // Some collectors succeeded and some did not
+	OutcomeFailed    RunOutcome = "failed"    // No collector succeeded
+	OutcomeCancelled RunOutcome = "cancelled" // The run was stopped before every collector had run
+)
+
+// RunRecord is the entry of a past run in the history index.
+type RunRecord struct {
+	ID            string     `json:"id"` // Name of the run's folder in the output directory
+	Started       time.Time  `json:"started"`
+	Finished      time.Time  `json:"finished"`
+	GoDiagVersion string     `json:"godiag_version,omitempty"`
+	HostName      string     `json:"host_name"`
+	Collectors    []string   `json:"collectors"` // IDs of the collectors that were run
+	Outcome       RunOutcome `json:"outcome"`
+	Succeeded     int        `json:"succeeded"`
+	Failed        int        `json:"failed"`
+	TimedOut      int        `json:"timed_out"`
+	Skipped       int        `json:"skipped"`
+	Findings      int        `json:"findings"`
+}
+
+// runHistoryFile is the layout of Run_History.json.
+type runHistoryFile struct {
+	SchemaVersion int         `json:"schema_version"`
+	Runs          []RunRecord `json:"runs"`
+}
+
+// RetentionPolicy limits how many past runs are kept. Zero means no limit.
+type RetentionPolicy struct {
+	KeepRuns int `json:"keep_runs"` // Keep only the most recent runs
+	KeepDays int `json:"keep_days"` // Delete runs older than this many days
+}
+
+// DefaultRetentionPolicy keeps the last 10 runs.
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{KeepRuns: 10}
+}
+
+// Validate checks that the limits are not negative.
+func (p RetentionPolicy) Validate() error {
+	if p.KeepRuns < 0 || p.KeepDays < 0 {
+		return fmt.Errorf("invalid retention policy: limits cannot be negative")
+	}
+	return nil
+}
+
+// String describes the policy for the UI, e.g. "last 10 runs, at most 30 days".
+func (p RetentionPolicy) String() string {
+	var limits []string
+	if p.KeepRuns > 0 {
+		limits = append(limits, fmt.Sprintf("last %d runs", p.KeepRuns))
+	}
+	if p.KeepDays > 0 {
+		limits = append(limits, fmt.Sprintf("at most %d days", p.KeepDays))
+	}
+	if len(limits) == 0 {
+		return "every run"
+	}
+	return strings.Join(limits, ", ")
+}
+
+var (
+	historyMu sync.Mutex // Guards Run_History.json and the run folders it lists
+	retention = DefaultRetentionPolicy()
+)
+
+// SetRetentionPolicy sets how many runs RecordRun keeps.
+func SetRetentionPolicy(policy RetentionPolicy) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	retention = policy
+}
+
+// GetRetentionPolicy returns the policy RecordRun applies.
+func GetRetentionPolicy() RetentionPolicy {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	return retention
+}
+
+// NewRunDir creates a timestamped folder for a new run in root, the output directory, and returns
+// its path.
+func NewRunDir(root string) (string, error) {
+	name := time.Now().Format(runDirLayout)
+	for n := 2; ; n++ {
+		dir := filepath.Join(root, name)
+		err := os.Mkdir(dir, os.ModePerm)
+		if err == nil {
+			return dir, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("failed to create run folder: %w", err)
+		}
+		// Two runs started within the same second
+		name = fmt.Sprintf("%s_%d", time.Now().Format(runDirLayout), n)
+	}
+}
+
+// RecordRun adds the run described by manifest, whose output directory must be a folder created
+// by NewRunDir in root, to the history index and then deletes the runs the retention policy no
+// longer keeps. It returns the runs deleted.
+func RecordRun(root string, manifest *RunManifest) ([]RunRecord, error) {
+	if filepath.Clean(filepath.Dir(manifest.OutputDir)) != filepath.Clean(root) {
+		return nil, fmt.Errorf("run folder %s is not in %s", manifest.OutputDir, root)
+	}
+	hostName, _ := os.Hostname()
+	record := RunRecord{
+		ID:            filepath.Base(manifest.OutputDir),
+		Started:       manifest.Started,
+		Finished:      manifest.Finished,
+		GoDiagVersion: AppVersion(),
+		HostName:      hostName,
+		Collectors:    []string{},
+		Succeeded:     manifest.Count(StatusSuccess),
+		Failed:        manifest.Count(StatusFailed),
+		TimedOut:      manifest.Count(StatusTimedOut),
+		Skipped:       manifest.Count(StatusSkipped),
+		Findings:      len(manifest.Findings),
+	}
+	for _, result := range manifest.Results {
+		record.Collectors = append(record.Collectors, result.ID)
+	}
+	switch {
+	case manifest.Cancelled:
+		record.Outcome = OutcomeCancelled
+	case record.Succeeded == len(manifest.Results):
+		record.Outcome = OutcomeSuccess
+	case record.Succeeded == 0:
+		record.Outcome = OutcomeFailed
+	default:
+		record.Outcome = OutcomePartial
+	}
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	runs, err := readHistory(root)
+	if err != nil {
+		return nil, err
+	}
+	runs = append(removeRun(runs, record.ID), record)
+	runs, removed := applyRetention(root, runs, retention, record.ID, time.Now())
+	if err := writeHistory(root, runs); err != nil {
+		return removed, err
+	}
+	return removed, nil
+}
+
+// RunSession gathers collectors run one at a time, such as from the GUI's buttons, into one run
+// folder and history entry, so that the bundle of the latest run holds every report of the session.
+type RunSession struct {
+	mu       sync.Mutex
+	manifest *RunManifest
+}
+
+// Dir returns the session's run folder in root. The folder is created by the first call, and
+// again when it has since been deleted or root has changed.
+func (s *RunSession) Dir(root string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.manifest != nil && filepath.Clean(filepath.Dir(s.manifest.OutputDir)) == filepath.Clean(root) {
+		if info, err := os.Stat(s.manifest.OutputDir); err == nil && info.IsDir() {
+			return s.manifest.OutputDir, nil
+		}
+	}
+	dir, err := NewRunDir(root)
+	if err != nil {
+		return "", err
+	}
+	s.manifest = &RunManifest{Started: time.Now(), OutputDir: dir}
+	return dir, nil
+}
+
+// Record adds result, collected into the folder Dir returned, to the session's run, replacing an
+// earlier result of the same collector, and records the run in root's history. It returns the
+// runs the retention policy deleted.
+func (s *RunSession) Record(root string, result CollectorResult) ([]RunRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.manifest == nil {
+		return nil, fmt.Errorf("no run folder to record %s in", result.ID)
+	}
+
+	results := s.manifest.Results[:0:0]
+	for _, earlier := range s.manifest.Results {
+		if earlier.ID != result.ID {
+			results = append(results, earlier)
+		}
+	}
+	s.manifest.Results = append(results, result)
+	s.manifest.Finished = time.Now()
+	// Only a session whose every collector was stopped counts as cancelled
+	s.manifest.Cancelled = s.manifest.Count(StatusSkipped) == len(s.manifest.Results)
+	return RecordRun(root, s.manifest)
+}
+
+// End closes the session, so the next collector run starts a new run folder.
+func (s *RunSession) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.manifest = nil
+}
+
+// LoadHistory returns the runs in root's history index, most recent first. Runs whose folder
+// has been deleted by hand are left out.
+func LoadHistory(root string) ([]RunRecord, error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	return readHistory(root)
+}
+
+// LatestRunDir returns the folder of the most recent run in root, or root itself when no run has
+// been recorded, as in output directories written before the history existed.
+func LatestRunDir(root string) (string, error) {
+	runs, err := LoadHistory(root)
+	if err != nil || len(runs) == 0 {
+		return root, err
+	}
+	return filepath.Join(root, runs[0].ID), nil
+}
+
+// RunDir returns the folder of the run with the given ID in root.
+func RunDir(root, id string) (string, error) {
+	if !validRunID(id) {
+		return "", fmt.Errorf("invalid run %q", id)
+	}
+	dir := filepath.Join(root, id)
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("run %q not found in %s", id, root)
+	}
+	return dir, nil
+}
+
+// DeleteRun deletes the folder of the run with the given ID and its entry in the history index.
+func DeleteRun(root, id string) error {
+	if !validRunID(id) {
+		return fmt.Errorf("invalid run %q", id)
+	}
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	runs, err := readHistory(root)
+	if err != nil {
+		return err
+	}
+	// Only folders GoDiag created itself are ever deleted
+	if len(removeRun(runs, id)) == len(runs) {
+		return fmt.Errorf("run %q not found in %s", id, root)
+	}
+	if err := os.RemoveAll(filepath.Join(root, id)); err != nil {
+		return fmt.Errorf("failed to delete run %s: %w", id, err)
+	}
+	return writeHistory(root, removeRun(runs, id))
+}
+
+// PruneHistory deletes the runs in root the retention policy no longer keeps and returns them.
+func PruneHistory(root string) ([]RunRecord, error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	runs, err := readHistory(root)
+	if err != nil {
+		return nil, err
+	}
+	runs, removed := applyRetention(root, runs, retention, "", time.Now())
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	return removed, writeHistory(root, runs)
+}
+
+// validRunID reports whether id names a folder directly inside the output directory, so that a
+// run ID can never reach outside it.
+func validRunID(id string) bool {
+	return id != "" && id != "." && id != ".." && !strings.ContainsAny(id, `/\:`)
+}
+
+// applyRetention deletes the folders of the runs policy does not keep, other than the run named
+// keep, and returns the runs kept and deleted. Runs that could not be deleted stay in the index.
+func applyRetention(root string, runs []RunRecord, policy RetentionPolicy, keep string, now time.Time) (kept, removed []RunRecord) {
+	sortRuns(runs)
+	for i, run := range runs {
+		expired := (policy.KeepRuns > 0 && i >= policy.KeepRuns) ||
+			(policy.KeepDays > 0 && now.Sub(run.Started) > time.Duration(policy.KeepDays)*24*time.Hour)
+		if expired && run.ID != keep && os.RemoveAll(filepath.Join(root, run.ID)) == nil {
+			removed = append(removed, run)
+			continue
+		}
+		kept = append(kept, run)
+	}
+	return kept, removed
+}
+
+// readHistory reads Run_History.json, leaving out runs whose folder no longer exists. A missing
+// index is an empty history.
+func readHistory(root string) ([]RunRecord, error) {
+	data, err := os.ReadFile(filepath.Join(root, HistoryFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file runHistoryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", HistoryFileName, err)
+	}
+
+	var runs []RunRecord
+	for _, run := range file.Runs {
+		if !validRunID(run.ID) {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(root, run.ID)); err == nil && info.IsDir() {
+			runs = append(runs, run)
+		}
+	}
+	sortRuns(runs)
+	return runs, nil
+}
+
+func writeHistory(root string, runs []RunRecord) error {
+	if runs == nil {
+		runs = []RunRecord{}
+	}
+	file := runHistoryFile{SchemaVersion: ReportSchemaVersion, Runs: runs}
+	if err := writeJSONFile(filepath.Join(root, HistoryFileName), file); err != nil {
+		return fmt.Errorf("failed to write %s: %w", HistoryFileName, err)
+	}
+	return nil
+}
+
+func removeRun(runs []RunRecord, id string) []RunRecord {
+	kept := runs[:0:0]
+	for _, run := range runs {
+		if run.ID != id {
+			kept = append(kept, run)
+		}
+	}
+	return kept
+}
+
+// sortRuns orders runs most recent first.
+func sortRuns(runs []RunRecord) {
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Started.After(runs[j].Started) })
+}
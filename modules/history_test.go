@@ -0,0 +1,89 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRunSession(t *testing.T) {
+	defer SetRetentionPolicy(GetRetentionPolicy())
+	SetRetentionPolicy(RetentionPolicy{KeepRuns: 1})
+	root := t.TempDir()
+	session := &RunSession{}
+
+	// More single reports than the retention policy keeps runs all land in one folder
+	var dir string
+	for i, id := range []string{"network", "drivers", "bios", "network"} {
+		got, err := session.Dir(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 && got != dir {
+			t.Fatalf("collector %d went into %s, want %s", i, got, dir)
+		}
+		dir = got
+		status := StatusSuccess
+		if i == 1 {
+			status = StatusFailed
+		}
+		if removed, err := session.Record(root, CollectorResult{ID: id, Status: status}); err != nil || len(removed) != 0 {
+			t.Fatalf("Record(%s) removed %+v, %v", id, removed, err)
+		}
+	}
+
+	runs, err := LoadHistory(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].ID != filepath.Base(dir) {
+		t.Fatalf("history = %+v, want the session's run only", runs)
+	}
+	// A collector run again replaces its earlier result
+	if want := []string{"drivers", "bios", "network"}; !reflect.DeepEqual(runs[0].Collectors, want) {
+		t.Errorf("collectors = %q, want %q", runs[0].Collectors, want)
+	}
+	if runs[0].Outcome != OutcomePartial || runs[0].Succeeded != 2 || runs[0].Failed != 1 {
+		t.Errorf("run = %+v, want 2 succeeded and 1 failed", runs[0])
+	}
+	if latest, err := LatestRunDir(root); err != nil || latest != dir {
+		t.Errorf("LatestRunDir() = %s, %v; want %s", latest, err, dir)
+	}
+
+	// A new session starts a new folder, and the retention policy then deletes the old one
+	session.End()
+	next, err := session.Dir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next == dir {
+		t.Fatal("the session was not ended")
+	}
+	removed, err := session.Record(root, CollectorResult{ID: "bios", Status: StatusSkipped})
+	if err != nil || len(removed) != 1 || removed[0].ID != filepath.Base(dir) {
+		t.Errorf("Record() removed %+v, %v; want the previous session", removed, err)
+	}
+	runs, _ = LoadHistory(root)
+	if len(runs) != 1 || runs[0].Outcome != OutcomeCancelled {
+		t.Errorf("history = %+v, want one cancelled run", runs)
+	}
+
+	// A session folder deleted from the History tab is created again
+	if err := os.RemoveAll(next); err != nil {
+		t.Fatal(err)
+	}
+	again, err := session.Dir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(again); err != nil || !info.IsDir() {
+		t.Errorf("Dir() did not create %s: %v", again, err)
+	}
+}
+
+func TestRunSessionRecordWithoutDir(t *testing.T) {
+	if _, err := (&RunSession{}).Record(t.TempDir(), CollectorResult{ID: "bios"}); err == nil {
+		t.Error("Record() before Dir() succeeded")
+	}
+}
@@ -0,0 +1,216 @@
+package modules
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTMLReportFileName is the name of the single-page HTML summary written by RunCollectors.
+const HTMLReportFileName = "GoDiag_Report.html"
+
+//go:embed templates/report.html
+var htmlReportTemplate string
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(htmlReportTemplate))
+
+// htmlPage is the data behind templates/report.html.
+type htmlPage struct {
+	HostName      string
+	GoDiagVersion string
+	Started       time.Time
+	Finished      time.Time
+	Findings      []Finding
+	Sections      []htmlSection
+}
+
+// htmlSection is one collector's collapsible section of the page.
+type htmlSection struct {
+	ID     string
+	Name   string
+	Status CollectorStatus
+	Error  string
+	Files  []string
+	Open   bool        // Expanded when the page loads
+	Tables []htmlTable // Structured view of the report, if it has one
+	Text   string      // The plain-text report, shown under the tables if there are any
+}
+
+// htmlTable is a table within a section. Sortable tables can be sorted by clicking a column heading.
+type htmlTable struct {
+	Title    string
+	Error    string
+	Sortable bool
+	Columns  []string
+	Rows     []htmlRow
+}
+
+type htmlRow struct {
+	Class string // CSS class, used to highlight event levels
+	Cells []string
+}
+
+// writeHTMLReport writes GoDiag_Report.html for a finished batch run, combining every collector's
+// report into one page that needs no external CSS or JavaScript.
+func writeHTMLReport(outputDir string, manifest *RunManifest) error {
+	hostName, _ := os.Hostname()
+	page := htmlPage{
+		HostName:      hostName,
+		GoDiagVersion: AppVersion(),
+		Started:       manifest.Started,
+		Finished:      manifest.Finished,
+		Findings:      manifest.Findings,
+	}
+	for _, result := range manifest.Results {
+		section := htmlSection{
+			ID:     result.ID,
+			Name:   result.Name,
+			Status: result.Status,
+			Error:  result.Error,
+			Files:  result.Files,
+			Open:   result.Status == StatusFailed || result.Status == StatusTimedOut,
+		}
+		if result.Report != nil {
+			var text bytes.Buffer
+			result.Report.WriteText(&text)
+			section.Text = strings.TrimSpace(text.String())
+			section.Tables = htmlTables(result.Report)
+		}
+		page.Sections = append(page.Sections, section)
+	}
+
+	var output bytes.Buffer
+	if err := htmlReport.Execute(&output, page); err != nil {
+		return fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, HTMLReportFileName), output.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write HTML report: %w", err)
+	}
+	return nil
+}
+
+// htmlTables returns the tables shown for reports that have a better view than plain text.
+func htmlTables(report Report) []htmlTable {
+	switch r := report.(type) {
+	case *EventLogReport:
+		return eventTables(r)
+	case *DriverReport:
+		return []htmlTable{driverTable(r)}
+	case *ProcessReport:
+		return []htmlTable{processTable(r.Processes)}
+	case *StartupReport:
+		return []htmlTable{startupTable(r.Items)}
+	}
+	return nil
+}
+
+func eventTables(r *EventLogReport) []htmlTable {
+	var tables []htmlTable
+	if len(r.KnownIssues) > 0 {
+		table := htmlTable{
+			Title:   "Known Issues",
+			Columns: []string{"Issue", "Count", "First", "Last", "Explanation", "Next Steps"},
+		}
+		for _, hit := range r.KnownIssues {
+			table.Rows = append(table.Rows, htmlRow{
+				Cells: []string{hit.Issue.Title, strconv.Itoa(hit.Count), hit.First, hit.Last, hit.Issue.Explanation,
+					strings.Join(hit.Issue.NextSteps, "\n")},
+			})
+		}
+		tables = append(tables, table)
+	}
+	if r.Summary != nil {
+		if len(r.Summary.TopEvents) > 0 {
+			table := htmlTable{
+				Title:   "Top Recurring Event IDs",
+				Columns: []string{"Event ID", "Source", "Level", "Count", "First", "Last", "Message"},
+			}
+			for _, id := range r.Summary.TopEvents {
+				table.Rows = append(table.Rows, htmlRow{
+					Class: "level-" + strings.ToLower(id.Level),
+					Cells: []string{strconv.FormatInt(id.EventID, 10), id.Provider, id.Level, strconv.Itoa(id.Count), id.First, id.Last, firstLine(id.Message)},
+				})
+			}
+			tables = append(tables, table)
+		}
+		table := htmlTable{
+			Title:    "Events per Provider",
+			Sortable: true,
+			Columns:  []string{"Source", "Count", "First", "Last"},
+		}
+		for _, provider := range r.Summary.Providers {
+			table.Rows = append(table.Rows, htmlRow{
+				Cells: []string{provider.Provider, strconv.Itoa(provider.Count), provider.First, provider.Last},
+			})
+		}
+		tables = append(tables, table)
+	}
+	for _, group := range r.Groups {
+		table := htmlTable{
+			Title:   group.Title,
+			Columns: []string{"Time", "Level", "Source", "Event ID", "Log", "Message", "Known Issue"},
+		}
+		table.Error, _ = r.Errors.For(group.Title)
+		for _, event := range group.Events {
+			table.Rows = append(table.Rows, htmlRow{
+				Class: "level-" + strings.ToLower(event.Level),
+				Cells: []string{event.Time, event.Level, event.Provider, strconv.FormatInt(event.EventID, 10), event.LogName, event.Message,
+					event.KnownIssue},
+			})
+		}
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+func driverTable(r *DriverReport) htmlTable {
+	table := htmlTable{
+		Title:    sectionDrivers,
+		Sortable: true,
+		Columns:  []string{"Module Name", "Display Name", "Type", "Start Mode", "State", "Status", "Version", "Link Date", "Path"},
+	}
+	table.Error, _ = r.Errors.For(sectionDrivers)
+	for _, d := range r.Drivers {
+		table.Rows = append(table.Rows, htmlRow{
+			Cells: []string{d.ModuleName, d.DisplayName, d.DriverType, d.StartMode, d.State, d.Status, d.Version, d.LinkDate, d.Path},
+		})
+	}
+	return table
+}
+
+func processTable(processes []ProcessEntry) htmlTable {
+	table := htmlTable{
+		Title:    "Running Processes",
+		Sortable: true,
+		Columns:  []string{"Image Name", "PID", "Session", "Memory (KB)", "Status", "User Name", "CPU Time", "Window Title"},
+	}
+	for _, p := range processes {
+		table.Rows = append(table.Rows, htmlRow{
+			Cells: []string{p.ImageName, strconv.FormatInt(p.PID, 10), p.SessionName, strconv.FormatInt(p.MemoryKB, 10),
+				p.Status, p.UserName, p.CPUTime, p.WindowTitle},
+		})
+	}
+	return table
+}
+
+func startupTable(items []StartupItem) htmlTable {
+	table := htmlTable{
+		Title:    "Startup Items",
+		Sortable: true,
+		Columns:  []string{"Name", "Command", "Location", "User", "Modified"},
+	}
+	for _, item := range items {
+		table.Rows = append(table.Rows, htmlRow{
+			Cells: []string{item.Name, item.Command, item.Location, item.User, item.Modified},
+		})
+	}
+	return table
+}
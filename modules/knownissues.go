@@ -0,0 +1,215 @@
+package modules
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// KnownIssuesFileName is the name of the user's knowledge base file, which adds to and
+// overrides the built-in one.
+const KnownIssuesFileName = "known_issues.json"
+
+//go:embed knowledge/known_issues.json
+var builtinKnownIssuesJSON []byte
+
+// KnownIssue explains an event that comes up often, such as Kernel-Power 41, and what to do about it.
+type KnownIssue struct {
+	ID          string   `json:"id"`
+	Provider    string   `json:"provider"`            // Event source, compared without regard to case
+	EventIDs    []int64  `json:"event_ids,omitempty"` // Empty for any event from Provider
+	Values      []string `json:"values,omitempty"`    // Driver or service names, error codes, ...; one must appear in the event's data
+	Title       string   `json:"title"`
+	Explanation string   `json:"explanation"`
+	NextSteps   []string `json:"next_steps,omitempty"`
+}
+
+// knownIssuesFile is the layout of known_issues.json.
+type knownIssuesFile struct {
+	Issues []KnownIssue `json:"issues"`
+}
+
+// Validate checks that the issue has the fields needed to match and show it.
+func (k KnownIssue) Validate() error {
+	switch {
+	case k.ID == "":
+		return fmt.Errorf("known issue %q has no id", k.Title)
+	case k.Provider == "":
+		return fmt.Errorf("known issue %q has no provider", k.ID)
+	case k.Title == "":
+		return fmt.Errorf("known issue %q has no title", k.ID)
+	}
+	return nil
+}
+
+// Matches reports whether event is an occurrence of the issue.
+func (k KnownIssue) Matches(event Event) bool {
+	if !strings.EqualFold(k.Provider, event.Provider) {
+		return false
+	}
+	if len(k.EventIDs) > 0 && !containsInt(k.EventIDs, event.EventID) {
+		return false
+	}
+	if len(k.Values) == 0 {
+		return true
+	}
+	for _, value := range k.Values {
+		for _, data := range event.Data {
+			if strings.EqualFold(value, data.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// specific reports whether the issue narrows its events down by data values, which makes it
+// win over an issue matching the same event IDs in general.
+func (k KnownIssue) specific() bool {
+	return len(k.Values) > 0
+}
+
+func containsInt(values []int64, n int64) bool {
+	for _, v := range values {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseKnownIssues parses a knowledge base file and validates every issue in it.
+func ParseKnownIssues(data []byte) ([]KnownIssue, error) {
+	var file knownIssuesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse known issues: %w", err)
+	}
+	for _, issue := range file.Issues {
+		if err := issue.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return file.Issues, nil
+}
+
+// LoadKnownIssuesFile reads a user knowledge base file. A missing file is not an error and
+// yields no issues.
+func LoadKnownIssuesFile(path string) ([]KnownIssue, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	issues, err := ParseKnownIssues(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return issues, nil
+}
+
+// builtinKnownIssues is parsed once at startup; a broken embedded file is a bug in GoDiag itself.
+var builtinKnownIssues = func() []KnownIssue {
+	issues, err := ParseKnownIssues(builtinKnownIssuesJSON)
+	if err != nil {
+		panic(err)
+	}
+	return issues
+}()
+
+var (
+	userKnownIssuesMu sync.RWMutex
+	userKnownIssues   []KnownIssue
+)
+
+// SetUserKnownIssues sets the issues added by the user. An issue with the ID of a built-in one
+// replaces it.
+func SetUserKnownIssues(issues []KnownIssue) {
+	userKnownIssuesMu.Lock()
+	defer userKnownIssuesMu.Unlock()
+	userKnownIssues = append([]KnownIssue(nil), issues...)
+}
+
+// KnownIssues returns the user's issues followed by the built-in ones they do not replace.
+func KnownIssues() []KnownIssue {
+	userKnownIssuesMu.RLock()
+	defer userKnownIssuesMu.RUnlock()
+
+	issues := append([]KnownIssue(nil), userKnownIssues...)
+	replaced := make(map[string]bool)
+	for _, issue := range userKnownIssues {
+		replaced[issue.ID] = true
+	}
+	for _, issue := range builtinKnownIssues {
+		if !replaced[issue.ID] {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// matchKnownIssue returns the issue event is an occurrence of, preferring issues that name a
+// driver or service over general ones and otherwise the first in the list.
+func matchKnownIssue(issues []KnownIssue, event Event) (KnownIssue, bool) {
+	var match KnownIssue
+	found := false
+	for _, issue := range issues {
+		if !issue.Matches(event) {
+			continue
+		}
+		if issue.specific() {
+			return issue, true
+		}
+		if !found {
+			match, found = issue, true
+		}
+	}
+	return match, found
+}
+
+// KnownIssueHit is a known issue found among the collected events.
+type KnownIssueHit struct {
+	Issue KnownIssue `json:"issue"`
+	Count int        `json:"count"`
+	First string     `json:"first,omitempty"`
+	Last  string     `json:"last,omitempty"`
+}
+
+// annotateKnownIssues tags every event in groups with the known issue it matches and returns
+// the issues found, in knowledge base order.
+func annotateKnownIssues(groups []EventGroup) []KnownIssueHit {
+	issues := KnownIssues()
+	counts := make(map[string]*EventCount)
+	seen := make(map[string]bool)
+	for g := range groups {
+		for e := range groups[g].Events {
+			event := &groups[g].Events[e]
+			issue, ok := matchKnownIssue(issues, *event)
+			if !ok {
+				continue
+			}
+			event.KnownIssue = issue.ID
+			key := eventKey(*event)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if counts[issue.ID] == nil {
+				counts[issue.ID] = &EventCount{}
+			}
+			counts[issue.ID].add(*event)
+		}
+	}
+
+	var hits []KnownIssueHit
+	for _, issue := range issues {
+		if count := counts[issue.ID]; count != nil {
+			hits = append(hits, KnownIssueHit{Issue: issue, Count: count.Count, First: count.First, Last: count.Last})
+		}
+	}
+	return hits
+}
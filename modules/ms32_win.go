@@ -0,0 +1,23 @@
+package modules
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+)
+
+var msinfo32Collector = &toolCollector{
+	collectorInfo: collectorInfo{
+		id:          "msinfo32",
+		name:        "Generate msinfo32.nfo",
+		outputFiles: []string{"msinfo32.nfo"},
+		timeout:     10 * time.Minute,
+	},
+	run: GenerateMsinfo32,
+}
+
+func GenerateMsinfo32(ctx context.Context, outputDir string) error {
+	outputPath := filepath.Join(outputDir, "msinfo32.nfo")
+	_, err := runner.Output(ctx, "msinfo32", "/nfo", outputPath)
+	return err
+}
@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 )
@@ -8,6 +9,38 @@ import (
 // customOutputDir stores the user-selected output directory. If empty, the default path is used.
 var customOutputDir string
 
+// managedOutputDir is the output directory a machine policy forces; see SetManagedOutputDir.
+var managedOutputDir string
+
+// SetManagedOutputDir forces every run into path, whatever the user selected, as a machine
+// policy requires. An empty path lifts the restriction.
+func SetManagedOutputDir(path string) {
+	managedOutputDir = path
+}
+
+// ManagedOutputDir returns the output directory forced by SetManagedOutputDir, if any.
+func ManagedOutputDir() string {
+	return managedOutputDir
+}
+
+// defaultOutputDir is the output directory used when none is selected; see SetDefaultOutputDir.
+var defaultOutputDir string
+
+// SetDefaultOutputDir sets the output directory used when the user has not selected one, which
+// depends on the platform and on whether GoDiag runs in portable mode.
+func SetDefaultOutputDir(path string) {
+	defaultOutputDir = path
+}
+
+// DefaultOutputDir returns the output directory used when the user has not selected one. Until
+// SetDefaultOutputDir is called it is a DiagnosticsFiles folder in the temporary directory.
+func DefaultOutputDir() string {
+	if defaultOutputDir != "" {
+		return defaultOutputDir
+	}
+	return filepath.Join(os.TempDir(), "DiagnosticsFiles")
+}
+
 // SetCustomOutputDir sets the custom output directory. This function should be called
 // when the user selects a new directory via the settings.
 func SetCustomOutputDir(path string) {
@@ -20,21 +53,38 @@ func GetCustomOutputDir() string {
 	return customOutputDir
 }
 
-// EnsureOutputDir creates the output directory and returns its path.
-// It uses the customOutputDir if it has been set; otherwise, it defaults
-// to `%LOCALAPPDATA%\Temp\DiagnosticsFiles`.
-func EnsureOutputDir() (string, error) {
-	var targetDir string
+// OutputDir returns the output directory without creating it: the managedOutputDir if a policy
+// forces one, then the customOutputDir if it has been set; otherwise, DefaultOutputDir.
+func OutputDir() string {
+	if managedOutputDir != "" {
+		return managedOutputDir
+	}
 	if customOutputDir != "" {
-		targetDir = customOutputDir
-	} else {
-		targetDir = filepath.Join(os.Getenv("LOCALAPPDATA"), "Temp", "DiagnosticsFiles")
+		return customOutputDir
 	}
+	return DefaultOutputDir()
+}
 
-	// Create all necessary parent directories if they don't exist
-	err := os.MkdirAll(targetDir, os.ModePerm)
-	if err != nil {
+// EnsureOutputDir creates the output directory and returns its path, or an error if it cannot be
+// written to, such as a folder on a drive that is no longer connected.
+func EnsureOutputDir() (string, error) {
+	dir := OutputDir()
+	if err := CheckOutputDir(dir); err != nil {
 		return "", err
 	}
-	return targetDir, nil
+	return dir, nil
+}
+
+// CheckOutputDir creates dir if needed and checks that files can be written to it.
+func CheckOutputDir(dir string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("output directory %s cannot be created: %w", dir, err)
+	}
+	probe, err := os.CreateTemp(dir, ".godiag-write-test-*")
+	if err != nil {
+		return fmt.Errorf("output directory %s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
 }
@@ -0,0 +1,43 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnsureOutputDir(t *testing.T) {
+	defer SetCustomOutputDir(GetCustomOutputDir())
+
+	dir := filepath.Join(t.TempDir(), "reports", "GoDiag")
+	SetCustomOutputDir(dir)
+	if OutputDir() != dir {
+		t.Errorf("OutputDir() = %s, want %s", OutputDir(), dir)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("OutputDir() created the folder: %v", err)
+	}
+	got, err := EnsureOutputDir()
+	if err != nil || got != dir {
+		t.Fatalf("EnsureOutputDir() = %s, %v; want %s", got, err, dir)
+	}
+	// The write check leaves nothing behind
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Errorf("folder holds %v, %v; want nothing", entries, err)
+	}
+}
+
+func TestEnsureOutputDirUnavailable(t *testing.T) {
+	defer SetCustomOutputDir(GetCustomOutputDir())
+
+	// A file where a parent folder should be cannot be created on any platform
+	file := filepath.Join(t.TempDir(), "drive")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	SetCustomOutputDir(filepath.Join(file, "GoDiag"))
+	if _, err := EnsureOutputDir(); err == nil || !strings.Contains(err.Error(), "cannot be created") {
+		t.Errorf("EnsureOutputDir() = %v, want an error saying the folder cannot be created", err)
+	}
+}
@@ -0,0 +1,161 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Property is a single name/value pair parsed from command output, kept in its original order.
+type Property struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// normalizeOutput decodes command output to text, converting UTF-16 (recognised by its byte
+// order mark, or by the NUL bytes of little-endian ASCII as some tools write without one) and
+// turning CRLF, and the stray CRCRLF some Windows tools emit, into LF.
+func normalizeOutput(data []byte) string {
+	var text string
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		text = decodeUTF16(data[2:], binary.LittleEndian)
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		text = decodeUTF16(data[2:], binary.BigEndian)
+	case len(data) >= 2 && len(data)%2 == 0 && data[0] != 0 && data[1] == 0:
+		text = decodeUTF16(data, binary.LittleEndian)
+	default:
+		text = strings.TrimPrefix(string(data), "\uFEFF") // UTF-8 byte order mark
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "")
+}
+
+// parseListRecords parses "Name<sep>Value" output, with records separated by blank lines, as
+// produced by `wmic ... /format:list` (sep "="), `driverquery /FO LIST`, `systeminfo` and
+// PowerShell's Format-List (sep ":"). Indented lines without a separator continue the previous value.
+func parseListRecords(data []byte, sep string) [][]Property {
+	var records [][]Property
+	var current []Property
+
+	flush := func() {
+		if len(current) > 0 {
+			records = append(records, current)
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		indented := line[0] == ' ' || line[0] == '\t'
+		name, value, found := strings.Cut(line, sep)
+		if (indented || !found) && len(current) > 0 {
+			last := &current[len(current)-1]
+			last.Value = strings.TrimSpace(last.Value + "\n" + strings.TrimSpace(line))
+			continue
+		}
+		if !found {
+			continue
+		}
+		current = append(current, Property{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+	}
+	flush()
+	return records
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// propertyMap indexes a parsed record by name. Later duplicates overwrite earlier ones.
+func propertyMap(record []Property) map[string]string {
+	values := make(map[string]string, len(record))
+	for _, p := range record {
+		values[p.Name] = p.Value
+	}
+	return values
+}
+
+// parseCSVRecords parses CSV output such as `driverquery /FO CSV` or `tasklist /FO CSV`,
+// returning the data rows without the header. Blank lines are skipped.
+func parseCSVRecords(data []byte) ([][]string, error) {
+	reader := csv.NewReader(strings.NewReader(normalizeOutput(data)))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 {
+		rows = rows[1:]
+	}
+	return rows, nil
+}
+
+// column returns row[i], or an empty string if the row is too short.
+func column(row []string, i int) string {
+	if i < len(row) {
+		return strings.TrimSpace(row[i])
+	}
+	return ""
+}
+
+// parseDottedLine splits an `ipconfig`-style "Name . . . . : Value" line. ok is false for
+// lines that carry no name, such as the extra DNS servers listed under the first one.
+func parseDottedLine(line string) (name, value string, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if i := strings.Index(trimmed, " : "); i >= 0 {
+		name, value = trimmed[:i], trimmed[i+3:]
+	} else if strings.HasSuffix(trimmed, " :") {
+		name = strings.TrimSuffix(trimmed, " :")
+	} else {
+		return "", "", false
+	}
+	return strings.TrimRight(name, " ."), strings.TrimSpace(value), true
+}
+
+// SplitList splits a comma-separated setting or flag value, trimming space around the entries
+// and dropping empty ones.
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseInt parses a decimal integer, ignoring surrounding space and thousands separators.
+// Anything unparsable yields 0.
+func parseInt(value string) int64 {
+	value = strings.NewReplacer(",", "", ".", "", " ", "", " ", "").Replace(strings.TrimSpace(value))
+	n, _ := strconv.ParseInt(value, 10, 64)
+	return n
+}
+
+// formatWMIDate turns a CIM datetime such as "20230515000000.000000+000" into "2023-05-15".
+// Values that are not CIM datetimes are returned unchanged.
+func formatWMIDate(value string) string {
+	if len(value) < 8 {
+		return value
+	}
+	for _, r := range value[:8] {
+		if r < '0' || r > '9' {
+			return value
+		}
+	}
+	return value[0:4] + "-" + value[4:6] + "-" + value[6:8]
+}
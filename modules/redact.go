@@ -0,0 +1,521 @@
+package modules
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"os/user"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// RedactionCategory is a kind of personal or identifying information the redaction pass can remove.
+type RedactionCategory string
+
+const (
+	RedactSerials   RedactionCategory = "serials"   // BIOS and disk serial numbers
+	RedactMAC       RedactionCategory = "mac"       // Hardware (MAC) addresses
+	RedactIP        RedactionCategory = "ip"        // IPv4 and IPv6 addresses
+	RedactUserNames RedactionCategory = "usernames" // Account names, including those in C:\Users paths
+	RedactDNSCache  RedactionCategory = "dns_cache" // Names and data in the DNS resolver cache
+)
+
+// redactionCategories lists every category in the order they are presented to the user.
+var redactionCategories = []RedactionCategory{RedactSerials, RedactMAC, RedactIP, RedactUserNames, RedactDNSCache}
+
+var redactionLabels = map[RedactionCategory]string{
+	RedactSerials:   "Serial numbers",
+	RedactMAC:       "MAC addresses",
+	RedactIP:        "IP addresses",
+	RedactUserNames: "User names",
+	RedactDNSCache:  "DNS cache entries",
+}
+
+var redactionTokenPrefixes = map[RedactionCategory]string{
+	RedactSerials:   "SERIAL",
+	RedactMAC:       "MAC",
+	RedactIP:        "IP",
+	RedactUserNames: "USER",
+	RedactDNSCache:  "DNS",
+}
+
+// RedactionCategories returns every redaction category.
+func RedactionCategories() []RedactionCategory {
+	return append([]RedactionCategory(nil), redactionCategories...)
+}
+
+// Label returns the human-readable name of the category.
+func (c RedactionCategory) Label() string {
+	if label, ok := redactionLabels[c]; ok {
+		return label
+	}
+	return string(c)
+}
+
+// RedactionMode controls what redacted values are replaced with.
+type RedactionMode string
+
+const (
+	// RedactMask replaces values with numbered tokens such as [IP-1].
+	RedactMask RedactionMode = "mask"
+	// RedactHash replaces values with a salted hash such as [IP-3fa2b1c4]. With a fixed salt the
+	// same value gets the same token in every bundle, so support can correlate them.
+	RedactHash RedactionMode = "hash"
+)
+
+// RedactionConfig selects what the redaction pass removes and how.
+type RedactionConfig struct {
+	Categories []RedactionCategory `json:"categories"`
+	Mode       RedactionMode       `json:"mode"`
+	Salt       string              `json:"salt,omitempty"` // Hash mode only; a random salt is used per bundle when empty
+}
+
+// DefaultRedactionConfig masks every category.
+func DefaultRedactionConfig() RedactionConfig {
+	return RedactionConfig{Categories: RedactionCategories(), Mode: RedactMask}
+}
+
+// Enabled reports whether category is redacted.
+func (c RedactionConfig) Enabled(category RedactionCategory) bool {
+	for _, enabled := range c.Categories {
+		if enabled == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Salted returns c with a random salt filled in when it is in hash mode without one. Every
+// Redactor built from an unsalted config draws its own salt, so a preview shows the same tokens as
+// the bundle made after it only when both are given the same salted config.
+func (c RedactionConfig) Salted() RedactionConfig {
+	if c.Mode == RedactHash && c.Salt == "" {
+		salt := make([]byte, 16)
+		rand.Read(salt)
+		c.Salt = hex.EncodeToString(salt)
+	}
+	return c
+}
+
+// Validate checks that the mode and every category are known.
+func (c RedactionConfig) Validate() error {
+	if c.Mode != RedactMask && c.Mode != RedactHash {
+		return fmt.Errorf("unknown redaction mode %q (expected %q or %q)", c.Mode, RedactMask, RedactHash)
+	}
+	for _, category := range c.Categories {
+		if _, ok := redactionLabels[category]; !ok {
+			return fmt.Errorf("unknown redaction category %q", category)
+		}
+	}
+	return nil
+}
+
+var (
+	requiredRedactionMu sync.RWMutex
+	requiredRedaction   []RedactionCategory // Nil when redaction is optional
+)
+
+// SetRequiredRedaction makes CreateBundle redact every bundle, hiding at least categories, as a
+// machine policy requires. Passing nil makes redaction optional again.
+func SetRequiredRedaction(categories []RedactionCategory) {
+	requiredRedactionMu.Lock()
+	defer requiredRedactionMu.Unlock()
+	if categories == nil {
+		requiredRedaction = nil
+		return
+	}
+	requiredRedaction = append([]RedactionCategory{}, categories...)
+}
+
+// RequiredRedaction returns the categories every bundle must hide, or nil when redaction is
+// optional.
+func RequiredRedaction() []RedactionCategory {
+	requiredRedactionMu.RLock()
+	defer requiredRedactionMu.RUnlock()
+	if requiredRedaction == nil {
+		return nil
+	}
+	return append([]RedactionCategory{}, requiredRedaction...)
+}
+
+// enforceRedaction adds the categories SetRequiredRedaction requires to config, or returns a
+// masking configuration with just those categories when config is nil.
+func enforceRedaction(config *RedactionConfig) *RedactionConfig {
+	required := RequiredRedaction()
+	if required == nil {
+		return config
+	}
+	enforced := RedactionConfig{Mode: RedactMask}
+	if config != nil {
+		enforced = *config
+		enforced.Categories = append([]RedactionCategory(nil), config.Categories...)
+	}
+	for _, category := range required {
+		if !enforced.Enabled(category) {
+			enforced.Categories = append(enforced.Categories, category)
+		}
+	}
+	return &enforced
+}
+
+// ParseRedactionCategories parses a comma-separated list of categories, or "all".
+func ParseRedactionCategories(spec string) ([]RedactionCategory, error) {
+	if strings.TrimSpace(spec) == "all" {
+		return RedactionCategories(), nil
+	}
+
+	var categories []RedactionCategory
+	for _, name := range strings.Split(spec, ",") {
+		category := RedactionCategory(strings.TrimSpace(name))
+		if category == "" {
+			continue
+		}
+		if _, ok := redactionLabels[category]; !ok {
+			return nil, fmt.Errorf("unknown redaction category %q", category)
+		}
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+// Redaction is one distinct value the redaction pass replaced.
+type Redaction struct {
+	Category RedactionCategory `json:"category"`
+	Value    string            `json:"value"`
+	Token    string            `json:"token"`
+	Count    int               `json:"count"` // Occurrences replaced across all files
+	Files    []string          `json:"files"`
+}
+
+// Redactor replaces identifying values in report text with tokens. The same value always gets
+// the same token, whichever file or category it turns up in. Values that can only be recognised
+// by their label (serial numbers, user names, DNS cache entries) are learned with Learn first and
+// then replaced wherever they appear; MAC and IP addresses are recognised by their shape.
+type Redactor struct {
+	config  RedactionConfig
+	salt    []byte
+	values  map[string]*Redaction // Keyed by normalised value
+	order   []*Redaction          // In the order their tokens were assigned
+	counts  map[RedactionCategory]int
+	learned []string // Values learned from labels, replaced by the learned pattern
+	pattern *regexp.Regexp
+}
+
+// NewRedactor creates a Redactor for config.
+func NewRedactor(config RedactionConfig) *Redactor {
+	r := &Redactor{
+		config: config,
+		values: map[string]*Redaction{},
+		counts: map[RedactionCategory]int{},
+	}
+	if config.Salt != "" {
+		r.salt = []byte(config.Salt)
+	} else {
+		r.salt = make([]byte, 16)
+		rand.Read(r.salt)
+	}
+
+	if config.Enabled(RedactUserNames) {
+		if current, err := user.Current(); err == nil {
+			r.learn(RedactUserNames, accountName(current.Username))
+		}
+	}
+	return r
+}
+
+var (
+	serialPattern     = regexp.MustCompile(`(?mi)^[ \t]*serial ?number[ \t]*[:=][ \t]*(\S.*?)[ \t]*$`)
+	serialJSONPattern = regexp.MustCompile(`"serial_number"\s*:\s*"([^"]+)"`)
+	userPattern       = regexp.MustCompile(`(?mi)^[ \t]*(?:user ?name|user|registered owner)[ \t]*[:=][ \t]*(\S.*?)[ \t]*$`)
+	userJSONPattern   = regexp.MustCompile(`"(?:user_name|user|domain_user)"\s*:\s*"([^"]+)"`)
+	profilePattern    = regexp.MustCompile(`(?i)[a-z]:\\{1,2}users\\{1,2}([^\\/"\r\n<>:|?*]+)`)
+	dnsFieldPattern   = regexp.MustCompile(`^[ \t]*(?:Record Name|Data)[ \t]*:[ \t]*(\S.*?)[ \t]*$`)
+
+	macPattern  = regexp.MustCompile(`[0-9A-Fa-f]{2}(?:[-:][0-9A-Fa-f]{2}){5}`)
+	ipv4Pattern = regexp.MustCompile(`\d{1,3}(?:\.\d{1,3}){3}`)
+	ipv6Pattern = regexp.MustCompile(`[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}(?:%[0-9A-Za-z]+)?`)
+)
+
+// placeholderValues are filler values vendors and Windows use where there is nothing to hide.
+var placeholderValues = map[string]bool{
+	"n/a": true, "none": true, "0": true, "default string": true, "to be filled by o.e.m.": true,
+	"system serial number": true, "not specified": true, "not applicable": true,
+	"system": true, "local service": true, "network service": true, "public": true, "default": true,
+	"default user": true, "all users": true, "administrator": true, "everyone": true,
+}
+
+// Learn records the values in text that can only be recognised by their label, such as the
+// "Serial Number:" lines of the BIOS and health reports, so Redact replaces them in every file.
+// Call it for every file before redacting any of them.
+func (r *Redactor) Learn(text string) {
+	if r.config.Enabled(RedactSerials) {
+		for _, pattern := range []*regexp.Regexp{serialPattern, serialJSONPattern} {
+			for _, match := range pattern.FindAllStringSubmatch(text, -1) {
+				r.learn(RedactSerials, match[1])
+			}
+		}
+	}
+
+	if r.config.Enabled(RedactUserNames) {
+		for _, pattern := range []*regexp.Regexp{userPattern, userJSONPattern, profilePattern} {
+			for _, match := range pattern.FindAllStringSubmatch(text, -1) {
+				r.learn(RedactUserNames, accountName(match[1]))
+			}
+		}
+	}
+
+	if r.config.Enabled(RedactDNSCache) {
+		inCache := false
+		for _, line := range strings.Split(normalizeOutput([]byte(text)), "\n") {
+			if strings.HasPrefix(line, "--- ") {
+				inCache = strings.Contains(line, "DNS Resolver Cache")
+				continue
+			}
+			if match := dnsFieldPattern.FindStringSubmatch(line); inCache && match != nil {
+				r.learn(RedactDNSCache, match[1])
+			}
+		}
+	}
+}
+
+// accountName strips the domain from DOMAIN\user (also as escaped in JSON) and any surrounding quotes.
+func accountName(value string) string {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	if i := strings.LastIndex(value, `\`); i >= 0 {
+		value = value[i+1:]
+	}
+	return value
+}
+
+func (r *Redactor) learn(category RedactionCategory, value string) {
+	value = strings.TrimSpace(value)
+	lower := strings.ToLower(value)
+	if len(value) < 3 || placeholderValues[lower] || (strings.HasPrefix(lower, "s-1-5-") && len(value) <= 12) {
+		return
+	}
+	if _, known := r.values[lower]; known {
+		return
+	}
+	r.token(category, lower, value)
+	r.learned = append(r.learned, value)
+	r.pattern = nil
+}
+
+// token returns the redaction for the value with normalised form key, creating it if needed.
+// Its token is assigned when it is first replaced, so masked tokens are numbered without gaps.
+func (r *Redactor) token(category RedactionCategory, key, value string) *Redaction {
+	if redaction, ok := r.values[key]; ok {
+		return redaction
+	}
+	redaction := &Redaction{Category: category, Value: value}
+	r.values[key] = redaction
+	return redaction
+}
+
+func (r *Redactor) assignToken(redaction *Redaction) {
+	r.order = append(r.order, redaction)
+	prefix := redactionTokenPrefixes[redaction.Category]
+	if r.config.Mode == RedactHash {
+		hash := sha256.Sum256(append(append([]byte(nil), r.salt...), strings.ToLower(redaction.Value)...))
+		redaction.Token = fmt.Sprintf("[%s-%s]", prefix, hex.EncodeToString(hash[:4]))
+	} else {
+		r.counts[redaction.Category]++
+		redaction.Token = fmt.Sprintf("[%s-%d]", prefix, r.counts[redaction.Category])
+	}
+}
+
+// Redact returns text with every identifying value replaced by its token, counting the
+// replacements against file.
+func (r *Redactor) Redact(file, text string) string {
+	if len(r.learned) > 0 {
+		if r.pattern == nil {
+			values := append([]string(nil), r.learned...)
+			sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+			for i, value := range values {
+				values[i] = regexp.QuoteMeta(value)
+			}
+			r.pattern = regexp.MustCompile(`(?i)(?:` + strings.Join(values, "|") + `)`)
+		}
+		text = r.replaceMatches(text, r.pattern, func(text string, start, end int) *Redaction {
+			if isWordByte(byteAt(text, start-1)) || isWordByte(byteAt(text, end)) {
+				return nil
+			}
+			return r.values[strings.ToLower(text[start:end])]
+		}, file)
+	}
+
+	if r.config.Enabled(RedactMAC) {
+		text = r.replaceMatches(text, macPattern, func(text string, start, end int) *Redaction {
+			before, after := byteAt(text, start-1), byteAt(text, end)
+			if isWordByte(before) || isWordByte(after) || before == '-' || before == ':' || after == '-' || after == ':' {
+				return nil // Part of something longer, such as a tunnel adapter's 8-byte address
+			}
+			key := strings.ToUpper(strings.ReplaceAll(text[start:end], ":", "-"))
+			if key == "00-00-00-00-00-00" || key == "FF-FF-FF-FF-FF-FF" {
+				return nil
+			}
+			return r.token(RedactMAC, key, text[start:end])
+		}, file)
+	}
+
+	if r.config.Enabled(RedactIP) {
+		text = r.replaceMatches(text, ipv4Pattern, func(text string, start, end int) *Redaction {
+			before, after := byteAt(text, start-1), byteAt(text, end)
+			if isWordByte(before) || before == '.' || isWordByte(after) || after == '.' && isWordByte(byteAt(text, end+1)) {
+				return nil // Part of a longer dotted number, such as a driver version
+			}
+			return r.ipToken(text[start:end])
+		}, file)
+		text = r.replaceMatches(text, ipv6Pattern, func(text string, start, end int) *Redaction {
+			before, after := byteAt(text, start-1), byteAt(text, end)
+			if isWordByte(before) || before == ':' || isWordByte(after) || after == ':' {
+				return nil // Part of a word or a time of day, such as "th::after" or 10:00:00
+			}
+			return r.ipToken(text[start:end])
+		}, file)
+	}
+	return text
+}
+
+func (r *Redactor) ipToken(match string) *Redaction {
+	addr, err := netip.ParseAddr(match)
+	if err != nil {
+		return nil
+	}
+	addr = addr.WithZone("")
+	if addr.IsUnspecified() || addr.IsLoopback() || (addr.Is4() && addr.As4()[0] == 255) {
+		return nil // Not identifying, and subnet masks look just like addresses
+	}
+	return r.token(RedactIP, addr.String(), match)
+}
+
+// replaceMatches replaces each match of pattern in text for which redaction returns a value.
+// redaction is given the whole text so it can look at what surrounds the match.
+func (r *Redactor) replaceMatches(text string, pattern *regexp.Regexp, redaction func(text string, start, end int) *Redaction, file string) string {
+	var output strings.Builder
+	last := 0
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		found := redaction(text, loc[0], loc[1])
+		if found == nil {
+			continue
+		}
+		if found.Token == "" {
+			r.assignToken(found)
+		}
+		found.Count++
+		if len(found.Files) == 0 || found.Files[len(found.Files)-1] != file {
+			found.Files = append(found.Files, file)
+		}
+		output.WriteString(text[last:loc[0]])
+		output.WriteString(found.Token)
+		last = loc[1]
+	}
+	if last == 0 {
+		return text
+	}
+	output.WriteString(text[last:])
+	return output.String()
+}
+
+// byteAt returns text[i], or 0 when i is outside text.
+func byteAt(text string, i int) byte {
+	if i < 0 || i >= len(text) {
+		return 0
+	}
+	return text[i]
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// Redactions returns every value that has been replaced at least once, grouped by category.
+func (r *Redactor) Redactions() []Redaction {
+	var redactions []Redaction
+	for _, category := range redactionCategories {
+		for _, redaction := range r.order {
+			if redaction.Category == category {
+				redactions = append(redactions, *redaction)
+			}
+		}
+	}
+	return redactions
+}
+
+// decodeText returns the contents of a report file as text along with a function that encodes
+// redacted text the same way. ok is false for binary files, such as .evtx logs, which cannot be
+// redacted. UTF-16 files such as .reg exports and msinfo32.nfo are recognised by their byte order mark.
+func decodeText(data []byte) (text string, encode func(string) []byte, ok bool) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeUTF16(data[2:], binary.LittleEndian), func(s string) []byte {
+			return encodeUTF16(s, []byte{0xFF, 0xFE}, binary.LittleEndian)
+		}, true
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeUTF16(data[2:], binary.BigEndian), func(s string) []byte {
+			return encodeUTF16(s, []byte{0xFE, 0xFF}, binary.BigEndian)
+		}, true
+	case bytes.IndexByte(data, 0) >= 0:
+		return "", nil, false
+	default:
+		return string(data), func(s string) []byte { return []byte(s) }, true
+	}
+}
+
+func encodeUTF16(text string, bom []byte, order binary.AppendByteOrder) []byte {
+	units := utf16.Encode([]rune(text))
+	data := make([]byte, len(bom), len(bom)+2*len(units))
+	copy(data, bom)
+	for _, unit := range units {
+		data = order.AppendUint16(data, unit)
+	}
+	return data
+}
+
+// RedactionPreview lists what a redacted bundle of an output directory would hide.
+type RedactionPreview struct {
+	Config     RedactionConfig `json:"config"`
+	Redactions []Redaction     `json:"redactions"`
+	Omitted    []string        `json:"omitted"` // Binary files left out of the bundle because they cannot be redacted
+}
+
+// WriteText renders the preview as a plain-text list.
+func (p *RedactionPreview) WriteText(output *bytes.Buffer) {
+	if len(p.Redactions) == 0 {
+		output.WriteString("Nothing to redact.\n")
+	}
+
+	var category RedactionCategory
+	for _, redaction := range p.Redactions {
+		if redaction.Category != category {
+			if category != "" {
+				output.WriteString("\n")
+			}
+			category = redaction.Category
+			output.WriteString(fmt.Sprintf("%s:\n", category.Label()))
+		}
+		output.WriteString(fmt.Sprintf("  %s -> %s (%s in %s)\n", redaction.Value, redaction.Token,
+			pluralize(redaction.Count, "occurrence"), pluralize(len(redaction.Files), "file")))
+	}
+
+	if len(p.Omitted) > 0 {
+		output.WriteString("\nLeft out because they cannot be redacted:\n")
+		for _, file := range p.Omitted {
+			output.WriteString(fmt.Sprintf("  %s\n", file))
+		}
+	}
+}
+
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,78 @@
+package modules
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactionConfigSalted(t *testing.T) {
+	hash := RedactionConfig{Categories: []RedactionCategory{RedactIP}, Mode: RedactHash}
+	salted := hash.Salted()
+	if salted.Salt == "" {
+		t.Fatal("Salted left the hash config without a salt")
+	}
+	if again := hash.Salted(); again.Salt == salted.Salt {
+		t.Error("two calls to Salted drew the same salt")
+	}
+	if kept := salted.Salted(); kept.Salt != salted.Salt {
+		t.Errorf("Salted replaced the salt %q with %q", salted.Salt, kept.Salt)
+	}
+	if mask := (RedactionConfig{Mode: RedactMask}).Salted(); mask.Salt != "" {
+		t.Errorf("Salted gave a mask config the salt %q", mask.Salt)
+	}
+}
+
+func TestPreviewTokensMatchBundle(t *testing.T) {
+	outputDir := t.TempDir()
+	report := "IPv4 Address: 192.168.1.23\nDefault Gateway: 192.168.1.1\n"
+	if err := os.WriteFile(filepath.Join(outputDir, "Network_Diagnostics.txt"), []byte(report), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := RedactionConfig{Categories: []RedactionCategory{RedactIP}, Mode: RedactHash}.Salted()
+	preview, err := PreviewRedaction(context.Background(), outputDir, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Redactions) != 2 {
+		t.Fatalf("preview found %d values, want 2: %+v", len(preview.Redactions), preview.Redactions)
+	}
+
+	bundlePath, err := CreateBundle(context.Background(), outputDir, &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundled := readBundleFile(t, bundlePath, "Network_Diagnostics.txt")
+	for _, redaction := range preview.Redactions {
+		if !strings.Contains(bundled, redaction.Token) {
+			t.Errorf("bundle does not use the preview's token %s for %s:\n%s", redaction.Token, redaction.Value, bundled)
+		}
+		if strings.Contains(bundled, redaction.Value) {
+			t.Errorf("bundle still contains %s", redaction.Value)
+		}
+	}
+}
+
+func readBundleFile(t *testing.T, bundlePath, name string) string {
+	t.Helper()
+	bundle, err := zip.OpenReader(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bundle.Close()
+	file, err := bundle.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,267 @@
+package modules
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RegistryProfilesFileName is the name of the user's registry profiles file, which adds to and
+// overrides the built-in profiles.
+const RegistryProfilesFileName = "registry_profiles.json"
+
+// DefaultRegistryProfile is the profile exported when none is selected.
+const DefaultRegistryProfile = "default"
+
+//go:embed profiles/registry_profiles.json
+var builtinRegistryProfilesJSON []byte
+
+// RegistryKey defines a specific registry key to export.
+type RegistryKey struct {
+	Name string `json:"name"` // A friendly name for the key (e.g., "Startup_Programs_HKLM"), used in the .reg file name
+	Path string `json:"path"` // The full path to the registry key (e.g., "HKLM\\SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\Run")
+}
+
+// registryHives maps the root keys `reg export` accepts, in short and long form, to their short form.
+var registryHives = map[string]string{
+	"HKLM": "HKLM", "HKEY_LOCAL_MACHINE": "HKLM",
+	"HKCU": "HKCU", "HKEY_CURRENT_USER": "HKCU",
+	"HKCR": "HKCR", "HKEY_CLASSES_ROOT": "HKCR",
+	"HKU": "HKU", "HKEY_USERS": "HKU",
+	"HKCC": "HKCC", "HKEY_CURRENT_CONFIG": "HKCC",
+}
+
+// Validate checks that the key has a name usable in a file name and a path under a root key
+// `reg export` accepts.
+func (k RegistryKey) Validate() error {
+	if k.Name == "" {
+		return fmt.Errorf("registry key %q has no name", k.Path)
+	}
+	if strings.ContainsAny(k.Name, `\/:*?"<>|`) || strings.TrimSpace(k.Name) != k.Name {
+		return fmt.Errorf("registry key name %q cannot be used in a file name", k.Name)
+	}
+	hive, subkey, _ := strings.Cut(k.Path, `\`)
+	if _, ok := registryHives[strings.ToUpper(hive)]; !ok {
+		return fmt.Errorf("registry key %q does not start with HKLM, HKCU, HKCR, HKU or HKCC", k.Path)
+	}
+	if strings.ContainsAny(k.Path, "\"\r\n") || strings.Contains(subkey, `\\`) || strings.HasSuffix(k.Path, `\`) {
+		return fmt.Errorf("invalid registry key path %q", k.Path)
+	}
+	return nil
+}
+
+// ParseRegistryKey parses a key written as "Name=Path" or just a path, in which case the name is
+// made from the path's last subkey, e.g. "HKCU\Software\Valve\Steam" becomes "Steam".
+func ParseRegistryKey(spec string) (RegistryKey, error) {
+	var key RegistryKey
+	if name, path, found := strings.Cut(spec, "="); found && !strings.Contains(name, `\`) {
+		key = RegistryKey{Name: strings.TrimSpace(name), Path: strings.TrimSpace(path)}
+	} else {
+		key.Path = strings.TrimSpace(spec)
+		key.Name = registryKeyName(key.Path)
+	}
+	return key, key.Validate()
+}
+
+// registryKeyName turns the last subkey of path into a name usable in a file name.
+func registryKeyName(path string) string {
+	last := path[strings.LastIndex(path, `\`)+1:]
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`\/:*?"<>| `, r) {
+			return '_'
+		}
+		return r
+	}, last)
+	return strings.Trim(name, "_")
+}
+
+// RegistryProfile is a named set of registry keys to export, such as "networking".
+type RegistryProfile struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Keys        []RegistryKey `json:"keys"`
+}
+
+// Validate checks that the profile has a name and keys and that every key is valid.
+func (p RegistryProfile) Validate() error {
+	if p.Name == "" || strings.ContainsAny(p.Name, ", ") {
+		return fmt.Errorf("invalid registry profile name %q", p.Name)
+	}
+	if len(p.Keys) == 0 {
+		return fmt.Errorf("registry profile %q has no keys", p.Name)
+	}
+	for _, key := range p.Keys {
+		if err := key.Validate(); err != nil {
+			return fmt.Errorf("registry profile %q: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// registryProfilesFile is the layout of registry_profiles.json.
+type registryProfilesFile struct {
+	Profiles []RegistryProfile `json:"profiles"`
+}
+
+// ParseRegistryProfiles parses a registry profiles file and validates every profile in it.
+func ParseRegistryProfiles(data []byte) ([]RegistryProfile, error) {
+	var file registryProfilesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse registry profiles: %w", err)
+	}
+	for _, profile := range file.Profiles {
+		if err := profile.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return file.Profiles, nil
+}
+
+// LoadRegistryProfilesFile reads a user registry profiles file. A missing file is not an error
+// and yields no profiles.
+func LoadRegistryProfilesFile(path string) ([]RegistryProfile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := ParseRegistryProfiles(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return profiles, nil
+}
+
+// builtinRegistryProfiles is parsed once at startup; a broken embedded file is a bug in GoDiag itself.
+var builtinRegistryProfiles = func() []RegistryProfile {
+	profiles, err := ParseRegistryProfiles(builtinRegistryProfilesJSON)
+	if err != nil {
+		panic(err)
+	}
+	return profiles
+}()
+
+var (
+	registryProfilesMu   sync.RWMutex
+	userRegistryProfiles []RegistryProfile
+	selectedProfiles     = []string{DefaultRegistryProfile}
+	userRegistryKeys     []RegistryKey
+)
+
+// SetUserRegistryProfiles sets the profiles added by the user. A profile with the name of a
+// built-in one replaces it.
+func SetUserRegistryProfiles(profiles []RegistryProfile) {
+	registryProfilesMu.Lock()
+	defer registryProfilesMu.Unlock()
+	userRegistryProfiles = append([]RegistryProfile(nil), profiles...)
+}
+
+// RegistryProfiles returns the built-in profiles, as replaced by the user's, followed by the
+// user's own, sorted by name after the default profile.
+func RegistryProfiles() []RegistryProfile {
+	registryProfilesMu.RLock()
+	defer registryProfilesMu.RUnlock()
+	return registryProfiles()
+}
+
+func registryProfiles() []RegistryProfile {
+	byName := make(map[string]RegistryProfile)
+	for _, profile := range builtinRegistryProfiles {
+		byName[profile.Name] = profile
+	}
+	for _, profile := range userRegistryProfiles {
+		byName[profile.Name] = profile
+	}
+	profiles := make([]RegistryProfile, 0, len(byName))
+	for _, profile := range byName {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if (profiles[i].Name == DefaultRegistryProfile) != (profiles[j].Name == DefaultRegistryProfile) {
+			return profiles[i].Name == DefaultRegistryProfile
+		}
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles
+}
+
+// SetRegistryExport selects the profiles to export, by name, and additional keys to export with
+// them. Selecting no profiles exports only the additional keys; unknown profile names are
+// reported by RegistryKeysToExport.
+func SetRegistryExport(profiles []string, keys []RegistryKey) {
+	registryProfilesMu.Lock()
+	defer registryProfilesMu.Unlock()
+	selectedProfiles = append([]string(nil), profiles...)
+	userRegistryKeys = append([]RegistryKey(nil), keys...)
+}
+
+// RegistryExportSelection returns the selected profile names and additional keys.
+func RegistryExportSelection() ([]string, []RegistryKey) {
+	registryProfilesMu.RLock()
+	defer registryProfilesMu.RUnlock()
+	return append([]string(nil), selectedProfiles...), append([]RegistryKey(nil), userRegistryKeys...)
+}
+
+// RegistryKeysToExport returns the keys of the selected profiles followed by the additional
+// keys. A key listed more than once is exported once, and keys sharing a name get a numbered
+// suffix so that their .reg files do not overwrite each other.
+func RegistryKeysToExport() ([]RegistryKey, error) {
+	registryProfilesMu.RLock()
+	defer registryProfilesMu.RUnlock()
+
+	byName := make(map[string]RegistryProfile)
+	for _, profile := range registryProfiles() {
+		byName[profile.Name] = profile
+	}
+	var keys []RegistryKey
+	for _, name := range selectedProfiles {
+		profile, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown registry profile %q", name)
+		}
+		keys = append(keys, profile.Keys...)
+	}
+	for _, key := range userRegistryKeys {
+		if err := key.Validate(); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	var unique []RegistryKey
+	paths := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, key := range keys {
+		path := strings.ToUpper(normalizeRegistryPath(key.Path))
+		if paths[path] {
+			continue
+		}
+		paths[path] = true
+		name := key.Name
+		for n := 2; names[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s_%d", key.Name, n)
+		}
+		names[strings.ToLower(name)] = true
+		unique = append(unique, RegistryKey{Name: name, Path: key.Path})
+	}
+	return unique, nil
+}
+
+// normalizeRegistryPath writes the root key of path in its short form, so that
+// "HKEY_LOCAL_MACHINE\SOFTWARE" and "HKLM\SOFTWARE" compare equal.
+func normalizeRegistryPath(path string) string {
+	hive, subkey, found := strings.Cut(path, `\`)
+	if short, ok := registryHives[strings.ToUpper(hive)]; ok {
+		hive = short
+	}
+	if !found {
+		return hive
+	}
+	return hive + `\` + subkey
+}
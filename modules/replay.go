@@ -0,0 +1,141 @@
+package modules
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// maxFixtureNameLength caps fixture file names; longer command lines (mostly PowerShell
+// one-liners) are truncated and suffixed with a hash so they stay unique.
+const maxFixtureNameLength = 100
+
+// FixtureName returns the base file name, without extension, under which the output of
+// the given command line is stored by RecordingRunner and looked up by ReplayRunner.
+// For example `wmic bios get Manufacturer` becomes "wmic_bios_get_Manufacturer".
+func FixtureName(name string, args ...string) string {
+	commandLine := strings.Join(append([]string{name}, args...), " ")
+
+	var sanitized strings.Builder
+	for _, r := range commandLine {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == ',', r == '-', r == '=':
+			sanitized.WriteRune(r)
+		default:
+			sanitized.WriteRune('_')
+		}
+	}
+
+	fixture := sanitized.String()
+	if len(fixture) > maxFixtureNameLength {
+		sum := sha256.Sum256([]byte(commandLine))
+		fixture = fixture[:maxFixtureNameLength] + "_" + hex.EncodeToString(sum[:4])
+	}
+	return fixture
+}
+
+// ReplayRunner is a CommandRunner that serves canned output from fixture files instead of
+// running anything. For each command it reads "<FixtureName>.txt" from Dir; if a matching
+// "<FixtureName>.err" file exists, its contents are returned as the command's error.
+type ReplayRunner struct {
+	Dir string
+
+	mu    sync.Mutex
+	calls [][]string
+}
+
+// NewReplayRunner creates a ReplayRunner serving fixtures from dir.
+func NewReplayRunner(dir string) *ReplayRunner {
+	return &ReplayRunner{Dir: dir}
+}
+
+// Output returns the recorded output of the command.
+func (r *ReplayRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return r.replay(ctx, name, args)
+}
+
+// CombinedOutput returns the recorded output of the command.
+func (r *ReplayRunner) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return r.replay(ctx, name, args)
+}
+
+// Calls returns every command line requested so far, in order.
+func (r *ReplayRunner) Calls() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]string(nil), r.calls...)
+}
+
+func (r *ReplayRunner) replay(ctx context.Context, name string, args []string) ([]byte, error) {
+	r.mu.Lock()
+	r.calls = append(r.calls, append([]string{name}, args...))
+	r.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	base := filepath.Join(r.Dir, FixtureName(name, args...))
+	output, outErr := os.ReadFile(base + ".txt")
+	errText, errErr := os.ReadFile(base + ".err")
+
+	if errErr == nil {
+		return output, errors.New(strings.TrimSpace(string(errText)))
+	}
+	if outErr != nil {
+		return nil, fmt.Errorf("no fixture for command %q: %w", strings.Join(append([]string{name}, args...), " "), outErr)
+	}
+	return output, nil
+}
+
+// RecordingRunner wraps another CommandRunner and saves the output of every command it runs
+// into Dir, in the layout ReplayRunner expects. Run it once on a real machine to capture fixtures.
+type RecordingRunner struct {
+	Dir    string
+	Runner CommandRunner
+}
+
+// NewRecordingRunner creates a RecordingRunner that records commands run by r into dir.
+func NewRecordingRunner(dir string, r CommandRunner) *RecordingRunner {
+	return &RecordingRunner{Dir: dir, Runner: r}
+}
+
+// Output runs the command through the wrapped runner and records its standard output.
+func (r *RecordingRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	output, err := r.Runner.Output(ctx, name, args...)
+	return output, r.record(name, args, output, err)
+}
+
+// CombinedOutput runs the command through the wrapped runner and records its combined output.
+func (r *RecordingRunner) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	output, err := r.Runner.CombinedOutput(ctx, name, args...)
+	return output, r.record(name, args, output, err)
+}
+
+// record writes the fixture files and returns the command's original error, or the
+// error encountered while writing the fixtures.
+func (r *RecordingRunner) record(name string, args []string, output []byte, cmdErr error) error {
+	if err := os.MkdirAll(r.Dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create fixture directory '%s': %w", r.Dir, err)
+	}
+
+	base := filepath.Join(r.Dir, FixtureName(name, args...))
+	if err := os.WriteFile(base+".txt", output, 0644); err != nil {
+		return fmt.Errorf("failed to record fixture: %w", err)
+	}
+	if cmdErr == nil {
+		// Drop any error recorded by an earlier capture of the same command
+		os.Remove(base + ".err")
+		return nil
+	}
+	if err := os.WriteFile(base+".err", []byte(cmdErr.Error()), 0644); err != nil {
+		return fmt.Errorf("failed to record fixture: %w", err)
+	}
+	return cmdErr
+}
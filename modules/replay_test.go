@@ -0,0 +1,101 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// stubRunner answers every command with fixed output and error.
+type stubRunner struct {
+	output []byte
+	err    error
+}
+
+func (s stubRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return s.output, s.err
+}
+
+func (s stubRunner) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return s.output, s.err
+}
+
+func TestFixtureName(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"wmic", "bios", "get", "Manufacturer"}, "wmic_bios_get_Manufacturer"},
+		{[]string{"driverquery", "/FO", "CSV", "/v"}, "driverquery__FO_CSV__v"},
+		{[]string{"wmic", "path", "Win32_PnPEntity", "where", "PNPClass='USB'", "get", "Caption,DeviceID", "/format:list"}, "wmic_path_Win32_PnPEntity_where_PNPClass=_USB__get_Caption,DeviceID__format_list"},
+	}
+	for _, tt := range tests {
+		if got := FixtureName(tt.args[0], tt.args[1:]...); got != tt.want {
+			t.Errorf("FixtureName(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	long := FixtureName("powershell", "-Command", strings.Repeat("Get-Item ", 20))
+	if len(long) != maxFixtureNameLength+9 {
+		t.Errorf("long fixture name %q has %d characters, want %d", long, len(long), maxFixtureNameLength+9)
+	}
+	if other := FixtureName("powershell", "-Command", strings.Repeat("Get-Item ", 21)); other == long {
+		t.Errorf("command lines sharing a prefix both map to %q", long)
+	}
+}
+
+func TestRecordingRunnerReplays(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	recorder := NewRecordingRunner(dir, stubRunner{output: []byte("Manufacturer=Dell Inc.\r\r\n")})
+	if _, err := recorder.Output(ctx, "wmic", "bios", "get", "Manufacturer"); err != nil {
+		t.Fatal(err)
+	}
+	recorder.Runner = stubRunner{output: []byte("ERROR: not found\r\n"), err: errors.New("exit status 1")}
+	if _, err := recorder.CombinedOutput(ctx, "reg", "query", `HKCU\Missing`); err == nil || err.Error() != "exit status 1" {
+		t.Fatalf("recording returned %v, want the command's error", err)
+	}
+
+	replayer := NewReplayRunner(dir)
+	output, err := replayer.Output(ctx, "wmic", "bios", "get", "Manufacturer")
+	if err != nil || string(output) != "Manufacturer=Dell Inc.\r\r\n" {
+		t.Errorf("replayed %q, %v", output, err)
+	}
+	output, err = replayer.CombinedOutput(ctx, "reg", "query", `HKCU\Missing`)
+	if err == nil || err.Error() != "exit status 1" || string(output) != "ERROR: not found\r\n" {
+		t.Errorf("replayed %q, %v; want the recorded output and error", output, err)
+	}
+	if _, err := replayer.Output(ctx, "ipconfig", "/all"); err == nil {
+		t.Error("replaying a command without a fixture succeeded")
+	}
+
+	// A later successful capture drops the recorded error
+	recorder.Runner = stubRunner{output: []byte("HKEY_CURRENT_USER\\Missing\r\n")}
+	if _, err := recorder.CombinedOutput(ctx, "reg", "query", `HKCU\Missing`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replayer.CombinedOutput(ctx, "reg", "query", `HKCU\Missing`); err != nil {
+		t.Errorf("replayed a stale error: %v", err)
+	}
+
+	want := [][]string{
+		{"wmic", "bios", "get", "Manufacturer"},
+		{"reg", "query", `HKCU\Missing`},
+		{"ipconfig", "/all"},
+		{"reg", "query", `HKCU\Missing`},
+	}
+	if calls := replayer.Calls(); !reflect.DeepEqual(calls, want) {
+		t.Errorf("Calls() = %q, want %q", calls, want)
+	}
+}
+
+func TestReplayRunnerStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewReplayRunner(t.TempDir()).Output(ctx, "systeminfo"); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,120 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// reportFooter closes every text report GoDiag writes.
+const reportFooter = "\n\nReport generated by GoDiag. Learn more at https://github.com/LewdLillyVT/godiag"
+
+// Report is the structured result of a ReportCollector, such as a *BIOSReport or a *NetworkReport.
+// The plain-text files GoDiag writes are one rendering of it; other formats work from the same data.
+type Report interface {
+	// WriteText renders the report in GoDiag's plain-text layout, without the footer.
+	WriteText(output *bytes.Buffer)
+}
+
+// SectionError records a part of a report that could not be collected.
+type SectionError struct {
+	Section string `json:"section"`
+	Error   string `json:"error"`
+}
+
+// SectionErrors is the list of sections of a report that could not be collected.
+type SectionErrors []SectionError
+
+// Add records that section could not be collected because of err.
+func (e *SectionErrors) Add(section string, err error) {
+	*e = append(*e, SectionError{Section: section, Error: err.Error()})
+}
+
+// For returns the error recorded for section, if any.
+func (e SectionErrors) For(section string) (string, bool) {
+	for _, sectionErr := range e {
+		if sectionErr.Section == section {
+			return sectionErr.Error, true
+		}
+	}
+	return "", false
+}
+
+// writeTextReport renders report to path, noting when ctx stopped collection early.
+func writeTextReport(ctx context.Context, path string, report Report) error {
+	var output bytes.Buffer
+	report.WriteText(&output)
+	writeInterruptedNotice(ctx, &output)
+	output.WriteString(reportFooter)
+	return os.WriteFile(path, output.Bytes(), 0644)
+}
+
+// field is a single "Name: value" line in a text report.
+type field struct {
+	name  string
+	value string
+}
+
+// writeSection writes a "--- title ---" heading followed by either the error recorded for the
+// section or, if it was collected, whatever body writes.
+func writeSection(output *bytes.Buffer, title string, errs SectionErrors, body func()) {
+	output.WriteString(fmt.Sprintf("--- %s ---\n\n", title))
+	if errText, failed := errs.For(title); failed {
+		output.WriteString(fmt.Sprintf("Error gathering %s: %s\n", strings.ToLower(title), errText))
+	} else {
+		body()
+	}
+	output.WriteString("\n\n")
+}
+
+// writeFields writes one record as aligned "Name: value" lines followed by a blank line.
+// Fields with empty values are left out; multi-line values are indented under their name.
+func writeFields(output *bytes.Buffer, fields ...field) {
+	width := 0
+	for _, f := range fields {
+		if f.value != "" && len(f.name) > width {
+			width = len(f.name)
+		}
+	}
+
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		value := strings.ReplaceAll(f.value, "\n", "\n"+strings.Repeat(" ", width+2))
+		output.WriteString(fmt.Sprintf("%-*s %s\n", width+1, f.name+":", value))
+	}
+	output.WriteString("\n")
+}
+
+// writeNone notes that a collected section turned up no entries.
+func writeNone(output *bytes.Buffer, what string) {
+	output.WriteString(fmt.Sprintf("No %s found.\n", what))
+}
+
+// formatUnit formats n with a unit suffix, or returns an empty string when n is zero (unknown).
+func formatUnit(n int64, unit string) string {
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
+
+// formatBytes formats a byte count using binary units, or returns an empty string when it is zero.
+func formatBytes(n int64) string {
+	if n == 0 {
+		return ""
+	}
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,67 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"time"
+)
+
+// CommandRunner executes the external tools (wmic, ipconfig, tasklist, ...) the report
+// generators rely on. Swapping it out lets the generators run without those tools present.
+// Implementations must stop the command once ctx is done.
+type CommandRunner interface {
+	// Output runs the command and returns its standard output.
+	Output(ctx context.Context, name string, args ...string) ([]byte, error)
+	// CombinedOutput runs the command and returns its standard output and standard error combined.
+	CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner is the default CommandRunner. It runs commands with os/exec.
+type ExecRunner struct{}
+
+// Output runs the command with os/exec and returns its standard output.
+func (ExecRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return command(ctx, name, args...).Output()
+}
+
+// CombinedOutput runs the command with os/exec and returns its combined output.
+func (ExecRunner) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return command(ctx, name, args...).CombinedOutput()
+}
+
+// command builds an exec.Cmd that is killed when ctx is done. WaitDelay stops a killed command
+// from hanging on to its output pipes when it has spawned children of its own.
+func command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = 5 * time.Second
+	return cmd
+}
+
+// runner is the CommandRunner used by every report generator in this package.
+var runner CommandRunner = ExecRunner{}
+
+// SetCommandRunner replaces the CommandRunner used by the report generators.
+// Passing nil restores the default ExecRunner.
+func SetCommandRunner(r CommandRunner) {
+	if r == nil {
+		r = ExecRunner{}
+	}
+	runner = r
+}
+
+// GetCommandRunner returns the CommandRunner currently used by the report generators.
+func GetCommandRunner() CommandRunner {
+	return runner
+}
+
+// writeInterruptedNotice notes in a report that collection stopped early because ctx timed out
+// or was cancelled, so readers know the sections above it may be incomplete.
+func writeInterruptedNotice(ctx context.Context, output *bytes.Buffer) {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		output.WriteString("\n\nNOTE: Collection timed out. Some sections of this report may be incomplete.")
+	case context.Canceled:
+		output.WriteString("\n\nNOTE: Collection was cancelled. Some sections of this report may be incomplete.")
+	}
+}
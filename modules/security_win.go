@@ -0,0 +1,111 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+)
+
+var securityCollector = &reportCollector{
+	collectorInfo: collectorInfo{
+		id:            "security",
+		name:          "Generate Security and Antivirus Logs",
+		outputFiles:   []string{"Security_Antivirus_Logs.txt"},
+		requiresAdmin: true,
+	},
+	collect: collectFunc(CollectSecurityAndAntivirusLogs),
+}
+
+// ThreatDetection is a threat detected by Windows Defender, as listed by Get-MpThreatDetection.
+type ThreatDetection struct {
+	ThreatID             string `json:"threat_id"`
+	DetectionID          string `json:"detection_id,omitempty"`
+	InitialDetectionTime string `json:"initial_detection_time,omitempty"`
+	ProcessName          string `json:"process_name,omitempty"`
+	DomainUser           string `json:"domain_user,omitempty"`
+	Resources            string `json:"resources,omitempty"`
+	ActionSuccess        string `json:"action_success,omitempty"`
+	ThreatStatusID       string `json:"threat_status_id,omitempty"`
+}
+
+// SecurityReport is the structured result of the security and antivirus log report.
+type SecurityReport struct {
+	SecurityEvents   []Event           `json:"security_events"`
+	ThreatDetections []ThreatDetection `json:"threat_detections"`
+	Errors           SectionErrors     `json:"errors,omitempty"`
+}
+
+const (
+	sectionSecurityLogs = "Security Logs"
+	sectionDefenderLogs = "Windows Defender Logs"
+)
+
+// CollectSecurityAndAntivirusLogs extracts recent security and antivirus-related events.
+func CollectSecurityAndAntivirusLogs(ctx context.Context) (*SecurityReport, error) {
+	// Ensure the application has administrative privileges
+	if err := ensureAdminPrivileges(ctx); err != nil {
+		return nil, err
+	}
+
+	report := &SecurityReport{}
+
+	// Gather Security logs
+	securityLogs, err := runner.Output(ctx, "wevtutil", "qe", "Security", "/c:50", "/rd:true", "/f:text")
+	if err != nil {
+		report.Errors.Add(sectionSecurityLogs, err)
+	} else {
+		report.SecurityEvents = parseTextEvents(securityLogs)
+	}
+
+	// Gather Antivirus logs (specific to Windows Defender)
+	antivirusLogs, err := runner.Output(ctx, "powershell", "Get-MpThreatDetection")
+	if err != nil {
+		report.Errors.Add(sectionDefenderLogs, err)
+	} else {
+		for _, record := range parseListRecords(antivirusLogs, ":") {
+			values := propertyMap(record)
+			report.ThreatDetections = append(report.ThreatDetections, ThreatDetection{
+				ThreatID:             values["ThreatID"],
+				DetectionID:          values["DetectionID"],
+				InitialDetectionTime: values["InitialDetectionTime"],
+				ProcessName:          values["ProcessName"],
+				DomainUser:           values["DomainUser"],
+				Resources:            values["Resources"],
+				ActionSuccess:        values["ActionSuccess"],
+				ThreatStatusID:       values["ThreatStatusID"],
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// WriteText renders the report in GoDiag's plain-text layout.
+func (r *SecurityReport) WriteText(output *bytes.Buffer) {
+	writeSection(output, sectionSecurityLogs, r.Errors, func() {
+		writeEvents(output, r.SecurityEvents)
+	})
+
+	writeSection(output, sectionDefenderLogs, r.Errors, func() {
+		if len(r.ThreatDetections) == 0 {
+			writeNone(output, "threat detections")
+			return
+		}
+		for _, threat := range r.ThreatDetections {
+			writeFields(output,
+				field{"Threat ID", threat.ThreatID},
+				field{"Detection ID", threat.DetectionID},
+				field{"Detected", threat.InitialDetectionTime},
+				field{"Process", threat.ProcessName},
+				field{"User", threat.DomainUser},
+				field{"Resources", threat.Resources},
+				field{"Action Success", threat.ActionSuccess},
+				field{"Threat Status ID", threat.ThreatStatusID},
+			)
+		}
+	})
+}
+
+// GenerateSecurityAndAntivirusLogs extracts recent security and antivirus-related events.
+func GenerateSecurityAndAntivirusLogs(ctx context.Context, outputDir string) error {
+	return securityCollector.Run(ctx, outputDir)
+}
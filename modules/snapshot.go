@@ -0,0 +1,504 @@
+package modules
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sections compared by CompareSnapshots, in the order they are shown.
+const (
+	diffBIOS      = "BIOS"
+	diffDrivers   = "Drivers"
+	diffPrograms  = "Installed Programs"
+	diffServices  = "Services"
+	diffStartup   = "Startup Programs"
+	diffRegistry  = "Registry Exports"
+	registryFiles = "RegistryExports/*.reg"
+)
+
+var diffSections = []string{diffBIOS, diffDrivers, diffPrograms, diffServices, diffStartup, diffRegistry}
+
+// Snapshot is what one GoDiag run recorded about a machine, as loaded from its output directory,
+// zip bundle or JSON report for comparison with another run.
+type Snapshot struct {
+	Source    string
+	HostName  string
+	Generated time.Time
+
+	sections map[string][]diffRecord
+}
+
+// diffRecord is one entry of a snapshot section, such as a driver, with the fields compared.
+type diffRecord struct {
+	key    string // Identifies the entry across snapshots; compared without regard to case
+	fields []field
+}
+
+// LoadSnapshot loads a run from an output directory, a zip bundle, or a JSON report: either
+// godiag_report.json or the .json file of a single collector. The reports are read from their
+// JSON form, which every run writes to godiag_report.json; registry exports are read from the
+// .reg files themselves.
+func LoadSnapshot(source string) (*Snapshot, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{Source: source, sections: make(map[string][]diffRecord)}
+
+	switch {
+	case info.IsDir():
+		err = snapshot.loadFS(os.DirFS(source))
+	case strings.EqualFold(filepath.Ext(source), ".zip"):
+		var archive *zip.ReadCloser
+		if archive, err = zip.OpenReader(source); err == nil {
+			err = snapshot.loadFS(archive)
+			archive.Close()
+		}
+	default:
+		var data []byte
+		if data, err = os.ReadFile(source); err == nil {
+			err = snapshot.loadJSON(data)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", source, err)
+	}
+	if len(snapshot.sections) == 0 {
+		return nil, fmt.Errorf("no JSON reports or registry exports found in %s", source)
+	}
+	return snapshot, nil
+}
+
+// loadFS reads the JSON reports and registry exports at the top of an output directory or bundle.
+func (s *Snapshot) loadFS(fsys fs.FS) error {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return err
+	}
+	// Other JSON files, such as Run_Manifest.json and Findings.json, are not reports
+	known := reportFileNames()
+	var reports []string
+	for _, name := range names {
+		if known[strings.ToLower(name)] {
+			reports = append(reports, name)
+		}
+	}
+	// The per-collector files come after godiag_report.json and hold the same data when both exist
+	sort.SliceStable(reports, func(i, j int) bool { return reports[i] == CombinedReportFileName })
+	for _, name := range reports {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if err := s.loadJSON(data); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	exports, err := fs.Glob(fsys, registryFiles)
+	if err != nil {
+		return err
+	}
+	for _, name := range exports {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		s.sections[diffRegistry] = append(s.sections[diffRegistry], parseRegFile(data, path.Base(name))...)
+	}
+	return nil
+}
+
+// reportFileNames returns the lower-case names of the JSON reports a run can write:
+// godiag_report.json and the .json file of each collector that produces a report.
+func reportFileNames() map[string]bool {
+	names := map[string]bool{strings.ToLower(CombinedReportFileName): true}
+	for _, c := range Collectors() {
+		if _, ok := c.(ReportCollector); ok {
+			names[strings.ToLower(JSONFileName(c))] = true
+		}
+	}
+	return names
+}
+
+// loadJSON reads godiag_report.json or a single collector's JSON report. Other JSON files, such
+// as the run manifest, are ignored.
+func (s *Snapshot) loadJSON(data []byte) error {
+	var report struct {
+		HostName  string    `json:"host_name"`
+		Started   time.Time `json:"started"`
+		Generated time.Time `json:"generated"`
+		Sections  []struct {
+			Collector string          `json:"collector"`
+			Data      json.RawMessage `json:"data"`
+		} `json:"sections"`
+		Collector string          `json:"collector"`
+		Data      json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return err
+	}
+
+	if report.HostName != "" {
+		s.HostName = report.HostName
+	}
+	if s.Generated.IsZero() {
+		if !report.Started.IsZero() {
+			s.Generated = report.Started
+		} else {
+			s.Generated = report.Generated
+		}
+	}
+	for _, section := range report.Sections {
+		if err := s.addReport(section.Collector, section.Data); err != nil {
+			return err
+		}
+	}
+	if report.Collector != "" {
+		return s.addReport(report.Collector, report.Data)
+	}
+	return nil
+}
+
+// addReport turns the JSON data of a collector's report into the sections compared.
+func (s *Snapshot) addReport(collector string, data json.RawMessage) error {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	switch collector {
+	case "bios":
+		var report BIOSReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return err
+		}
+		var records []diffRecord
+		for i, bios := range report.BIOS {
+			records = append(records, diffRecord{key: fmt.Sprintf("BIOS %d", i+1), fields: []field{
+				{"Manufacturer", bios.Manufacturer}, {"Version", bios.Version}, {"Release Date", bios.ReleaseDate},
+			}})
+		}
+		s.sections[diffBIOS] = records
+	case "drivers":
+		var report DriverReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return err
+		}
+		var records []diffRecord
+		for _, d := range report.Drivers {
+			records = append(records, diffRecord{key: d.ModuleName, fields: []field{
+				{"Version", d.Version}, {"Link Date", d.LinkDate}, {"Start Mode", d.StartMode}, {"State", d.State}, {"Path", d.Path},
+			}})
+		}
+		s.sections[diffDrivers] = records
+	case "software":
+		var report SoftwareReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return err
+		}
+		var programs, services []diffRecord
+		for _, p := range report.Programs {
+			// Several versions of one program can be installed side by side, so each is its own entry
+			programs = append(programs, diffRecord{key: programKey(p), fields: []field{{"Publisher", p.Publisher}}})
+		}
+		for _, svc := range report.Services {
+			services = append(services, diffRecord{key: svc.Name, fields: []field{
+				{"Start Mode", svc.StartMode}, {"State", svc.State}, {"Path", svc.PathName},
+			}})
+		}
+		s.sections[diffPrograms] = programs
+		s.sections[diffServices] = services
+		// The startup report has the same entries; it is preferred when both are present
+		if _, ok := s.sections[diffStartup]; !ok {
+			s.sections[diffStartup] = startupRecords(report.StartupItems)
+		}
+	case "startup":
+		var report StartupReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return err
+		}
+		s.sections[diffStartup] = startupRecords(report.Items)
+	}
+	return nil
+}
+
+// programKey identifies an installed program by its name and version, e.g. "Microsoft Edge 129.0.2792.52".
+func programKey(p InstalledProgram) string {
+	if p.Version == "" {
+		return p.Name
+	}
+	return p.Name + " " + p.Version
+}
+
+func startupRecords(items []StartupItem) []diffRecord {
+	records := []diffRecord{}
+	for _, item := range items {
+		records = append(records, diffRecord{key: item.Location + `\` + item.Name, fields: []field{{"Command", item.Command}}})
+	}
+	return records
+}
+
+// parseRegFile parses a `reg export` file into one record per value, keyed by the registry key
+// and value name. Value data is compared in its exported form, e.g. dword:00000001.
+func parseRegFile(data []byte, file string) []diffRecord {
+	var records []diffRecord
+	key := ""
+	var pending string
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		line = strings.TrimSpace(line)
+		// Long hex values are continued on the next line after a trailing backslash
+		if strings.HasSuffix(line, `\`) && !strings.HasSuffix(line, `"`) {
+			pending += strings.TrimSuffix(line, `\`)
+			continue
+		}
+		line, pending = pending+line, ""
+
+		switch {
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			key = line[1 : len(line)-1]
+		case key == "" || line == "":
+		case strings.HasPrefix(line, "@="):
+			records = append(records, diffRecord{key: key + `\(Default)`, fields: []field{{"Data", line[2:]}, {"File", file}}})
+		case strings.HasPrefix(line, `"`):
+			if name, value, ok := cutRegValueName(line); ok {
+				records = append(records, diffRecord{key: key + `\` + name, fields: []field{{"Data", value}, {"File", file}}})
+			}
+		}
+	}
+	return records
+}
+
+// cutRegValueName splits a `"name"=data` line, unescaping the quoted name.
+func cutRegValueName(line string) (name, value string, ok bool) {
+	var b strings.Builder
+	for i := 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if i+1 < len(line) {
+				i++
+				b.WriteByte(line[i])
+			}
+		case '"':
+			if rest, found := strings.CutPrefix(line[i+1:], "="); found {
+				return b.String(), rest, true
+			}
+			return "", "", false
+		default:
+			b.WriteByte(line[i])
+		}
+	}
+	return "", "", false
+}
+
+// SnapshotInfo describes one side of a comparison.
+type SnapshotInfo struct {
+	Source    string `json:"source"`
+	HostName  string `json:"host_name,omitempty"`
+	Generated string `json:"generated,omitempty"`
+}
+
+// SnapshotDiff lists what changed between two runs.
+type SnapshotDiff struct {
+	Old      SnapshotInfo  `json:"old"`
+	New      SnapshotInfo  `json:"new"`
+	Sections []DiffSection `json:"sections"`
+}
+
+// DiffSection lists the entries added, removed and changed in one part of the snapshots.
+type DiffSection struct {
+	Name    string      `json:"name"`
+	Note    string      `json:"note,omitempty"` // Why the section could not be compared
+	Added   []DiffEntry `json:"added,omitempty"`
+	Removed []DiffEntry `json:"removed,omitempty"`
+	Changed []DiffEntry `json:"changed,omitempty"`
+}
+
+// DiffEntry is an entry that was added, removed or changed.
+type DiffEntry struct {
+	Name    string        `json:"name"`
+	Summary string        `json:"summary,omitempty"` // The entry's fields, for added and removed entries
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is a field whose value differs between the two runs.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Empty reports whether nothing changed in the section.
+func (s DiffSection) Empty() bool {
+	return len(s.Added) == 0 && len(s.Removed) == 0 && len(s.Changed) == 0
+}
+
+func (s *Snapshot) info() SnapshotInfo {
+	info := SnapshotInfo{Source: s.Source, HostName: s.HostName}
+	if !s.Generated.IsZero() {
+		info.Generated = s.Generated.Local().Format("2006-01-02 15:04:05")
+	}
+	return info
+}
+
+// CompareSnapshots lists the drivers, programs, services, startup entries, registry values and
+// BIOS versions that were added, removed or changed from older to newer.
+func CompareSnapshots(older, newer *Snapshot) *SnapshotDiff {
+	diff := &SnapshotDiff{Old: older.info(), New: newer.info()}
+	for _, name := range diffSections {
+		oldRecords, inOld := older.sections[name]
+		newRecords, inNew := newer.sections[name]
+		section := DiffSection{Name: name}
+		switch {
+		case !inOld && !inNew:
+			section.Note = "Not recorded in either run."
+		case !inOld:
+			section.Note = "Not recorded in the old run."
+		case !inNew:
+			section.Note = "Not recorded in the new run."
+		default:
+			section = diffRecords(name, oldRecords, newRecords)
+		}
+		diff.Sections = append(diff.Sections, section)
+	}
+	return diff
+}
+
+// diffRecords compares two lists of records by key.
+func diffRecords(name string, older, newer []diffRecord) DiffSection {
+	section := DiffSection{Name: name}
+	oldByKey := indexRecords(older)
+	newByKey := indexRecords(newer)
+
+	for _, key := range sortedKeys(newByKey) {
+		record := newByKey[key]
+		before, ok := oldByKey[key]
+		if !ok {
+			section.Added = append(section.Added, DiffEntry{Name: record.key, Summary: summarizeFields(record.fields)})
+			continue
+		}
+		var changes []FieldChange
+		for i, f := range record.fields {
+			if i < len(before.fields) && before.fields[i].value != f.value {
+				changes = append(changes, FieldChange{Field: f.name, Old: before.fields[i].value, New: f.value})
+			}
+		}
+		if len(changes) > 0 {
+			section.Changed = append(section.Changed, DiffEntry{Name: record.key, Changes: changes})
+		}
+	}
+	for _, key := range sortedKeys(oldByKey) {
+		if _, ok := newByKey[key]; !ok {
+			record := oldByKey[key]
+			section.Removed = append(section.Removed, DiffEntry{Name: record.key, Summary: summarizeFields(record.fields)})
+		}
+	}
+	return section
+}
+
+// indexRecords maps records by lower-case key. Entries sharing a key, such as one program
+// installed twice under the same name and version, are told apart by a " #2" suffix in the order they were listed.
+func indexRecords(records []diffRecord) map[string]diffRecord {
+	byKey := make(map[string]diffRecord, len(records))
+	for _, record := range records {
+		key := strings.ToLower(record.key)
+		for n := 2; ; n++ {
+			if _, taken := byKey[key]; !taken {
+				break
+			}
+			key = fmt.Sprintf("%s #%d", strings.ToLower(record.key), n)
+			record.key = fmt.Sprintf("%s #%d", record.key, n)
+		}
+		byKey[key] = record
+	}
+	return byKey
+}
+
+func sortedKeys(records map[string]diffRecord) []string {
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// summarizeFields joins the non-empty fields of a record into one line.
+func summarizeFields(fields []field) string {
+	var parts []string
+	for _, f := range fields {
+		if f.value != "" {
+			parts = append(parts, f.name+": "+f.value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WriteText renders the comparison in GoDiag's plain-text layout.
+func (d *SnapshotDiff) WriteText(output *bytes.Buffer) {
+	for _, side := range []struct {
+		label string
+		info  SnapshotInfo
+	}{{"Old", d.Old}, {"New", d.New}} {
+		description := side.info.Source
+		if side.info.HostName != "" || side.info.Generated != "" {
+			description += fmt.Sprintf(" (%s)", strings.TrimSpace(side.info.HostName+" "+side.info.Generated))
+		}
+		output.WriteString(fmt.Sprintf("%s: %s\n", side.label, description))
+	}
+	output.WriteString("\n")
+
+	for _, section := range d.Sections {
+		writeSection(output, section.Name, nil, func() {
+			switch {
+			case section.Note != "":
+				output.WriteString(section.Note + "\n")
+				return
+			case section.Empty():
+				output.WriteString("No changes.\n")
+				return
+			}
+			groups := []struct {
+				title, marker string
+				entries       []DiffEntry
+			}{{"Added", "+", section.Added}, {"Removed", "-", section.Removed}, {"Changed", "~", section.Changed}}
+			separator := ""
+			for _, group := range groups {
+				if len(group.entries) > 0 {
+					output.WriteString(separator)
+					writeDiffEntries(output, group.title, group.marker, group.entries)
+					separator = "\n"
+				}
+			}
+		})
+	}
+}
+
+func writeDiffEntries(output *bytes.Buffer, title, marker string, entries []DiffEntry) {
+	output.WriteString(fmt.Sprintf("%s (%d):\n", title, len(entries)))
+	for _, entry := range entries {
+		line := fmt.Sprintf("  %s %s", marker, entry.Name)
+		if entry.Summary != "" {
+			line += " (" + entry.Summary + ")"
+		}
+		output.WriteString(line + "\n")
+		for _, change := range entry.Changes {
+			output.WriteString(fmt.Sprintf("      %s: %s -> %s\n", change.Field, orNone(change.Old), orNone(change.New)))
+		}
+	}
+}
+
+// orNone stands in for an empty value in a change.
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
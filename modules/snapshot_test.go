@@ -0,0 +1,147 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeSnapshotDir writes the given files, JSON-encoding any value that is not already bytes.
+func writeSnapshotDir(t *testing.T, files map[string]interface{}) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, v := range files {
+		data, ok := v.([]byte)
+		if !ok {
+			var err error
+			if data, err = json.Marshal(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadSnapshotReadsOnlyReports(t *testing.T) {
+	generated := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	dir := writeSnapshotDir(t, map[string]interface{}{
+		"Driver_Report.json": JSONReport{Collector: "drivers", Generated: generated, Data: &DriverReport{
+			Drivers: []DriverEntry{{ModuleName: "nvlddmkm", Version: "31.0.15.5222"}},
+		}},
+		// None of these is a report, though the manifest has the fields a report has
+		ManifestFileName:     map[string]interface{}{"started": "2020-01-01T00:00:00Z", "collector": "drivers", "data": map[string]interface{}{"drivers": "not a list"}},
+		FindingsJSONFileName: FindingsFile{Findings: []Finding{{Rule: "driver-old", Collector: "drivers"}}},
+		"known_issues.json":  []byte(`[{"id":"stray"}]`),
+	})
+
+	snapshot, err := LoadSnapshot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snapshot.Generated.Equal(generated) {
+		t.Errorf("Generated = %v, want %v from the driver report", snapshot.Generated, generated)
+	}
+	drivers := snapshot.sections[diffDrivers]
+	if len(drivers) != 1 || drivers[0].key != "nvlddmkm" {
+		t.Errorf("drivers = %+v", drivers)
+	}
+	if len(snapshot.sections) != 1 {
+		t.Errorf("loaded sections %v, want only %s", reflect.ValueOf(snapshot.sections).MapKeys(), diffDrivers)
+	}
+}
+
+func TestCompareSnapshotsProgramVersions(t *testing.T) {
+	software := func(programs ...InstalledProgram) map[string]interface{} {
+		return map[string]interface{}{
+			"Software_Diagnostics_Report.json": JSONReport{Collector: "software", Data: &SoftwareReport{Programs: programs}},
+		}
+	}
+	runtime2019 := InstalledProgram{Name: "Microsoft Visual C++ Redistributable", Version: "14.29.30133", Publisher: "Microsoft Corporation"}
+	runtime2022 := InstalledProgram{Name: "Microsoft Visual C++ Redistributable", Version: "14.38.33130", Publisher: "Microsoft Corporation"}
+	edge := InstalledProgram{Name: "Microsoft Edge", Version: "128.0.2739.79", Publisher: "Microsoft Corporation"}
+	newEdge := InstalledProgram{Name: "Microsoft Edge", Version: "129.0.2792.52", Publisher: "Microsoft Corporation"}
+
+	older, err := LoadSnapshot(writeSnapshotDir(t, software(runtime2019, runtime2022, edge)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer, err := LoadSnapshot(writeSnapshotDir(t, software(runtime2022, newEdge)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var programs DiffSection
+	for _, section := range CompareSnapshots(older, newer).Sections {
+		if section.Name == diffPrograms {
+			programs = section
+		}
+	}
+	var added, removed []string
+	for _, entry := range programs.Added {
+		added = append(added, entry.Name)
+	}
+	for _, entry := range programs.Removed {
+		removed = append(removed, entry.Name)
+	}
+	// Removing one of two side-by-side versions removes that version rather than changing the other
+	if want := []string{"Microsoft Edge 129.0.2792.52"}; !reflect.DeepEqual(added, want) {
+		t.Errorf("added %q, want %q", added, want)
+	}
+	if want := []string{"Microsoft Edge 128.0.2739.79", "Microsoft Visual C++ Redistributable 14.29.30133"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed %q, want %q", removed, want)
+	}
+	if len(programs.Changed) != 0 {
+		t.Errorf("changed %+v, want none", programs.Changed)
+	}
+}
+
+func TestCompareRunsWithDefaultSettings(t *testing.T) {
+	defer SetJSONExport(JSONExportEnabled())
+	SetJSONExport(false)
+
+	// Collect the drivers report twice, as "Run All Diagnostics" does, with a driver updated in between
+	run := func(drivers ...DriverEntry) string {
+		t.Helper()
+		collector := &reportCollector{
+			collectorInfo: collectorInfo{id: "drivers", name: "Driver Report", outputFiles: []string{"Driver_Report.txt"}},
+			collect: func(ctx context.Context, outputDir string) (Report, error) {
+				return &DriverReport{Drivers: drivers}, nil
+			},
+		}
+		dir := t.TempDir()
+		if _, err := RunCollectors(context.Background(), dir, []Collector{collector}, nil); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "Driver_Report.json")); !os.IsNotExist(err) {
+			t.Errorf("Driver_Report.json written with the JSON export off: %v", err)
+		}
+		return dir
+	}
+	olderDir := run(DriverEntry{ModuleName: "nvlddmkm", Version: "31.0.15.5222"}, DriverEntry{ModuleName: "e1dexpress", Version: "12.19.1.37"})
+	newerDir := run(DriverEntry{ModuleName: "nvlddmkm", Version: "32.0.15.6094"}, DriverEntry{ModuleName: "e1dexpress", Version: "12.19.1.37"})
+
+	older, err := LoadSnapshot(olderDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer, err := LoadSnapshot(newerDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, section := range CompareSnapshots(older, newer).Sections {
+		if section.Name != diffDrivers {
+			continue
+		}
+		want := []DiffEntry{{Name: "nvlddmkm", Changes: []FieldChange{{Field: "Version", Old: "31.0.15.5222", New: "32.0.15.6094"}}}}
+		if section.Note != "" || len(section.Added) != 0 || len(section.Removed) != 0 || !reflect.DeepEqual(section.Changed, want) {
+			t.Errorf("drivers section = %+v\nwant only the changed version of nvlddmkm", section)
+		}
+	}
+}
@@ -2,73 +2,121 @@ package modules
 
 import (
 	"bytes"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"runtime"
+	"context"
 )
 
-// generateQuickSysInfo gathers basic system information like CPU, GPU, RAM, and saves it to a text file.
-func GenerateQuickSysInfo(outputDir string) error {
-	var output bytes.Buffer
-	outputPath := filepath.Join(outputDir, "Quick_System_Info.txt")
-
-	// Collect CPU information
-	output.WriteString("CPU Information:\n")
-	cpuInfo, err := exec.Command("wmic", "cpu", "get", "Name,MaxClockSpeed,Manufacturer").Output()
-	if err != nil {
-		output.WriteString("Error gathering CPU information.\n")
-	} else {
-		output.Write(cpuInfo)
-	}
+var sysInfoCollector = &reportCollector{
+	collectorInfo: collectorInfo{
+		id:          "sysinfo",
+		name:        "Generate Quick System Info",
+		outputFiles: []string{"Quick_System_Info.txt"},
+	},
+	collect: collectFunc(CollectQuickSysInfo),
+}
 
-	// Collect GPU information
-	output.WriteString("\nGPU Information:\n")
-	gpuInfo, err := exec.Command("wmic", "path", "win32_videocontroller", "get", "name,driverversion").Output()
-	if err != nil {
-		output.WriteString("Error gathering GPU information.\n")
-	} else {
-		output.Write(gpuInfo)
-	}
+func init() {
+	Register(sysInfoCollector)
+}
 
-	// Collect RAM information
-	output.WriteString("\nRAM Information:\n")
-	ramInfo, err := exec.Command("wmic", "memorychip", "get", "capacity,manufacturer,partnumber,speed").Output()
-	if err != nil {
-		output.WriteString("Error gathering RAM information.\n")
-	} else {
-		output.Write(ramInfo)
-	}
+// CPUInfo describes a processor.
+type CPUInfo struct {
+	Name             string `json:"name" wmi:"Name"`
+	Manufacturer     string `json:"manufacturer,omitempty" wmi:"Manufacturer"`
+	MaxClockSpeedMHz int64  `json:"max_clock_speed_mhz,omitempty" wmi:"MaxClockSpeed"`
+}
 
-	// Collect Motherboard and BIOS information
-	output.WriteString("\nMotherboard and BIOS Information:\n")
-	moboInfo, err := exec.Command("wmic", "baseboard", "get", "product,manufacturer").Output()
-	if err != nil {
-		output.WriteString("Error gathering Motherboard information.\n")
-	} else {
-		output.Write(moboInfo)
-	}
-	biosInfo, err := exec.Command("wmic", "bios", "get", "version,serialnumber").Output()
-	if err != nil {
-		output.WriteString("Error gathering BIOS information.\n")
-	} else {
-		output.Write(biosInfo)
-	}
+// GPUInfo describes a video controller.
+type GPUInfo struct {
+	Name          string `json:"name" wmi:"Name"`
+	DriverVersion string `json:"driver_version,omitempty" wmi:"DriverVersion"`
+}
+
+// MemoryModule describes an installed memory module.
+type MemoryModule struct {
+	CapacityBytes int64  `json:"capacity_bytes" wmi:"Capacity"`
+	Manufacturer  string `json:"manufacturer,omitempty" wmi:"Manufacturer"`
+	PartNumber    string `json:"part_number,omitempty" wmi:"PartNumber"`
+	SpeedMHz      int64  `json:"speed_mhz,omitempty" wmi:"Speed"`
+}
+
+// BoardInfo describes the motherboard.
+type BoardInfo struct {
+	Manufacturer string `json:"manufacturer,omitempty" wmi:"Manufacturer"`
+	Product      string `json:"product,omitempty" wmi:"Product"`
+}
+
+// SystemInfo is the structured result of the quick system information report.
+type SystemInfo struct {
+	CPUs   []CPUInfo      `json:"cpus"`
+	GPUs   []GPUInfo      `json:"gpus"`
+	Memory []MemoryModule `json:"memory"`
+	Boards []BoardInfo    `json:"boards"`
+	BIOS   []BIOSInfo     `json:"bios"`
+	OS     []Property     `json:"os,omitempty"` // systeminfo output (os-release and kernel details on Linux), in its original order
+	Errors SectionErrors  `json:"errors,omitempty"`
+}
+
+const (
+	sectionCPU   = "CPU Information"
+	sectionGPU   = "GPU Information"
+	sectionRAM   = "RAM Information"
+	sectionBoard = "Motherboard Information"
+	sectionOS    = "OS Information"
+)
 
-	// Additional system details if on Windows
-	if runtime.GOOS == "windows" {
-		output.WriteString("\nOS Information:\n")
-		osInfo, err := exec.Command("systeminfo").Output()
-		if err != nil {
-			output.WriteString("Error gathering OS information.\n")
-		} else {
-			output.Write(osInfo)
+// WriteText renders the report in GoDiag's plain-text layout.
+func (r *SystemInfo) WriteText(output *bytes.Buffer) {
+	writeSection(output, sectionCPU, r.Errors, func() {
+		for _, cpu := range r.CPUs {
+			writeFields(output,
+				field{"Name", cpu.Name},
+				field{"Manufacturer", cpu.Manufacturer},
+				field{"Max Clock Speed", formatUnit(cpu.MaxClockSpeedMHz, "MHz")},
+			)
 		}
-	}
+	})
+
+	writeSection(output, sectionGPU, r.Errors, func() {
+		for _, gpu := range r.GPUs {
+			writeFields(output, field{"Name", gpu.Name}, field{"Driver Version", gpu.DriverVersion})
+		}
+	})
+
+	writeSection(output, sectionRAM, r.Errors, func() {
+		for _, module := range r.Memory {
+			writeFields(output,
+				field{"Capacity", formatBytes(module.CapacityBytes)},
+				field{"Manufacturer", module.Manufacturer},
+				field{"Part Number", module.PartNumber},
+				field{"Speed", formatUnit(module.SpeedMHz, "MHz")},
+			)
+		}
+	})
+
+	writeSection(output, sectionBoard, r.Errors, func() {
+		for _, board := range r.Boards {
+			writeFields(output, field{"Manufacturer", board.Manufacturer}, field{"Product", board.Product})
+		}
+	})
 
-	// Append footer
-	output.WriteString("\n\nReport generated by GoDiag. Learn more at https://github.com/LewdLillyVT/godiag")
+	writeSection(output, sectionBIOS, r.Errors, func() {
+		for _, bios := range r.BIOS {
+			writeFields(output, field{"Version", bios.Version}, field{"Serial Number", bios.SerialNumber})
+		}
+	})
+
+	if len(r.OS) > 0 || len(r.Errors) > 0 {
+		writeSection(output, sectionOS, r.Errors, func() {
+			fields := make([]field, 0, len(r.OS))
+			for _, p := range r.OS {
+				fields = append(fields, field{p.Name, p.Value})
+			}
+			writeFields(output, fields...)
+		})
+	}
+}
 
-	// Save to file
-	return os.WriteFile(outputPath, output.Bytes(), 0644)
+// GenerateQuickSysInfo gathers basic system information like CPU, GPU, RAM, and saves it to a text file.
+func GenerateQuickSysInfo(ctx context.Context, outputDir string) error {
+	return sysInfoCollector.Run(ctx, outputDir)
 }
@@ -0,0 +1,253 @@
+package modules
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// CollectQuickSysInfo gathers basic system information like CPU, GPU and RAM.
+func CollectQuickSysInfo(ctx context.Context) (*SystemInfo, error) {
+	info := &SystemInfo{}
+
+	// Collect CPU information
+	if cpuInfo, err := os.ReadFile("/proc/cpuinfo"); err != nil {
+		info.Errors.Add(sectionCPU, err)
+	} else {
+		info.CPUs = parseCPUInfo(cpuInfo)
+		// cpuinfo_max_freq is in kHz
+		maxMHz := readSysInt("/sys/devices/system/cpu/cpu0/cpufreq/cpuinfo_max_freq") / 1000
+		for i := range info.CPUs {
+			info.CPUs[i].MaxClockSpeedMHz = maxMHz
+		}
+	}
+
+	// Collect GPU information
+	if gpus, err := collectGPUs(ctx); err != nil {
+		info.Errors.Add(sectionGPU, err)
+	} else {
+		info.GPUs = gpus
+	}
+
+	// Collect RAM information. dmidecode lists the modules but needs root; without it
+	// only the total is known.
+	if dmi, err := runner.Output(ctx, "dmidecode", "-t", "memory"); err == nil {
+		info.Memory = parseDMIMemory(dmi)
+	}
+	if len(info.Memory) == 0 {
+		if memInfo, err := os.ReadFile("/proc/meminfo"); err != nil {
+			info.Errors.Add(sectionRAM, err)
+		} else {
+			info.Memory = append(info.Memory, MemoryModule{CapacityBytes: parseMemInfo(memInfo)["MemTotal"]})
+		}
+	}
+
+	// Collect Motherboard and BIOS information
+	if _, err := os.Stat(dmiDir); err != nil {
+		info.Errors.Add(sectionBoard, err)
+		info.Errors.Add(sectionBIOS, err)
+	} else {
+		info.Boards = append(info.Boards, BoardInfo{Manufacturer: readDMI("board_vendor"), Product: readDMI("board_name")})
+		info.BIOS = append(info.BIOS, BIOSInfo{Version: readDMI("bios_version"), SerialNumber: readDMI("product_serial")})
+	}
+
+	// Additional system details
+	if osRelease, err := readOSRelease(); err != nil {
+		info.Errors.Add(sectionOS, err)
+	} else {
+		info.OS = osProperties(osRelease)
+	}
+
+	return info, nil
+}
+
+// parseCPUInfo returns one entry per physical processor package listed in /proc/cpuinfo.
+func parseCPUInfo(data []byte) []CPUInfo {
+	var cpus []CPUInfo
+	seen := make(map[string]bool)
+	for _, record := range parseListRecords(data, ":") {
+		values := propertyMap(record)
+		name := values["model name"]
+		if name == "" {
+			name = values["Model"] // ARM boards name the machine rather than each core
+		}
+		if name == "" || seen[values["physical id"]] {
+			continue
+		}
+		seen[values["physical id"]] = true
+		cpus = append(cpus, CPUInfo{Name: name, Manufacturer: values["vendor_id"]})
+	}
+	return cpus
+}
+
+// collectGPUs lists display controllers using the names lspci resolves from the PCI ID database.
+func collectGPUs(ctx context.Context) ([]GPUInfo, error) {
+	data, err := runner.Output(ctx, "lspci", "-mm")
+	if err != nil {
+		return nil, err
+	}
+
+	// Each line is a slot followed by quoted class, vendor and device names:
+	// 00:02.0 "VGA compatible controller" "Intel Corporation" "UHD Graphics 620" -r07 "Lenovo" "Device 2249"
+	reader := csv.NewReader(strings.NewReader(normalizeOutput(data)))
+	reader.Comma = ' '
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lspci output: %w", err)
+	}
+
+	var gpus []GPUInfo
+	for _, row := range rows {
+		class := column(row, 1)
+		if class != "VGA compatible controller" && class != "3D controller" && class != "Display controller" {
+			continue
+		}
+		gpus = append(gpus, GPUInfo{
+			Name:          strings.TrimSpace(column(row, 2) + " " + column(row, 3)),
+			DriverVersion: pciDriverVersion(column(row, 0)),
+		})
+	}
+	return gpus, nil
+}
+
+// pciDriverVersion describes the driver bound to a PCI device. In-tree drivers carry no version
+// of their own, so the kernel release stands in for it.
+func pciDriverVersion(slot string) string {
+	if strings.Count(slot, ":") == 1 {
+		slot = "0000:" + slot
+	}
+	target, err := os.Readlink(filepath.Join("/sys/bus/pci/devices", slot, "driver"))
+	if err != nil {
+		return ""
+	}
+	driver := filepath.Base(target)
+	if version := readSysFile("/sys/module", driver, "version"); version != "" {
+		return driver + " " + version
+	}
+	return fmt.Sprintf("%s (kernel %s)", driver, readSysFile("/proc/sys/kernel/osrelease"))
+}
+
+// parseDMIMemory parses the "Memory Device" entries printed by `dmidecode -t memory`, skipping empty slots.
+func parseDMIMemory(data []byte) []MemoryModule {
+	var modules []MemoryModule
+	for _, block := range strings.Split(normalizeOutput(data), "\n\n") {
+		if !strings.Contains(block, "\nMemory Device\n") {
+			continue
+		}
+		values := make(map[string]string)
+		for _, line := range strings.Split(block, "\n") {
+			if name, value, found := strings.Cut(strings.TrimSpace(line), ": "); found {
+				values[name] = strings.TrimSpace(value)
+			}
+		}
+		size := parseDMISize(values["Size"])
+		if size == 0 {
+			continue
+		}
+		speed, _, _ := strings.Cut(values["Speed"], " ") // "3200 MT/s", or missing on some boards
+		module := MemoryModule{
+			CapacityBytes: size,
+			Manufacturer:  values["Manufacturer"],
+			PartNumber:    values["Part Number"],
+			SpeedMHz:      parseInt(speed),
+		}
+		for _, placeholder := range []string{"Unknown", "Not Specified"} {
+			if module.Manufacturer == placeholder {
+				module.Manufacturer = ""
+			}
+			if module.PartNumber == placeholder {
+				module.PartNumber = ""
+			}
+		}
+		modules = append(modules, module)
+	}
+	return modules
+}
+
+// parseDMISize converts a dmidecode size such as "8 GB" or "8192 MB" to bytes, returning zero
+// for "No Module Installed".
+func parseDMISize(value string) int64 {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return 0
+	}
+	n := parseInt(fields[0])
+	switch fields[1] {
+	case "kB", "KB":
+		return n << 10
+	case "MB":
+		return n << 20
+	case "GB":
+		return n << 30
+	case "TB":
+		return n << 40
+	}
+	return 0
+}
+
+// readOSRelease reads the distribution's os-release file.
+func readOSRelease() (map[string]string, error) {
+	data, err := os.ReadFile("/etc/os-release")
+	if os.IsNotExist(err) {
+		data, err = os.ReadFile("/usr/lib/os-release")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseKeyValueFile(data), nil
+}
+
+// osProperties lists the operating system details systeminfo would show on Windows.
+func osProperties(osRelease map[string]string) []Property {
+	name := osRelease["PRETTY_NAME"]
+	if name == "" {
+		name = strings.TrimSpace(osRelease["NAME"] + " " + osRelease["VERSION"])
+	}
+	memInfo, _ := os.ReadFile("/proc/meminfo")
+	meminfo := parseMemInfo(memInfo)
+
+	properties := []Property{
+		{Name: "Host Name", Value: readSysFile("/proc/sys/kernel/hostname")},
+		{Name: "OS Name", Value: name},
+		{Name: "OS Version", Value: osRelease["VERSION_ID"]},
+		{Name: "Kernel", Value: readSysFile("/proc/sys/kernel/osrelease")},
+		{Name: "Kernel Build", Value: readSysFile("/proc/sys/kernel/version")},
+		{Name: "System Manufacturer", Value: readDMI("sys_vendor")},
+		{Name: "System Model", Value: readDMI("product_name")},
+		{Name: "System Type", Value: runtime.GOARCH},
+		{Name: "Total Physical Memory", Value: formatBytes(meminfo["MemTotal"])},
+		{Name: "Available Physical Memory", Value: formatBytes(meminfo["MemAvailable"])},
+	}
+	if bootTime := parseBootTime(); !bootTime.IsZero() {
+		properties = append(properties, Property{Name: "System Boot Time", Value: bootTime.Format("2006-01-02 15:04:05")})
+	}
+
+	var present []Property
+	for _, p := range properties {
+		if p.Value != "" {
+			present = append(present, p)
+		}
+	}
+	return present
+}
+
+// parseBootTime reads the boot time from the btime line of /proc/stat.
+func parseBootTime() time.Time {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if seconds, found := strings.CutPrefix(line, "btime "); found {
+			return time.Unix(parseInt(seconds), 0)
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,76 @@
+package modules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCPUInfo(t *testing.T) {
+	tests := []struct {
+		file string
+		want []CPUInfo
+	}{
+		// Two sockets with two logical processors each
+		{"cpuinfo_x86.txt", []CPUInfo{
+			{Name: "Intel(R) Xeon(R) Silver 4214R CPU @ 2.40GHz", Manufacturer: "GenuineIntel"},
+			{Name: "Intel(R) Xeon(R) Silver 4214R CPU @ 2.40GHz", Manufacturer: "GenuineIntel"},
+		}},
+		{"cpuinfo_arm.txt", []CPUInfo{{Name: "Raspberry Pi 4 Model B Rev 1.4"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := parseCPUInfo(readLinuxFixture(t, tt.file)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCPUInfo() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCPUInfoMalformed(t *testing.T) {
+	if got := parseCPUInfo([]byte("processor\nmodel name\n\n: orphan value\n")); got != nil {
+		t.Errorf("parseCPUInfo() = %+v, want nothing", got)
+	}
+}
+
+func TestParseDMIMemory(t *testing.T) {
+	got := parseDMIMemory(readLinuxFixture(t, "dmidecode_memory.txt"))
+	// The empty slot is skipped and placeholder names are dropped
+	want := []MemoryModule{
+		{CapacityBytes: 16 << 30, Manufacturer: "Samsung", PartNumber: "M471A2K43EB1-CWE", SpeedMHz: 3200},
+		{CapacityBytes: 8192 << 20, SpeedMHz: 2666},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDMIMemory() = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseDMIMemoryMalformed(t *testing.T) {
+	// A device without a speed, and one whose size cannot be read
+	data := "Handle 0x0040, DMI type 17, 40 bytes\nMemory Device\n\tSize: 4 GB\n\n" +
+		"Handle 0x0041, DMI type 17, 40 bytes\nMemory Device\n\tSize: lots\n\tSpeed: 2400 MT/s\n"
+	got := parseDMIMemory([]byte(data))
+	if want := []MemoryModule{{CapacityBytes: 4 << 30}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDMIMemory() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseDMISize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"512 kB", 512 << 10},
+		{"8192 MB", 8 << 30},
+		{"16 GB", 16 << 30},
+		{"1 TB", 1 << 40},
+		{"No Module Installed", 0},
+		{"16 PB", 0},
+		{"16GB", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := parseDMISize(tt.value); got != tt.want {
+			t.Errorf("parseDMISize(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
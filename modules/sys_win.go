@@ -0,0 +1,35 @@
+package modules
+
+import "context"
+
+// collectWindowsQuickSysInfo gathers basic system information like CPU, GPU and RAM.
+func collectWindowsQuickSysInfo(ctx context.Context) (*SystemInfo, error) {
+	info := &SystemInfo{}
+
+	// Collect CPU information
+	queryWMI(ctx, &info.Errors, sectionCPU, &info.CPUs, "Win32_Processor", "")
+
+	// Collect GPU information
+	queryWMI(ctx, &info.Errors, sectionGPU, &info.GPUs, "Win32_VideoController", "")
+
+	// Collect RAM information
+	queryWMI(ctx, &info.Errors, sectionRAM, &info.Memory, "Win32_PhysicalMemory", "")
+
+	// Collect Motherboard and BIOS information
+	queryWMI(ctx, &info.Errors, sectionBoard, &info.Boards, "Win32_BaseBoard", "")
+	for _, values := range wmiRecords(ctx, &info.Errors, sectionBIOS, "Win32_BIOS", "Version", "SerialNumber") {
+		info.BIOS = append(info.BIOS, BIOSInfo{Version: values["Version"], SerialNumber: values["SerialNumber"]})
+	}
+
+	// Additional system details
+	osInfo, err := runner.Output(ctx, "systeminfo")
+	if err != nil {
+		info.Errors.Add(sectionOS, err)
+	} else {
+		for _, record := range parseListRecords(osInfo, ":") {
+			info.OS = append(info.OS, record...)
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,137 @@
+package modules
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// dmiDir is where the kernel exports the SMBIOS tables. Everything in it is world-readable
+// except serial numbers, which need root.
+const dmiDir = "/sys/class/dmi/id"
+
+// readSysFile returns the trimmed contents of a /proc or /sys file, or an empty string when it
+// cannot be read.
+func readSysFile(path ...string) string {
+	data, err := os.ReadFile(filepath.Join(path...))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readSysInt reads a /proc or /sys file holding a single number, returning zero when it cannot be read.
+func readSysInt(path ...string) int64 {
+	return parseInt(readSysFile(path...))
+}
+
+// readDMI reads a field from dmiDir, dropping the placeholders vendors leave in unused fields.
+func readDMI(name string) string {
+	value := readSysFile(dmiDir, name)
+	switch strings.ToLower(value) {
+	case "default string", "to be filled by o.e.m.", "not specified", "none":
+		return ""
+	}
+	return value
+}
+
+// formatDMIDate converts an SMBIOS MM/DD/YYYY date to YYYY-MM-DD, returning other values unchanged.
+func formatDMIDate(value string) string {
+	parts := strings.Split(value, "/")
+	if len(parts) != 3 || len(parts[0]) != 2 || len(parts[1]) != 2 || len(parts[2]) != 4 {
+		return value
+	}
+	return parts[2] + "-" + parts[0] + "-" + parts[1]
+}
+
+// parseKeyValueFile parses files made of "KEY=value" lines, such as /etc/os-release and
+// .desktop entries, unquoting values. Later keys overwrite earlier ones.
+func parseKeyValueFile(data []byte) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		name, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(name)] = value
+	}
+	return values
+}
+
+// parseMemInfo parses /proc/meminfo into byte counts keyed by field name.
+func parseMemInfo(data []byte) map[string]int64 {
+	values := make(map[string]int64)
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		n := parseInt(fields[0])
+		if len(fields) > 1 && fields[1] == "kB" {
+			n *= 1024
+		}
+		values[name] = n
+	}
+	return values
+}
+
+var (
+	userNamesMu sync.Mutex
+	userNames   = make(map[string]string)
+)
+
+// lookupUserName resolves a numeric user ID to a user name, caching the result. It returns the
+// ID itself when the user is unknown.
+func lookupUserName(uid string) string {
+	userNamesMu.Lock()
+	defer userNamesMu.Unlock()
+	if name, ok := userNames[uid]; ok {
+		return name
+	}
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	userNames[uid] = name
+	return name
+}
+
+// xdgConfigHome returns $XDG_CONFIG_HOME, defaulting to ~/.config.
+func xdgConfigHome() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config")
+}
+
+// xdgConfigDirs returns the system configuration directories in $XDG_CONFIG_DIRS, defaulting to /etc/xdg.
+func xdgConfigDirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv("XDG_CONFIG_DIRS")) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		dirs = []string{"/etc/xdg"}
+	}
+	return dirs
+}
@@ -0,0 +1,87 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readLinuxFixture reads a file captured from /proc, /sys or a command under testdata/linux.
+func readLinuxFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "linux", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestFormatDMIDate(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"03/04/2024", "2024-03-04"},
+		{"2024-03-04", "2024-03-04"},
+		{"3/4/2024", "3/4/2024"},
+		{"03/04/24", "03/04/24"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := formatDMIDate(tt.value); got != tt.want {
+			t.Errorf("formatDMIDate(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseKeyValueFile(t *testing.T) {
+	got := parseKeyValueFile(readLinuxFixture(t, "os-release.txt"))
+	want := map[string]string{
+		"PRETTY_NAME":      "Ubuntu 22.04.4 LTS",
+		"NAME":             "Ubuntu",
+		"VERSION_ID":       "22.04",
+		"VERSION":          "22.04.4 LTS (Jammy Jellyfish)",
+		"VERSION_CODENAME": "jammy",
+		"ID":               "ubuntu",
+		"ID_LIKE":          "debian",
+		"HOME_URL":         "https://www.ubuntu.com/",
+		"SUPPORT_URL":      "https://help.ubuntu.com/",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeyValueFile() = %v\nwant %v", got, want)
+	}
+}
+
+func TestParseKeyValueFileMalformed(t *testing.T) {
+	got := parseKeyValueFile([]byte("not a key\r\nNAME=\"unterminated\r\nID='\r\n"))
+	want := map[string]string{"NAME": `"unterminated`, "ID": "'"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeyValueFile() = %q, want %q", got, want)
+	}
+}
+
+func TestParseMemInfo(t *testing.T) {
+	got := parseMemInfo(readLinuxFixture(t, "meminfo.txt"))
+	want := map[string]int64{
+		"MemTotal":        16312420 << 10,
+		"MemFree":         1048576 << 10,
+		"MemAvailable":    9437184 << 10,
+		"Buffers":         524288 << 10,
+		"Cached":          7340032 << 10,
+		"SwapTotal":       2097148 << 10,
+		"SwapFree":        2097148 << 10,
+		"HugePages_Total": 0,
+		"HugePages_Free":  0,
+		"Hugepagesize":    2048 << 10,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMemInfo() = %v\nwant %v", got, want)
+	}
+}
+
+func TestParseMemInfoMalformed(t *testing.T) {
+	got := parseMemInfo([]byte("MemTotal:\nno separator\nMemFree: lots kB\n"))
+	if want := map[string]int64{"MemFree": 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMemInfo() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+//go:build !windows && !linux
+
+package modules
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// errUnsupportedPlatform is returned by collectors that have no implementation for this operating system.
+var errUnsupportedPlatform = fmt.Errorf("not supported on %s", runtime.GOOS)
+
+var (
+	sectionIPConfig    = "IP Configuration"
+	sectionConnections = "Active Network Connections"
+	sectionRoutes      = "IP Routing Table"
+	sectionDNSCache    = "DNS Resolver Cache"
+	sectionPing        = "Basic Connectivity Test"
+	sectionServices    = "Services"
+)
+
+func CollectBIOSReport(ctx context.Context) (*BIOSReport, error) {
+	return nil, errUnsupportedPlatform
+}
+
+func CollectQuickSysInfo(ctx context.Context) (*SystemInfo, error) {
+	return nil, errUnsupportedPlatform
+}
+
+func CollectDriverReport(ctx context.Context) (*DriverReport, error) {
+	return nil, errUnsupportedPlatform
+}
+
+func CollectNetworkReport(ctx context.Context) (*NetworkReport, error) {
+	return nil, errUnsupportedPlatform
+}
+
+func CollectRunningProcessesReport(ctx context.Context) (*ProcessReport, error) {
+	return nil, errUnsupportedPlatform
+}
+
+func CollectHealthAndUsageReport(ctx context.Context) (*HealthReport, error) {
+	return nil, errUnsupportedPlatform
+}
+
+func CollectHardwareReport(ctx context.Context) (*HardwareReport, error) {
+	return nil, errUnsupportedPlatform
+}
+
+func CollectSoftwareReport(ctx context.Context) (*SoftwareReport, error) {
+	return nil, errUnsupportedPlatform
+}
+
+func CollectStartupProgramsReport(ctx context.Context) (*StartupReport, error) {
+	return nil, errUnsupportedPlatform
+}
+
+func CollectEventLogs(ctx context.Context) (*EventLogReport, error) {
+	return nil, errUnsupportedPlatform
+}
+
+// FlushDNSCache flushes the DNS resolver cache.
+func FlushDNSCache(ctx context.Context) error {
+	return errUnsupportedPlatform
+}
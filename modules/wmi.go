@@ -0,0 +1,360 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// WMIBackend answers WMI queries. GoDiag asks for a class, optionally filtered by a WQL condition,
+// and the properties it needs; every value comes back as a string in the form wmic prints it.
+type WMIBackend interface {
+	Query(ctx context.Context, class, where string, fields []string) ([]map[string]string, error)
+}
+
+// WMICBackend queries WMI through wmic.exe, which is fast but no longer ships with current
+// Windows 11 builds.
+type WMICBackend struct{}
+
+func (WMICBackend) Query(ctx context.Context, class, where string, fields []string) ([]map[string]string, error) {
+	args := []string{"path", class}
+	if where != "" {
+		args = append(args, "where", where)
+	}
+	args = append(args, "get", strings.Join(fields, ","), "/format:list")
+
+	output, err := runner.Output(ctx, "wmic", args...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseWMICList(output), nil
+}
+
+// CIMBackend queries WMI through PowerShell's Get-CimInstance, which is available wherever
+// GoDiag runs but takes a moment to start for every query.
+type CIMBackend struct{}
+
+func (CIMBackend) Query(ctx context.Context, class, where string, fields []string) ([]map[string]string, error) {
+	output, err := runner.Output(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", cimCommand(class, where, fields))
+	if err != nil {
+		return nil, err
+	}
+	return parseCIMJSON(output, fields)
+}
+
+// cimCommand builds the Get-CimInstance pipeline for a query. Dates are converted to the DMTF
+// strings wmic prints so both backends return the same values.
+func cimCommand(class, where string, fields []string) string {
+	command := "Get-CimInstance -ClassName " + psQuote(class)
+	if where != "" {
+		command += " -Filter " + psQuote(where)
+	}
+
+	var properties []string
+	for _, field := range fields {
+		properties = append(properties, fmt.Sprintf(
+			"@{n=%[1]s;e={$v=$_.%[1]s; if ($v -is [datetime]) {[Management.ManagementDateTimeConverter]::ToDmtfDateTime($v)} else {$v}}}",
+			psQuote(field)))
+	}
+	return command + " | Select-Object " + strings.Join(properties, ",") + " | ConvertTo-Json -Compress -Depth 2"
+}
+
+// psQuote quotes s as a literal PowerShell string.
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// parseCIMJSON decodes the JSON ConvertTo-Json prints for CIM instances: an array, a single
+// object when there is only one instance, or nothing at all when there are none. Values are
+// converted to wmic's notation (TRUE/FALSE, {a,b} for arrays) and only fields are kept, if given.
+func parseCIMJSON(data []byte, fields []string) ([]map[string]string, error) {
+	data = bytes.TrimSpace([]byte(normalizeOutput(data)))
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse CIM output: %w", err)
+	}
+
+	var instances []interface{}
+	switch value := raw.(type) {
+	case []interface{}:
+		instances = value
+	case map[string]interface{}:
+		instances = []interface{}{value}
+	default:
+		return nil, fmt.Errorf("unexpected CIM output: %s", firstLineOf(data))
+	}
+
+	var records []map[string]string
+	for _, instance := range instances {
+		properties, ok := instance.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		record := make(map[string]string, len(properties))
+		for name, value := range properties {
+			record[name] = cimValue(value)
+		}
+		if len(fields) > 0 {
+			record = selectFields(record, fields)
+		}
+		if !blankRecord(record) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func cimValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case json.Number:
+		return v.String()
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, cimValue(item))
+		}
+		return "{" + strings.Join(items, ",") + "}"
+	default:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
+
+// selectFields keeps only fields (matched without regard to case) in record, under the names
+// they were asked for.
+func selectFields(record map[string]string, fields []string) map[string]string {
+	lower := make(map[string]string, len(record))
+	for name, value := range record {
+		lower[strings.ToLower(name)] = value
+	}
+	selected := make(map[string]string, len(fields))
+	for _, field := range fields {
+		selected[field] = lower[strings.ToLower(field)]
+	}
+	return selected
+}
+
+func firstLineOf(data []byte) string {
+	line, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimSpace(line)
+}
+
+// FallbackWMIBackend tries wmic first and falls back to Get-CimInstance when wmic fails. Once
+// wmic turns out not to be installed it is not tried again.
+type FallbackWMIBackend struct {
+	WMIC WMIBackend
+	CIM  WMIBackend
+
+	mu          sync.Mutex
+	wmicMissing bool
+}
+
+// NewFallbackWMIBackend returns the default backend: wmic with a Get-CimInstance fallback.
+func NewFallbackWMIBackend() *FallbackWMIBackend {
+	return &FallbackWMIBackend{WMIC: WMICBackend{}, CIM: CIMBackend{}}
+}
+
+func (b *FallbackWMIBackend) Query(ctx context.Context, class, where string, fields []string) ([]map[string]string, error) {
+	b.mu.Lock()
+	tryWMIC := !b.wmicMissing
+	b.mu.Unlock()
+
+	var wmicErr error
+	if tryWMIC {
+		records, err := b.WMIC.Query(ctx, class, where, fields)
+		if err == nil {
+			return records, nil
+		}
+		if errors.Is(err, exec.ErrNotFound) {
+			b.mu.Lock()
+			b.wmicMissing = true
+			b.mu.Unlock()
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		wmicErr = err
+	}
+
+	records, err := b.CIM.Query(ctx, class, where, fields)
+	if err != nil && wmicErr != nil && !errors.Is(wmicErr, exec.ErrNotFound) {
+		return nil, fmt.Errorf("wmic: %v; Get-CimInstance: %w", wmicErr, err)
+	}
+	return records, err
+}
+
+// FixtureWMIBackend answers queries from JSON files, so collectors can be exercised on machines
+// without WMI. Each class is read from <class>.json (lower case) in Dir, holding what
+// `Get-CimInstance <class> | ConvertTo-Json` prints. A where clause made of Property='value'
+// conditions joined by AND is applied to the instances; anything more complex is not supported.
+type FixtureWMIBackend struct {
+	Dir string
+}
+
+// NewFixtureWMIBackend returns a backend that reads its answers from fixtures in dir.
+func NewFixtureWMIBackend(dir string) *FixtureWMIBackend {
+	return &FixtureWMIBackend{Dir: dir}
+}
+
+func (b *FixtureWMIBackend) Query(ctx context.Context, class, where string, fields []string) ([]map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(b.Dir, strings.ToLower(class)+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("no fixture for WMI class %s: %w", class, err)
+	}
+	records, err := parseCIMJSON(data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions, err := parseWhereEquals(where)
+	if err != nil {
+		return nil, err
+	}
+	var matched []map[string]string
+	for _, record := range records {
+		if matchesConditions(record, conditions) {
+			matched = append(matched, selectFields(record, fields))
+		}
+	}
+	return matched, nil
+}
+
+// parseWhereEquals parses a WQL condition of the form A='x' AND B='y'. As in WQL, a quote inside
+// a value is written twice.
+func parseWhereEquals(where string) (map[string]string, error) {
+	unsupported := fmt.Errorf("unsupported where clause in WMI fixture query: %s", where)
+	conditions := map[string]string{}
+	rest := strings.TrimSpace(where)
+	for rest != "" {
+		name, literal, found := strings.Cut(rest, "=")
+		name = strings.TrimSpace(name)
+		literal = strings.TrimLeft(literal, " ")
+		if !found || name == "" || strings.ContainsAny(name, " '") || !strings.HasPrefix(literal, "'") {
+			return nil, unsupported
+		}
+
+		var value strings.Builder
+		closed := false
+		i := 1
+		for ; i < len(literal) && !closed; i++ {
+			switch {
+			case literal[i] != '\'':
+				value.WriteByte(literal[i])
+			case i+1 < len(literal) && literal[i+1] == '\'':
+				value.WriteByte('\'')
+				i++
+			default:
+				closed = true
+			}
+		}
+		if !closed {
+			return nil, unsupported
+		}
+		conditions[strings.ToLower(name)] = value.String()
+
+		rest = strings.TrimSpace(literal[i:])
+		if rest == "" {
+			break
+		}
+		if len(rest) < 4 || !strings.EqualFold(rest[:4], "and ") {
+			return nil, unsupported
+		}
+		if rest = strings.TrimSpace(rest[4:]); rest == "" {
+			return nil, unsupported
+		}
+	}
+	return conditions, nil
+}
+
+func matchesConditions(record map[string]string, conditions map[string]string) bool {
+	for name, want := range conditions {
+		found := false
+		for property, value := range record {
+			if strings.ToLower(property) == name && strings.EqualFold(value, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	wmiMu sync.RWMutex
+	wmi   WMIBackend = NewFallbackWMIBackend()
+)
+
+// SetWMIBackend replaces the backend every WMI query goes through. Passing nil restores the
+// default wmic backend with its Get-CimInstance fallback.
+func SetWMIBackend(b WMIBackend) {
+	wmiMu.Lock()
+	defer wmiMu.Unlock()
+	if b == nil {
+		b = NewFallbackWMIBackend()
+	}
+	wmi = b
+}
+
+// QueryWMI returns the given properties of every instance of class.
+func QueryWMI(ctx context.Context, class string, fields []string) ([]map[string]string, error) {
+	return QueryWMIWhere(ctx, class, "", fields)
+}
+
+// QueryWMIWhere returns the given properties of the instances of class matching the WQL
+// condition where, such as "PNPClass='USB'".
+func QueryWMIWhere(ctx context.Context, class, where string, fields []string) ([]map[string]string, error) {
+	wmiMu.RLock()
+	backend := wmi
+	wmiMu.RUnlock()
+	return backend.Query(ctx, class, where, fields)
+}
+
+// wmiRecords runs a WMI query, recording any failure against section in errs.
+func wmiRecords(ctx context.Context, errs *SectionErrors, section, class string, fields ...string) []map[string]string {
+	records, err := QueryWMI(ctx, class, fields)
+	if err != nil {
+		errs.Add(section, err)
+	}
+	return records
+}
+
+// queryWMI asks for the properties tagged on the struct type of out, a pointer to a slice of
+// structs, and appends the matching instances of class to out. Failures are recorded against
+// section in errs.
+func queryWMI(ctx context.Context, errs *SectionErrors, section string, out interface{}, class, where string) {
+	records, err := QueryWMIWhere(ctx, class, where, wmiFields(out))
+	if err == nil {
+		err = UnmarshalWMI(records, out)
+	}
+	if err != nil {
+		errs.Add(section, err)
+	}
+}
@@ -0,0 +1,225 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestParseCIMJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		fields []string
+		want   []map[string]string
+	}{
+		{"no instances", "\r\n", nil, nil},
+		{"single object", `{"Name":"C:","Size":510770802688}`, nil, []map[string]string{
+			{"Name": "C:", "Size": "510770802688"},
+		}},
+		{"array", `[{"Name":"C:"},{"Name":"D:"}]`, nil, []map[string]string{
+			{"Name": "C:"}, {"Name": "D:"},
+		}},
+		{"nulls and bools", `{"Name":"Spooler","Started":true,"AcceptPause":false,"ExitCode":null}`, nil, []map[string]string{
+			{"Name": "Spooler", "Started": "TRUE", "AcceptPause": "FALSE", "ExitCode": ""},
+		}},
+		{"arrays", `{"Capabilities":[3,4,10],"Descriptions":["Random Access","SMART Notification"],"Nested":[[1,2],[3]],"Empty":[]}`, nil, []map[string]string{
+			{"Capabilities": "{3,4,10}", "Descriptions": "{Random Access,SMART Notification}", "Nested": "{{1,2},{3}}", "Empty": "{}"},
+		}},
+		{"objects", `{"Name":"eth0","Settings":{"MTU":1500}}`, nil, []map[string]string{
+			{"Name": "eth0", "Settings": `{"MTU":1500}`},
+		}},
+		{"large numbers", `{"Size":18446744073709551615}`, nil, []map[string]string{
+			{"Size": "18446744073709551615"},
+		}},
+		{"fields", `{"Name":"C:","FreeSpace":1,"VolumeName":"Windows"}`, []string{"name", "FileSystem"}, []map[string]string{
+			{"name": "C:", "FileSystem": ""},
+		}},
+		{"blank instances", `[{"Name":null},{"Name":"D:"},"stray"]`, nil, []map[string]string{
+			{"Name": "D:"},
+		}},
+		{"UTF-16", "\xff\xfe{\x00\"\x00N\x00\"\x00:\x00\"\x00C\x00:\x00\"\x00}\x00", nil, []map[string]string{
+			{"N": "C:"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCIMJSON([]byte(tt.data), tt.fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCIMJSON() = %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, data := range []string{`{"Name":`, `"just a string"`, `42`} {
+		if _, err := parseCIMJSON([]byte(data), nil); err == nil {
+			t.Errorf("parseCIMJSON(%q) succeeded", data)
+		}
+	}
+}
+
+func TestParseWhereEquals(t *testing.T) {
+	tests := []struct {
+		where string
+		want  map[string]string
+	}{
+		{"", map[string]string{}},
+		{"PNPClass='USB'", map[string]string{"pnpclass": "USB"}},
+		{"PNPClass = 'USB' AND Present='TRUE'", map[string]string{"pnpclass": "USB", "present": "TRUE"}},
+		{"Caption='Brand and Model' and Status='OK'", map[string]string{"caption": "Brand and Model", "status": "OK"}},
+		{"Name=''", map[string]string{"name": ""}},
+		{"Name='O''Brien''s PC'", map[string]string{"name": "O'Brien's PC"}},
+	}
+	for _, tt := range tests {
+		got, err := parseWhereEquals(tt.where)
+		if err != nil {
+			t.Errorf("parseWhereEquals(%q): %v", tt.where, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseWhereEquals(%q) = %q, want %q", tt.where, got, tt.want)
+		}
+	}
+
+	for _, where := range []string{"DriveType=3", "Name LIKE '%USB%'", "PNPClass='USB' OR PNPClass='Net'", "Name='unterminated", "Name='x' AND", "='x'", "Name='x'Status='y'"} {
+		if _, err := parseWhereEquals(where); err == nil {
+			t.Errorf("parseWhereEquals(%q) succeeded", where)
+		}
+	}
+}
+
+func TestFixtureWMIBackend(t *testing.T) {
+	backend := NewFixtureWMIBackend("testdata/wmi")
+	ctx := context.Background()
+	tests := []struct {
+		class  string
+		where  string
+		fields []string
+		want   []map[string]string
+	}{
+		{"Win32_BIOS", "", []string{"Manufacturer", "SerialNumber", "PrimaryBIOS", "BiosCharacteristics"}, []map[string]string{
+			{"Manufacturer": "LENOVO", "SerialNumber": "PF3XK2QJ", "PrimaryBIOS": "TRUE", "BiosCharacteristics": "{7,11,12}"},
+		}},
+		{"Win32_PnPEntity", "PNPClass='USB'", []string{"Caption"}, []map[string]string{
+			{"Caption": "USB Root Hub (USB 3.0)"}, {"Caption": "USB Composite Device"},
+		}},
+		// Values are compared without regard to case, as WMI does
+		{"Win32_PnPEntity", "pnpclass='usb' AND Present='TRUE'", []string{"DeviceID"}, []map[string]string{
+			{"DeviceID": `USB\ROOT_HUB30\4&2B1E3C4F&0&0`},
+		}},
+		{"Win32_PnPEntity", "PNPClass='Display'", []string{"Caption"}, nil},
+		{"win32_diskdrive", "", []string{"Model", "Size", "LastErrorCode", "MediaLoaded", "Capabilities"}, []map[string]string{
+			{"Model": "Samsung SSD 980 PRO 1TB", "Size": "1000202273280", "LastErrorCode": "", "MediaLoaded": "TRUE", "Capabilities": "{3,4,10}"},
+		}},
+		{"Win32_Battery", "", []string{"DesignCapacity"}, nil},
+	}
+	for _, tt := range tests {
+		got, err := backend.Query(ctx, tt.class, tt.where, tt.fields)
+		if err != nil {
+			t.Errorf("Query(%s, %q): %v", tt.class, tt.where, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Query(%s, %q) = %q\nwant %q", tt.class, tt.where, got, tt.want)
+		}
+	}
+
+	if _, err := backend.Query(ctx, "Win32_Fan", "", nil); err == nil {
+		t.Error("query for a class without a fixture succeeded")
+	}
+	if _, err := backend.Query(ctx, "Win32_PnPEntity", "PNPClass LIKE 'U%'", nil); err == nil {
+		t.Error("query with an unsupported where clause succeeded")
+	}
+}
+
+// stubWMIBackend answers every query with records and err, counting the queries it gets.
+type stubWMIBackend struct {
+	records []map[string]string
+	err     error
+	queries int
+}
+
+func (b *stubWMIBackend) Query(ctx context.Context, class, where string, fields []string) ([]map[string]string, error) {
+	b.queries++
+	return b.records, b.err
+}
+
+func TestFallbackWMIBackend(t *testing.T) {
+	ctx := context.Background()
+	cimRecords := []map[string]string{{"Name": "from CIM"}}
+
+	t.Run("wmic works", func(t *testing.T) {
+		wmic := &stubWMIBackend{records: []map[string]string{{"Name": "from wmic"}}}
+		cim := &stubWMIBackend{records: cimRecords}
+		backend := &FallbackWMIBackend{WMIC: wmic, CIM: cim}
+		got, err := backend.Query(ctx, "Win32_BIOS", "", nil)
+		if err != nil || got[0]["Name"] != "from wmic" || cim.queries != 0 {
+			t.Errorf("got %q, %v after %d CIM queries", got, err, cim.queries)
+		}
+	})
+
+	t.Run("wmic missing", func(t *testing.T) {
+		wmic := &stubWMIBackend{err: &exec.Error{Name: "wmic", Err: exec.ErrNotFound}}
+		cim := &stubWMIBackend{records: cimRecords}
+		backend := &FallbackWMIBackend{WMIC: wmic, CIM: cim}
+		for i := 0; i < 3; i++ {
+			got, err := backend.Query(ctx, "Win32_BIOS", "", nil)
+			if err != nil || !reflect.DeepEqual(got, cimRecords) {
+				t.Fatalf("query %d: got %q, %v", i, got, err)
+			}
+		}
+		if wmic.queries != 1 || cim.queries != 3 {
+			t.Errorf("wmic was asked %d times and CIM %d times, want 1 and 3", wmic.queries, cim.queries)
+		}
+	})
+
+	t.Run("wmic fails", func(t *testing.T) {
+		wmic := &stubWMIBackend{err: errors.New("exit status 2147749911")}
+		cim := &stubWMIBackend{records: cimRecords}
+		backend := &FallbackWMIBackend{WMIC: wmic, CIM: cim}
+		for i := 0; i < 2; i++ {
+			if _, err := backend.Query(ctx, "Win32_BIOS", "", nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// A failing query says nothing about whether wmic is installed, so it is tried again
+		if wmic.queries != 2 {
+			t.Errorf("wmic was asked %d times, want 2", wmic.queries)
+		}
+	})
+
+	t.Run("both fail", func(t *testing.T) {
+		cimErr := fmt.Errorf("Get-CimInstance : Invalid class")
+		backend := &FallbackWMIBackend{WMIC: &stubWMIBackend{err: errors.New("Invalid class")}, CIM: &stubWMIBackend{err: cimErr}}
+		_, err := backend.Query(ctx, "Win32_Nothing", "", nil)
+		if !errors.Is(err, cimErr) || err.Error() != "wmic: Invalid class; Get-CimInstance: Get-CimInstance : Invalid class" {
+			t.Errorf("got %v", err)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		cim := &stubWMIBackend{records: cimRecords}
+		backend := &FallbackWMIBackend{WMIC: &stubWMIBackend{err: context.Canceled}, CIM: cim}
+		if _, err := backend.Query(cancelled, "Win32_BIOS", "", nil); !errors.Is(err, context.Canceled) || cim.queries != 0 {
+			t.Errorf("got %v after %d CIM queries", err, cim.queries)
+		}
+	})
+}
+
+// With the default runner, a missing wmic.exe surfaces as exec.ErrNotFound from the wmic backend,
+// which is what makes the fallback stop trying it.
+func TestWMICBackendMissing(t *testing.T) {
+	SetCommandRunner(stubRunner{err: &exec.Error{Name: "wmic", Err: exec.ErrNotFound}})
+	defer SetCommandRunner(nil)
+	if _, err := (WMICBackend{}).Query(context.Background(), "Win32_BIOS", "", []string{"Manufacturer"}); !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("got %v, want exec.ErrNotFound", err)
+	}
+}
@@ -0,0 +1,161 @@
+package modules
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// wmicNoInstances is what wmic prints when a query matches nothing, such as Win32_Battery on a desktop.
+const wmicNoInstances = "No Instance(s) Available."
+
+var wmicListLine = regexp.MustCompile(`^\w+=`)
+
+// ParseWMIC parses wmic output in either of its layouts: `/format:list` ("Name=Value" lines, one
+// block per instance) or the default column-aligned table. The output may be UTF-16 (as when wmic
+// writes to a file) and may use the CRCRLF line endings wmic emits when piped. Instances whose
+// properties are all empty are dropped.
+func ParseWMIC(data []byte) []map[string]string {
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if wmicListLine.MatchString(line) {
+			return ParseWMICList(data)
+		}
+		return ParseWMICTable(data)
+	}
+	return nil
+}
+
+// ParseWMICList parses `wmic ... /format:list` output.
+func ParseWMICList(data []byte) []map[string]string {
+	var records []map[string]string
+	for _, record := range parseListRecords(data, "=") {
+		if values := propertyMap(record); !blankRecord(values) {
+			records = append(records, values)
+		}
+	}
+	return records
+}
+
+// ParseWMICTable parses wmic's default table layout, where the header line names the properties
+// and every column is padded to a fixed width. Values may contain spaces, so rows are cut at the
+// positions where the header's column names start rather than split on whitespace.
+func ParseWMICTable(data []byte) []map[string]string {
+	var lines [][]rune
+	for _, line := range strings.Split(normalizeOutput(data), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, []rune(strings.TrimRight(line, " \t")))
+		}
+	}
+	if len(lines) == 0 || strings.TrimSpace(string(lines[0])) == wmicNoInstances {
+		return nil
+	}
+
+	header := lines[0]
+	var names []string
+	var starts []int
+	for i, r := range header {
+		if r != ' ' && (i == 0 || header[i-1] == ' ') {
+			starts = append(starts, i)
+		}
+	}
+	for i, start := range starts {
+		names = append(names, strings.TrimSpace(string(header[start:columnEnd(header, starts, i)])))
+	}
+
+	var records []map[string]string
+	for _, row := range lines[1:] {
+		values := make(map[string]string, len(names))
+		for i, name := range names {
+			if starts[i] < len(row) {
+				values[name] = strings.TrimSpace(string(row[starts[i]:columnEnd(row, starts, i)]))
+			} else {
+				values[name] = ""
+			}
+		}
+		if !blankRecord(values) {
+			records = append(records, values)
+		}
+	}
+	return records
+}
+
+// columnEnd returns where column i of line ends: at the start of the next column, or the end of the line.
+func columnEnd(line []rune, starts []int, i int) int {
+	if i+1 < len(starts) && starts[i+1] < len(line) {
+		return starts[i+1]
+	}
+	return len(line)
+}
+
+func blankRecord(values map[string]string) bool {
+	for _, value := range values {
+		if value != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// UnmarshalWMI decodes WMI records, such as those returned by QueryWMI or ParseWMIC, into out,
+// which must point to a slice of structs. Struct fields are matched to WMI properties through
+// their `wmi:"PropertyName"` tags (case is ignored); untagged fields are left alone. String, integer and bool fields are supported;
+// integers tolerate thousands separators and unparsable values decode as 0.
+func UnmarshalWMI(records []map[string]string, out interface{}) error {
+	slice := reflect.ValueOf(out)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice || slice.Elem().Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("wmi: cannot unmarshal into %T, need a pointer to a slice of structs", out)
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+
+	for _, record := range records {
+		lower := make(map[string]string, len(record))
+		for name, value := range record {
+			lower[strings.ToLower(name)] = value
+		}
+
+		elem := reflect.New(elemType).Elem()
+		for i := 0; i < elemType.NumField(); i++ {
+			property, ok := elemType.Field(i).Tag.Lookup("wmi")
+			if !ok {
+				continue
+			}
+			value := lower[strings.ToLower(property)]
+
+			field := elem.Field(i)
+			switch field.Kind() {
+			case reflect.String:
+				field.SetString(value)
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				field.SetInt(parseInt(value))
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				n, _ := strconv.ParseUint(strings.ReplaceAll(value, ",", ""), 10, 64)
+				field.SetUint(n)
+			case reflect.Bool:
+				field.SetBool(strings.EqualFold(value, "TRUE"))
+			default:
+				return fmt.Errorf("wmi: unsupported type %s for field %s.%s", field.Type(), elemType.Name(), elemType.Field(i).Name)
+			}
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
+	return nil
+}
+
+// wmiFields lists the WMI properties tagged on the struct type of out, a pointer to a slice of
+// structs, in field order.
+func wmiFields(out interface{}) []string {
+	elemType := reflect.TypeOf(out).Elem().Elem()
+	var properties []string
+	for i := 0; i < elemType.NumField(); i++ {
+		if property, ok := elemType.Field(i).Tag.Lookup("wmi"); ok {
+			properties = append(properties, property)
+		}
+	}
+	return properties
+}
@@ -0,0 +1,102 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseWMIC(t *testing.T) {
+	lenovo := map[string]string{
+		"Manufacturer":      "LENOVO",
+		"ReleaseDate":       "20230628000000.000000+000",
+		"SMBIOSBIOSVersion": "N3JET35W (1.20 )",
+	}
+	tests := []struct {
+		file string
+		want []map[string]string
+	}{
+		// `wmic ... /format:list` piped, with CRCRLF line endings
+		{"bios_list.txt", []map[string]string{lenovo}},
+		// The same query redirected to a file by cmd.exe, which wmic writes as UTF-16 with a BOM
+		{"bios_list_utf16.txt", []map[string]string{lenovo}},
+		// The default table layout, with spaces inside values and empty trailing columns
+		{"diskdrive_table.txt", []map[string]string{
+			{"Model": "Samsung SSD 980 PRO 1TB", "SerialNumber": "S5GXNF0R712345K", "Size": "1000202273280", "Status": "OK"},
+			{"Model": "WDC WD20EZAZ-00GGJB0", "SerialNumber": "WD-WXB2A71KLC3F", "Size": "2000396321280", "Status": "OK"},
+			{"Model": "Generic- SD/MMC USB Device", "SerialNumber": "", "Size": "", "Status": "OK"},
+		}},
+		// An instance whose properties are all empty is dropped
+		{"printer_list_blank.txt", []map[string]string{
+			{"Name": "Microsoft Print to PDF", "PortName": "PORTPROMPT:", "Shared": "FALSE"},
+			{"Name": "HP LaserJet M402dn", "PortName": "192.168.1.40", "Shared": "TRUE"},
+		}},
+		// An indented line continues the value above it
+		{"startup_list_continuation.txt", []map[string]string{{
+			"Caption":  "Contoso Agent",
+			"Command":  `"C:\Program Files\Contoso\agent.exe" --service` + "\n" + `--log "C:\ProgramData\Contoso\agent.log"`,
+			"Location": `HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\Run`,
+		}}},
+		// What wmic prints when nothing matches
+		{"battery_none.txt", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "wmic", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ParseWMIC(data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseWMIC() = %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWMICEmpty(t *testing.T) {
+	for _, data := range []string{"", "\r\r\n\r\r\n", "\xff\xfe"} {
+		if got := ParseWMIC([]byte(data)); got != nil {
+			t.Errorf("ParseWMIC(%q) = %q, want nil", data, got)
+		}
+	}
+}
+
+func TestUnmarshalWMI(t *testing.T) {
+	type drive struct {
+		Model  string `wmi:"model"`
+		Size   uint64 `wmi:"Size"`
+		Index  int    `wmi:"Index"`
+		Shared bool   `wmi:"Shared"`
+		Note   string
+	}
+	records := []map[string]string{
+		{"Model": "Samsung SSD 980 PRO 1TB", "Size": "1000202273280", "Index": "1,024", "Shared": "TRUE", "Note": "ignored"},
+		{"Model": "Generic- SD/MMC USB Device", "Size": "", "Index": "n/a", "Shared": "FALSE"},
+	}
+	var got []drive
+	if err := UnmarshalWMI(records, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []drive{
+		{Model: "Samsung SSD 980 PRO 1TB", Size: 1000202273280, Index: 1024, Shared: true},
+		{Model: "Generic- SD/MMC USB Device"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalWMI() = %+v\nwant %+v", got, want)
+	}
+	if fields := wmiFields(&got); !reflect.DeepEqual(fields, []string{"model", "Size", "Index", "Shared"}) {
+		t.Errorf("wmiFields() = %q", fields)
+	}
+
+	var notSlice drive
+	if err := UnmarshalWMI(records, &notSlice); err == nil {
+		t.Error("UnmarshalWMI into a struct succeeded")
+	}
+	var unsupported []struct {
+		Ratio float64 `wmi:"Size"`
+	}
+	if err := UnmarshalWMI(records, &unsupported); err == nil {
+		t.Error("UnmarshalWMI into a float field succeeded")
+	}
+}
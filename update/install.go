@@ -0,0 +1,271 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// backupSuffix is appended to the executable's name to keep the replaced version until the new
+// one has started.
+const backupSuffix = ".old"
+
+// probeTimeout bounds how long the downloaded binary may take to report its version.
+const probeTimeout = 30 * time.Second
+
+// ErrNoAsset is returned by Install when the release has no file for this OS and architecture,
+// in which case the release page is the only way to update.
+var ErrNoAsset = errors.New("the release has no download for this system")
+
+// ProgressFunc is called as the download proceeds. total is -1 when the size is unknown.
+type ProgressFunc func(done, total int64)
+
+// Installer downloads a release, checks it and swaps it in for the running executable.
+type Installer struct {
+	// Executable is the binary to replace; the running one when empty.
+	Executable string
+	// PublicKey, when set, is the ed25519 key release assets must be signed with. Assets are then
+	// rejected unless their signature is valid.
+	PublicKey ed25519.PublicKey
+	// Probe, when set, runs the downloaded binary before it is swapped in and fails the update
+	// if it does not work; see ProbeVersion.
+	Probe func(ctx context.Context, path string) error
+	// Progress, when set, is told how far the download has got.
+	Progress ProgressFunc
+}
+
+// ParsePublicKey decodes a base64 ed25519 public key. An empty key is nil, which turns signature
+// checks off.
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// Install downloads the release's asset for this system next to the executable, verifies its
+// size, SHA-256 checksum and, if the installer has a public key, its signature, probes it, and
+// then replaces the executable with it. The replaced
+// executable is kept with a ".old" suffix until CleanupBackup removes it; any failure during the
+// swap puts it back. It returns the path of the installed executable, or ErrDisabled while
+// updates are turned off.
+func (in *Installer) Install(ctx context.Context, release VersionInfo) (string, error) {
+	if Disabled() {
+		return "", ErrDisabled
+	}
+	asset, ok := release.AssetFor(runtime.GOOS, runtime.GOARCH)
+	if !ok {
+		return "", ErrNoAsset
+	}
+	exe, err := in.executable()
+	if err != nil {
+		return "", err
+	}
+
+	downloaded, err := in.download(ctx, asset, filepath.Dir(exe))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(downloaded) // Already gone once it has been swapped in
+
+	if in.Probe != nil {
+		if err := in.Probe(ctx, downloaded); err != nil {
+			return "", fmt.Errorf("the downloaded version does not work: %w", err)
+		}
+	}
+	if err := swap(exe, downloaded); err != nil {
+		return "", err
+	}
+	return exe, nil
+}
+
+func (in *Installer) executable() (string, error) {
+	if in.Executable != "" {
+		return in.Executable, nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the running executable: %w", err)
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// download fetches asset into a temporary file in dir, which is on the same volume as the
+// executable so that the swap is a rename, and verifies it.
+func (in *Installer) download(ctx context.Context, asset Asset, dir string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, asset.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s", asset.Name, resp.Status)
+	}
+
+	file, err := os.CreateTemp(dir, ".godiag-update-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to save the update: %w", err)
+	}
+	path := file.Name()
+	fail := func(err error) (string, error) {
+		file.Close()
+		os.Remove(path)
+		return "", err
+	}
+
+	total := resp.ContentLength
+	if asset.Size > 0 {
+		total = asset.Size
+	}
+	hash := sha256.New()
+	var signed bytes.Buffer // The whole file, when it has to be checked against a signature
+	writers := []io.Writer{file, hash}
+	if in.PublicKey != nil {
+		writers = append(writers, &signed)
+	}
+	body := io.Reader(resp.Body)
+	if in.Progress != nil {
+		body = &progressReader{r: body, total: total, progress: in.Progress}
+	}
+	size, err := io.Copy(io.MultiWriter(writers...), body)
+	if err != nil {
+		return fail(fmt.Errorf("failed to download %s: %w", asset.Name, err))
+	}
+
+	if asset.Size > 0 && size != asset.Size {
+		return fail(fmt.Errorf("%s is %d bytes instead of %d", asset.Name, size, asset.Size))
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, asset.SHA256) {
+		return fail(fmt.Errorf("%s has SHA-256 %s instead of %s", asset.Name, sum, asset.SHA256))
+	}
+	if in.PublicKey != nil {
+		signature, err := base64.StdEncoding.DecodeString(asset.Signature)
+		if asset.Signature == "" || err != nil || !ed25519.Verify(in.PublicKey, signed.Bytes(), signature) {
+			return fail(fmt.Errorf("%s is not signed by the GoDiag release key", asset.Name))
+		}
+	}
+	if err := file.Chmod(0755); err != nil && runtime.GOOS != "windows" {
+		return fail(err)
+	}
+	if err := file.Close(); err != nil {
+		return fail(err)
+	}
+	return path, nil
+}
+
+// progressReader reports how much of a download has been read.
+type progressReader struct {
+	r        io.Reader
+	done     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	p.progress(p.done, p.total)
+	return n, err
+}
+
+// swap moves exe aside to its backup and moves replacement into its place, restoring exe if the
+// second step fails. Windows allows a running executable to be renamed, though not overwritten.
+func swap(exe, replacement string) error {
+	backup := exe + backupSuffix
+	if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove the previous backup: %w", err)
+	}
+	if err := os.Rename(exe, backup); err != nil {
+		return fmt.Errorf("failed to move the current version aside: %w", err)
+	}
+	if err := os.Rename(replacement, exe); err != nil {
+		if rollbackErr := os.Rename(backup, exe); rollbackErr != nil {
+			return fmt.Errorf("failed to install the update (%v) and to restore the current version from %s: %w", err, backup, rollbackErr)
+		}
+		return fmt.Errorf("failed to install the update: %w", err)
+	}
+	return nil
+}
+
+// Rollback puts back the version Install replaced, for when the new one cannot be started.
+func Rollback(exe string) error {
+	backup := exe + backupSuffix
+	if _, err := os.Stat(backup); err != nil {
+		return fmt.Errorf("no previous version to restore: %w", err)
+	}
+	failed := exe + ".failed"
+	os.Remove(failed)
+	if err := os.Rename(exe, failed); err != nil {
+		return fmt.Errorf("failed to move the new version aside: %w", err)
+	}
+	if err := os.Rename(backup, exe); err != nil {
+		os.Rename(failed, exe)
+		return fmt.Errorf("failed to restore the previous version: %w", err)
+	}
+	os.Remove(failed) // Still running on Windows if it started at all; removed after the next update
+	return nil
+}
+
+// Relaunch starts exe with args in the background so the caller can exit, and rolls the update
+// back if it cannot be started.
+func Relaunch(exe string, args ...string) error {
+	cmd := exec.Command(exe, args...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		if rollbackErr := Rollback(exe); rollbackErr != nil {
+			return fmt.Errorf("failed to start the new version (%v) and to restore the previous one: %w", err, rollbackErr)
+		}
+		return fmt.Errorf("failed to start the new version, the previous one was restored: %w", err)
+	}
+	return cmd.Process.Release()
+}
+
+// CleanupBackup removes the version an earlier update replaced, and a version a rollback set
+// aside, once the running executable has started. It is meant to be called at startup.
+func CleanupBackup() {
+	exe, err := (&Installer{}).executable()
+	if err != nil {
+		return
+	}
+	os.Remove(exe + backupSuffix)
+	os.Remove(exe + ".failed")
+}
+
+// ProbeVersion returns a probe for Installer that runs the downloaded binary with the "version"
+// command and checks that it reports want.
+func ProbeVersion(want string) func(ctx context.Context, path string) error {
+	return func(ctx context.Context, path string) error {
+		ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+		defer cancel()
+		output, err := exec.CommandContext(ctx, path, "version").Output()
+		if err != nil {
+			return err
+		}
+		got := strings.TrimSpace(string(output))
+		if c, err := CompareVersions(got, want); err != nil || c != 0 {
+			return fmt.Errorf("it reports version %q instead of %s", got, want)
+		}
+		return nil
+	}
+}
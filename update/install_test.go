@@ -0,0 +1,284 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// installFixture is an executable to replace and a server publishing its next version.
+type installFixture struct {
+	exe     string
+	release VersionInfo
+}
+
+const (
+	oldBinary = "GoDiag 1.1.0"
+	newBinary = "GoDiag 1.2.0"
+)
+
+func newInstallFixture(t *testing.T, served string) *installFixture {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/GoDiag.exe" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(served))
+	}))
+	t.Cleanup(server.Close)
+
+	exe := filepath.Join(t.TempDir(), "GoDiag.exe")
+	if err := os.WriteFile(exe, []byte(oldBinary), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(newBinary))
+	return &installFixture{exe: exe, release: VersionInfo{
+		Version: "1.2.0",
+		URL:     server.URL,
+		Assets: []Asset{{
+			Name:   "GoDiag.exe",
+			URL:    server.URL + "/GoDiag.exe",
+			Size:   int64(len(newBinary)),
+			SHA256: hex.EncodeToString(sum[:]),
+		}},
+	}}
+}
+
+// check fails the test unless the executable holds want and nothing but it and its backup, if
+// any, are left next to it.
+func (f *installFixture) check(t *testing.T, want string, backup bool) {
+	t.Helper()
+	data, err := os.ReadFile(f.exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("executable holds %q, want %q", data, want)
+	}
+	entries, err := os.ReadDir(filepath.Dir(f.exe))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	wantNames := "GoDiag.exe"
+	if backup {
+		wantNames += " GoDiag.exe.old"
+	}
+	if got := strings.Join(names, " "); got != wantNames {
+		t.Errorf("folder holds %s, want %s", got, wantNames)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	f := newInstallFixture(t, newBinary)
+	var probed string
+	var progress []int64
+	installer := &Installer{
+		Executable: f.exe,
+		Probe: func(ctx context.Context, path string) error {
+			data, err := os.ReadFile(path)
+			probed = string(data)
+			return err
+		},
+		Progress: func(done, total int64) {
+			if total != int64(len(newBinary)) {
+				t.Errorf("progress total %d, want %d", total, len(newBinary))
+			}
+			progress = append(progress, done)
+		},
+	}
+
+	exe, err := installer.Install(context.Background(), f.release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exe != f.exe {
+		t.Errorf("installed %s, want %s", exe, f.exe)
+	}
+	if probed != newBinary {
+		t.Errorf("probed %q, want the download", probed)
+	}
+	if len(progress) == 0 || progress[len(progress)-1] != int64(len(newBinary)) {
+		t.Errorf("progress %v does not reach %d", progress, len(newBinary))
+	}
+	f.check(t, newBinary, true)
+	if backup, err := os.ReadFile(f.exe + backupSuffix); err != nil || string(backup) != oldBinary {
+		t.Errorf("backup holds %q, %v; want the old version", backup, err)
+	}
+
+	// The new version cannot be started, so the old one is put back
+	if err := Rollback(f.exe); err != nil {
+		t.Fatal(err)
+	}
+	f.check(t, oldBinary, false)
+	if err := Rollback(f.exe); err == nil {
+		t.Error("second rollback succeeded without a backup")
+	}
+}
+
+func TestInstallRejectsDownload(t *testing.T) {
+	tests := []struct {
+		name   string
+		served string
+		edit   func(asset *Asset)
+		err    string
+	}{
+		{"hash mismatch", "GoDiag 1.2.1", nil, "has SHA-256 "},
+		{"size mismatch", newBinary + " and more", nil, "bytes instead of"},
+		{"checksum case", newBinary, func(a *Asset) { a.SHA256 = strings.ToUpper(a.SHA256) }, ""},
+		{"unknown size", newBinary, func(a *Asset) { a.Size = 0 }, ""},
+		{"missing file", newBinary, func(a *Asset) { a.URL += ".missing" }, "404 Not Found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newInstallFixture(t, tt.served)
+			if tt.edit != nil {
+				tt.edit(&f.release.Assets[0])
+			}
+			probed := false
+			installer := &Installer{Executable: f.exe, Probe: func(ctx context.Context, path string) error {
+				probed = true
+				return nil
+			}}
+
+			_, err := installer.Install(context.Background(), f.release)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				f.check(t, newBinary, true)
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Install() = %v, want an error containing %q", err, tt.err)
+			}
+			if probed {
+				t.Error("a rejected download was run")
+			}
+			f.check(t, oldBinary, false)
+		})
+	}
+}
+
+func TestInstallFailedProbe(t *testing.T) {
+	f := newInstallFixture(t, newBinary)
+	crashed := errors.New("exit status 0xc0000135")
+	installer := &Installer{Executable: f.exe, Probe: func(ctx context.Context, path string) error {
+		return crashed
+	}}
+	if _, err := installer.Install(context.Background(), f.release); !errors.Is(err, crashed) {
+		t.Fatalf("Install() = %v, want the probe's error", err)
+	}
+	// The download is deleted and the running version is left in place
+	f.check(t, oldBinary, false)
+}
+
+func TestSwapRestoresOnFailure(t *testing.T) {
+	f := newInstallFixture(t, newBinary)
+	// The replacement has gone missing by the time it is moved into place
+	if err := swap(f.exe, filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("swap of a missing replacement succeeded")
+	}
+	f.check(t, oldBinary, false)
+}
+
+func TestInstallNoAsset(t *testing.T) {
+	f := newInstallFixture(t, newBinary)
+	f.release.Assets[0].OS = "plan9"
+	if _, err := (&Installer{Executable: f.exe}).Install(context.Background(), f.release); !errors.Is(err, ErrNoAsset) {
+		t.Errorf("Install() = %v, want ErrNoAsset", err)
+	}
+	f.check(t, oldBinary, false)
+}
+
+func TestInstallDisabled(t *testing.T) {
+	f := newInstallFixture(t, newBinary)
+	SetDisabled(true)
+	defer SetDisabled(false)
+	if _, err := (&Installer{Executable: f.exe}).Install(context.Background(), f.release); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Install() = %v, want ErrDisabled", err)
+	}
+	f.check(t, oldBinary, false)
+}
+
+func TestInstallSignature(t *testing.T) {
+	releaseKey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	otherKey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{2}, ed25519.SeedSize))
+	sign := func(key ed25519.PrivateKey, data string) string {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(data)))
+	}
+
+	tests := []struct {
+		name      string
+		publicKey ed25519.PublicKey
+		signature string
+		err       string
+	}{
+		{"valid signature", releaseKey.Public().(ed25519.PublicKey), sign(releaseKey, newBinary), ""},
+		{"signed by another key", releaseKey.Public().(ed25519.PublicKey), sign(otherKey, newBinary), "is not signed by the GoDiag release key"},
+		{"signature of another file", releaseKey.Public().(ed25519.PublicKey), sign(releaseKey, oldBinary), "is not signed by the GoDiag release key"},
+		{"missing signature", releaseKey.Public().(ed25519.PublicKey), "", "is not signed by the GoDiag release key"},
+		{"malformed signature", releaseKey.Public().(ed25519.PublicKey), "not base64!", "is not signed by the GoDiag release key"},
+		// Without a release key the checksum alone vouches for the download
+		{"no release key", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newInstallFixture(t, newBinary)
+			f.release.Assets[0].Signature = tt.signature
+			probed := false
+			installer := &Installer{Executable: f.exe, PublicKey: tt.publicKey, Probe: func(ctx context.Context, path string) error {
+				probed = true
+				return nil
+			}}
+
+			_, err := installer.Install(context.Background(), f.release)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				f.check(t, newBinary, true)
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Install() = %v, want an error containing %q", err, tt.err)
+			}
+			if probed {
+				t.Error("an unsigned download was run")
+			}
+			f.check(t, oldBinary, false)
+		})
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize)).Public().(ed25519.PublicKey)
+	if parsed, err := ParsePublicKey(base64.StdEncoding.EncodeToString(key)); err != nil || !key.Equal(parsed) {
+		t.Errorf("ParsePublicKey() = %x, %v; want %x", parsed, err, key)
+	}
+	if parsed, err := ParsePublicKey(""); err != nil || parsed != nil {
+		t.Errorf("ParsePublicKey(\"\") = %x, %v; want no key", parsed, err)
+	}
+	for _, encoded := range []string{"not base64!", base64.StdEncoding.EncodeToString(key[:16])} {
+		if _, err := ParsePublicKey(encoded); err == nil {
+			t.Errorf("ParsePublicKey(%q) succeeded", encoded)
+		}
+	}
+	if _, err := ParsePublicKey(ReleasePublicKey); err != nil {
+		t.Errorf("the built-in release key is invalid: %v", err)
+	}
+}
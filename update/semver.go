@@ -0,0 +1,140 @@
+package update
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version such as 1.2.3 or 1.3.0-beta.2.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 []string // Pre-release identifiers, e.g. ["beta", "2"]; empty for a release
+	Build               string   // Build metadata after "+"; ignored when comparing
+}
+
+// ParseVersion parses a semantic version. A leading "v" is accepted, as are versions that leave
+// out the minor or patch number, such as "2" or "1.1", which count as 2.0.0 and 1.1.0.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	text := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	text, v.Build, _ = strings.Cut(text, "+")
+	text, pre, hasPre := strings.Cut(text, "-")
+
+	parts := strings.Split(text, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := parseNumericIdentifier(part)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		*numbers[i] = n
+	}
+
+	if hasPre {
+		v.Pre = strings.Split(pre, ".")
+		for _, identifier := range v.Pre {
+			if identifier == "" || strings.Trim(identifier, "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ-") != "" {
+				return Version{}, fmt.Errorf("invalid pre-release in version %q", s)
+			}
+		}
+	}
+	return v, nil
+}
+
+// parseNumericIdentifier parses a version number, which may not have leading zeros.
+func parseNumericIdentifier(s string) (int, error) {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
+
+// String formats the version without a leading "v".
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Pre) > 0 {
+		s += "-" + strings.Join(v.Pre, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// IsPrerelease reports whether the version is a pre-release such as 1.1.0-beta.1.
+func (v Version) IsPrerelease() bool {
+	return len(v.Pre) > 0
+}
+
+// Compare returns -1, 0 or 1 as v is older than, the same as or newer than other, following the
+// precedence rules of Semantic Versioning 2.0.0: a pre-release comes before its release, and
+// pre-release identifiers are compared numerically when both are numbers.
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if c := compareInts(pair[0], pair[1]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(v.Pre) == 0 && len(other.Pre) == 0:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(other.Pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Pre) && i < len(other.Pre); i++ {
+		if c := comparePrerelease(v.Pre[i], other.Pre[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(v.Pre), len(other.Pre))
+}
+
+// comparePrerelease compares two pre-release identifiers. Numeric identifiers sort before
+// alphanumeric ones.
+func comparePrerelease(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// CompareVersions compares two version strings like Version.Compare.
+func CompareVersions(a, b string) (int, error) {
+	va, err := ParseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := ParseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
@@ -0,0 +1,253 @@
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ManifestURL is where the version.json describing the latest releases is published.
+const ManifestURL = "https://raw.githubusercontent.com/LewdLillyVT/godiag/refs/heads/main/version.json"
+
+// ReleasePublicKey is the base64 ed25519 key release assets are signed with. While it is empty,
+// signatures are not checked and the SHA-256 checksum alone vouches for a download.
+const ReleasePublicKey = ""
+
+// Channel selects which releases the update check offers.
+type Channel string
+
+const (
+	ChannelStable Channel = "stable" // Releases only
+	ChannelBeta   Channel = "beta"   // Releases and pre-releases, whichever is newer
+)
+
+// Channels returns every update channel, stable first.
+func Channels() []Channel {
+	return []Channel{ChannelStable, ChannelBeta}
+}
+
+// ParseChannel parses a channel name. An empty name is the stable channel.
+func ParseChannel(name string) (Channel, error) {
+	switch channel := Channel(strings.ToLower(strings.TrimSpace(name))); channel {
+	case "", ChannelStable:
+		return ChannelStable, nil
+	case ChannelBeta:
+		return ChannelBeta, nil
+	default:
+		return "", fmt.Errorf("unknown update channel %q (expected %q or %q)", name, ChannelStable, ChannelBeta)
+	}
+}
+
+var (
+	channelMu      sync.RWMutex
+	defaultChannel = ChannelStable
+)
+
+// SetDefaultChannel sets the channel to check when none is given, the one picked in the settings.
+func SetDefaultChannel(channel Channel) {
+	channelMu.Lock()
+	defer channelMu.Unlock()
+	defaultChannel = channel
+}
+
+// DefaultChannel returns the channel set by SetDefaultChannel, stable unless changed.
+func DefaultChannel() Channel {
+	channelMu.RLock()
+	defer channelMu.RUnlock()
+	if defaultChannel == "" {
+		return ChannelStable
+	}
+	return defaultChannel
+}
+
+// Asset is a file published with a release, such as the Windows executable.
+type Asset struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	OS        string `json:"os,omitempty"`   // GOOS the asset is built for; empty for any
+	Arch      string `json:"arch,omitempty"` // GOARCH the asset is built for; empty for any
+	Size      int64  `json:"size,omitempty"`
+	SHA256    string `json:"sha256"`              // Hex-encoded checksum of the file
+	Signature string `json:"signature,omitempty"` // Base64 ed25519 signature of the file
+}
+
+// VersionInfo describes a release as published in version.json.
+type VersionInfo struct {
+	Version      string  `json:"version"`
+	URL          string  `json:"url"` // Release page
+	ReleaseNotes string  `json:"release_notes,omitempty"`
+	MinVersion   string  `json:"min_version,omitempty"` // Older versions must update
+	Mandatory    bool    `json:"mandatory,omitempty"`   // Every older version must update
+	Published    string  `json:"published,omitempty"`   // YYYY-MM-DD
+	Assets       []Asset `json:"assets,omitempty"`
+}
+
+// Validate checks that the release has a valid version and that every asset has a URL and a
+// SHA-256 checksum.
+func (v VersionInfo) Validate() error {
+	if _, err := ParseVersion(v.Version); err != nil {
+		return err
+	}
+	if v.MinVersion != "" {
+		if _, err := ParseVersion(v.MinVersion); err != nil {
+			return fmt.Errorf("min_version: %w", err)
+		}
+	}
+	for _, asset := range v.Assets {
+		if asset.Name == "" || asset.URL == "" {
+			return fmt.Errorf("release %s has an asset without a name or URL", v.Version)
+		}
+		if checksum, err := hex.DecodeString(asset.SHA256); err != nil || len(checksum) != 32 {
+			return fmt.Errorf("asset %s of release %s has an invalid SHA-256 checksum", asset.Name, v.Version)
+		}
+		if signature, err := base64.StdEncoding.DecodeString(asset.Signature); err != nil || (asset.Signature != "" && len(signature) != ed25519.SignatureSize) {
+			return fmt.Errorf("asset %s of release %s has an invalid signature", asset.Name, v.Version)
+		}
+	}
+	return nil
+}
+
+// AssetFor returns the asset built for the given GOOS and GOARCH. Assets that do not name an
+// OS or architecture match any.
+func (v VersionInfo) AssetFor(goos, goarch string) (Asset, bool) {
+	for _, asset := range v.Assets {
+		if (asset.OS == "" || asset.OS == goos) && (asset.Arch == "" || asset.Arch == goarch) {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Manifest is the layout of version.json. The top-level release is the stable one, which is all
+// versions before channels existed read; other channels are listed under "channels".
+type Manifest struct {
+	VersionInfo
+	Channels map[Channel]VersionInfo `json:"channels,omitempty"`
+}
+
+// ParseManifest parses and validates version.json.
+func ParseManifest(data []byte) (*Manifest, error) {
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse version info: %w", err)
+	}
+	if err := manifest.VersionInfo.Validate(); err != nil {
+		return nil, err
+	}
+	for channel, release := range manifest.Channels {
+		if err := release.Validate(); err != nil {
+			return nil, fmt.Errorf("channel %s: %w", channel, err)
+		}
+	}
+	return &manifest, nil
+}
+
+// Latest returns the newest release offered on channel. The stable channel only offers the
+// top-level release, and only if it is not a pre-release; the beta channel offers whichever of
+// that and the beta release is newer.
+func (m *Manifest) Latest(channel Channel) (VersionInfo, bool) {
+	stable, _ := ParseVersion(m.Version)
+	if channel == ChannelStable {
+		return m.VersionInfo, !stable.IsPrerelease()
+	}
+	if beta, ok := m.Channels[channel]; ok {
+		if version, _ := ParseVersion(beta.Version); version.Compare(stable) > 0 {
+			return beta, true
+		}
+	}
+	return m.VersionInfo, true
+}
+
+// Update is a newer release than the running one.
+type Update struct {
+	VersionInfo
+	Required bool // The running version is older than MinVersion, or the release is mandatory
+}
+
+// ErrDisabled is returned by Check and Install while updates are turned off by policy.
+var ErrDisabled = errors.New("update checks are turned off by your organization")
+
+var (
+	disabledMu sync.RWMutex
+	disabled   bool
+)
+
+// SetDisabled turns update checks off, or back on, as a machine policy requires.
+func SetDisabled(off bool) {
+	disabledMu.Lock()
+	defer disabledMu.Unlock()
+	disabled = off
+}
+
+// Disabled reports whether update checks are turned off.
+func Disabled() bool {
+	disabledMu.RLock()
+	defer disabledMu.RUnlock()
+	return disabled
+}
+
+// checkTimeout bounds the update check so a stalled connection does not hang it forever.
+const checkTimeout = 30 * time.Second
+
+// Check fetches version.json from url and returns the newest release on channel if it is newer
+// than current, or nil if current is up to date. It returns ErrDisabled while update checks are
+// turned off.
+func Check(ctx context.Context, url, current string, channel Channel) (*Update, error) {
+	if Disabled() {
+		return nil, ErrDisabled
+	}
+	running, err := ParseVersion(current)
+	if err != nil {
+		return nil, fmt.Errorf("running version: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch version info: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := ParseManifest(data)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, ok := manifest.Latest(channel)
+	if !ok {
+		return nil, nil
+	}
+	version, _ := ParseVersion(latest.Version)
+	if version.Compare(running) <= 0 {
+		return nil, nil
+	}
+
+	update := &Update{VersionInfo: latest, Required: latest.Mandatory}
+	if latest.MinVersion != "" {
+		if minVersion, _ := ParseVersion(latest.MinVersion); running.Compare(minVersion) < 0 {
+			update.Required = true
+		}
+	}
+	return update, nil
+}
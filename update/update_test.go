@@ -0,0 +1,198 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// manifestServer serves body as version.json with the given status code.
+func manifestServer(t *testing.T, status int, body string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/version.json"
+}
+
+const channelsManifest = `{
+	"version": "1.2.0",
+	"url": "https://example.com/releases/1.2.0",
+	"release_notes": "Stable release",
+	"min_version": "1.0.0",
+	"channels": {
+		"beta": {"version": "1.3.0-beta.2", "url": "https://example.com/releases/1.3.0-beta.2"}
+	}
+}`
+
+func TestCheckChannels(t *testing.T) {
+	url := manifestServer(t, http.StatusOK, channelsManifest)
+	tests := []struct {
+		name     string
+		current  string
+		channel  Channel
+		want     string // Empty when current is up to date
+		required bool
+	}{
+		{"stable", "1.1.0", ChannelStable, "1.2.0", false},
+		{"stable below min_version", "0.9.5", ChannelStable, "1.2.0", true},
+		{"stable up to date", "1.2.0", ChannelStable, "", false},
+		{"stable ignores beta", "1.2.0-beta.1", ChannelStable, "1.2.0", false},
+		{"beta", "1.2.0", ChannelBeta, "1.3.0-beta.2", false},
+		{"beta from earlier pre-release", "1.3.0-beta.1", ChannelBeta, "1.3.0-beta.2", false},
+		{"beta up to date", "1.3.0-beta.2", ChannelBeta, "", false},
+		{"beta after its release", "1.3.0", ChannelBeta, "", false},
+		{"newer build", "v1.4.0+local", ChannelBeta, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available, err := Check(context.Background(), url, tt.current, tt.channel)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case tt.want == "" && available != nil:
+				t.Errorf("offered %s, want no update", available.Version)
+			case tt.want != "" && available == nil:
+				t.Errorf("offered no update, want %s", tt.want)
+			case available != nil && (available.Version != tt.want || available.Required != tt.required):
+				t.Errorf("offered %s (required %t), want %s (required %t)", available.Version, available.Required, tt.want, tt.required)
+			}
+		})
+	}
+}
+
+func TestCheckBetaFallsBackToStable(t *testing.T) {
+	// A beta channel left behind by a newer stable release, and a manifest without channels
+	for _, body := range []string{
+		`{"version": "1.3.0", "url": "https://example.com/1.3.0", "channels": {"beta": {"version": "1.3.0-rc.1", "url": "https://example.com/1.3.0-rc.1"}}}`,
+		`{"version": "1.3.0", "url": "https://example.com/1.3.0"}`,
+	} {
+		available, err := Check(context.Background(), manifestServer(t, http.StatusOK, body), "1.2.0", ChannelBeta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if available == nil || available.Version != "1.3.0" {
+			t.Errorf("offered %+v, want 1.3.0", available)
+		}
+	}
+}
+
+func TestCheckStableSkipsPrerelease(t *testing.T) {
+	url := manifestServer(t, http.StatusOK, `{"version": "2.0.0-rc.1", "url": "https://example.com/2.0.0-rc.1"}`)
+	available, err := Check(context.Background(), url, "1.9.0", ChannelStable)
+	if err != nil || available != nil {
+		t.Errorf("Check() = %+v, %v; want no update", available, err)
+	}
+}
+
+func TestCheckMandatory(t *testing.T) {
+	url := manifestServer(t, http.StatusOK, `{"version": "1.2.1", "url": "https://example.com/1.2.1", "mandatory": true}`)
+	available, err := Check(context.Background(), url, "1.2.0", ChannelStable)
+	if err != nil || available == nil || !available.Required {
+		t.Errorf("Check() = %+v, %v; want a required update", available, err)
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		current string
+		err     string
+	}{
+		{"not found", http.StatusNotFound, "404: Not Found", "1.0.0", "404 Not Found"},
+		{"server error", http.StatusInternalServerError, channelsManifest, "1.0.0", "500 Internal Server Error"},
+		{"malformed JSON", http.StatusOK, `{"version": "1.2.0",`, "1.0.0", "failed to parse version info"},
+		{"HTML page", http.StatusOK, "<html><body>Moved</body></html>", "1.0.0", "failed to parse version info"},
+		{"invalid version", http.StatusOK, `{"version": "one point two"}`, "1.0.0", `invalid version "one point two"`},
+		{"invalid beta", http.StatusOK, `{"version": "1.2.0", "channels": {"beta": {"version": "1.3.x"}}}`, "1.0.0", "channel beta"},
+		{"invalid checksum", http.StatusOK, `{"version": "1.2.0", "assets": [{"name": "GoDiag.exe", "url": "https://example.com/GoDiag.exe", "sha256": "abc"}]}`, "1.0.0", "invalid SHA-256 checksum"},
+		{"invalid signature", http.StatusOK, `{"version": "1.2.0", "assets": [{"name": "GoDiag.exe", "url": "https://example.com/GoDiag.exe", "sha256": "0000000000000000000000000000000000000000000000000000000000000000", "signature": "c2lnbmVk"}]}`, "1.0.0", "invalid signature"},
+		{"invalid running version", http.StatusOK, channelsManifest, "dev", "running version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Check(context.Background(), manifestServer(t, tt.status, tt.body), tt.current, ChannelBeta)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Check() = %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestCheckDisabled(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		w.Write([]byte(channelsManifest))
+	}))
+	defer server.Close()
+
+	SetDisabled(true)
+	defer SetDisabled(false)
+	if _, err := Check(context.Background(), server.URL, "1.0.0", ChannelStable); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Check() = %v, want ErrDisabled", err)
+	}
+	if requested {
+		t.Error("Check() fetched the manifest while disabled")
+	}
+}
+
+func TestParseChannel(t *testing.T) {
+	tests := map[string]Channel{"": ChannelStable, "stable": ChannelStable, " Beta ": ChannelBeta}
+	for name, want := range tests {
+		if got, err := ParseChannel(name); err != nil || got != want {
+			t.Errorf("ParseChannel(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseChannel("nightly"); err == nil {
+		t.Error(`ParseChannel("nightly") succeeded`)
+	}
+}
+
+func TestDefaultChannel(t *testing.T) {
+	defer SetDefaultChannel(DefaultChannel())
+	SetDefaultChannel(ChannelBeta)
+	if got := DefaultChannel(); got != ChannelBeta {
+		t.Errorf("DefaultChannel() = %q, want beta", got)
+	}
+	SetDefaultChannel("")
+	if got := DefaultChannel(); got != ChannelStable {
+		t.Errorf("DefaultChannel() = %q after clearing it, want stable", got)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	// Each version is older than the next, in the order of the Semantic Versioning spec
+	ordered := []string{
+		"0.9.0", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.9", "1.0.10", "1.1.0-beta.1", "1.1", "v2",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			got, err := CompareVersions(ordered[i], ordered[j])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := compareInts(i, j); got != want {
+				t.Errorf("CompareVersions(%q, %q) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+
+	if c, err := CompareVersions("1.0.0+build.5", "1.0.0"); err != nil || c != 0 {
+		t.Errorf("build metadata changed the order: %d, %v", c, err)
+	}
+	for _, invalid := range []string{"", "1.2.3.4", "01.2.3", "1.2.x", "1.2.3-", "1.2.3-beta..1", "1.2.3-beta_1"} {
+		if _, err := ParseVersion(invalid); err == nil {
+			t.Errorf("ParseVersion(%q) succeeded", invalid)
+		}
+	}
+}